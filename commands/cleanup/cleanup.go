@@ -19,10 +19,12 @@ package cleanup
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 
 	"github.com/apache/skywalking-infra-e2e/internal/components/cleanup"
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
 
 	"github.com/spf13/cobra"
 
@@ -43,17 +45,51 @@ var Cleanup = &cobra.Command{
 }
 
 func DoCleanupAccordingE2E() error {
+	// clean up all the environments even if some of them failed, in the reverse order of the creation,
+	// so that the environments depending on the ones created before are torn down first
+	var errs []string
+	environments := config.GlobalConfig.E2EConfig.Setup.GetEnvironments()
+	for i := len(environments) - 1; i >= 0; i-- {
+		environment := environments[i]
+		if err := cleanupEnvironment(environment); err != nil {
+			if environment.Name != "" {
+				err = fmt.Errorf("environment %s: %v", environment.Name, err)
+			}
+			errs = append(errs, err.Error())
+		}
+	}
+	setup.RestoreKubeConfig()
+	if err := setup.RunHook("cleanup.after", config.GlobalConfig.E2EConfig.Cleanup.After); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func cleanupEnvironment(environment *config.Environment) error {
 	e2eConfig := config.GlobalConfig.E2EConfig
+	e2eConfig.Setup = environment.Setup
+
+	setup.SetEnvironment(environment.Name)
+	defer setup.SetEnvironment("")
 
 	switch e2eConfig.Setup.Env {
 	case constant.Kind:
 		kubeConfigPath := e2eConfig.Setup.GetKubeconfig()
+		// the copy of the kubeconfig with the context switched is removed even if it fails to delete the manifests
+		defer setup.RemoveContextKubeConfigFile(&e2eConfig.Setup)
 		// if there is an existing kubernetes cluster, don't delete the kind cluster.
 		if kubeConfigPath == "" {
 			err := cleanup.KindCleanUp(&e2eConfig)
 			if err != nil {
 				return err
 			}
+		} else if e2eConfig.Cleanup.DeleteManifests {
+			if err := setup.DeleteSetupManifests(&e2eConfig.Setup); err != nil {
+				return err
+			}
 		}
 	case constant.Compose:
 		err := cleanup.ComposeCleanUp(&e2eConfig)
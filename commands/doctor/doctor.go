@@ -0,0 +1,182 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+const (
+	checkTimeout = 10 * time.Second
+
+	composeExecutable = "docker-compose"
+	kubectlExecutable = "kubectl"
+)
+
+// check is an item of the pre-flight environment checks, the failures of the optional checks are reported as warnings.
+type check struct {
+	name     string
+	required bool
+	run      func() (string, error)
+}
+
+// Doctor checks the tools and services required by the e2e are available before running.
+var Doctor = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment is ready to run the e2e, such as the docker daemon and the required command lines",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failed := 0
+		for _, c := range checks(requiredEnvs()) {
+			result, err := c.run()
+			switch {
+			case err == nil:
+				fmt.Printf("[PASS] %s: %s\n", c.name, result)
+			case c.required:
+				failed++
+				fmt.Printf("[FAIL] %s: %v\n", c.name, err)
+			default:
+				fmt.Printf("[WARN] %s: %v\n", c.name, err)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("[Doctor] %d required check(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+// requiredEnvs returns the environment types declared in the config file, all the types are required
+// if the config file could not be read.
+func requiredEnvs() map[string]bool {
+	if config.GlobalConfig.Error != nil {
+		return map[string]bool{constant.Kind: true, constant.Compose: true}
+	}
+	envs := make(map[string]bool)
+	for _, environment := range config.GlobalConfig.E2EConfig.Setup.GetEnvironments() {
+		envs[environment.Env] = true
+	}
+	return envs
+}
+
+func checks(envs map[string]bool) []check {
+	return []check{
+		{name: "docker daemon", required: envs[constant.Kind] || envs[constant.Compose], run: checkDocker},
+		{name: "kind", required: envs[constant.Kind], run: checkKind},
+		{name: composeExecutable, required: envs[constant.Compose], run: checkCompose},
+		// kubectl is only used by the steps of the users
+		{name: kubectlExecutable, required: false, run: checkKubectl},
+	}
+}
+
+// checkKind checks the kind binary of `kind.binary` if it's set, otherwise the built-in kind,
+// which creates the clusters by the docker daemon.
+func checkKind() (string, error) {
+	kindSetup := &config.GlobalConfig.E2EConfig.Setup.Kind
+	version, err := setup.KindVersion(kindSetup)
+	if err != nil {
+		return "", err
+	}
+	if binary := kindSetup.GetBinary(); binary != "" {
+		return fmt.Sprintf("%s %s", binary, version), nil
+	}
+	return fmt.Sprintf("built-in %s", version), nil
+}
+
+// checkDocker pings the docker daemon of `compose.docker-host` if it's set, otherwise the one from the environment, such as DOCKER_HOST.
+func checkDocker() (string, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if host := config.GlobalConfig.E2EConfig.Setup.Compose.GetDockerHost(); host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	if _, err := cli.Ping(ctx); err != nil {
+		return "", fmt.Errorf("the docker daemon is not reachable, is it running? %v", err)
+	}
+	version, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("version %s, API version %s", version.Version, version.APIVersion), nil
+}
+
+func checkCompose() (string, error) {
+	output, err := runCommand(composeExecutable, "version", "--short")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("version %s", output), nil
+}
+
+func checkKubectl() (string, error) {
+	output, err := runCommand(kubectlExecutable, "version", "--client", "-o", "json")
+	if err != nil {
+		return "", err
+	}
+	return kubectlClientVersion(output)
+}
+
+// kubectlClientVersion parses the client version from the JSON output of `kubectl version`.
+func kubectlClientVersion(output string) (string, error) {
+	var version struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err := json.Unmarshal([]byte(output), &version); err != nil {
+		return "", fmt.Errorf("failed to parse the kubectl version: %v", err)
+	}
+	if version.ClientVersion.GitVersion == "" {
+		return "", fmt.Errorf("unknown kubectl version: %s", output)
+	}
+	return fmt.Sprintf("version %s", version.ClientVersion.GitVersion), nil
+}
+
+// runCommand runs the command line with the timeout, returns the trimmed stdout.
+func runCommand(name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%s is not found in the PATH", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s %s: %v", name, strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
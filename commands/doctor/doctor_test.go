@@ -0,0 +1,65 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package doctor
+
+import (
+	"testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+func TestKubectlClientVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "should parse the client version",
+			output: `{"clientVersion": {"major": "1", "minor": "28", "gitVersion": "v1.28.2"}, "kustomizeVersion": "v5.0.4"}`,
+			want:   "version v1.28.2",
+		},
+		{name: "should fail when the output is not JSON", output: "Client Version: v1.28.2", wantErr: true},
+		{name: "should fail when the version is missing", output: `{}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kubectlClientVersion(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("kubectlClientVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("kubectlClientVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksRequired(t *testing.T) {
+	required := make(map[string]bool)
+	for _, c := range checks(map[string]bool{constant.Compose: true}) {
+		required[c.name] = c.required
+	}
+	if !required["docker daemon"] || !required[composeExecutable] {
+		t.Errorf("docker daemon and %s should be required by the compose environment", composeExecutable)
+	}
+	if required["kind"] || required[kubectlExecutable] {
+		t.Errorf("kind and %s should not be required by the compose environment", kubectlExecutable)
+	}
+}
@@ -18,13 +18,17 @@
 package commands
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/apache/skywalking-infra-e2e/commands/cleanup"
+	"github.com/apache/skywalking-infra-e2e/commands/doctor"
 	"github.com/apache/skywalking-infra-e2e/commands/run"
+	"github.com/apache/skywalking-infra-e2e/commands/schema"
 	"github.com/apache/skywalking-infra-e2e/commands/setup"
 	"github.com/apache/skywalking-infra-e2e/commands/trigger"
 	"github.com/apache/skywalking-infra-e2e/commands/verify"
@@ -34,8 +38,15 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 )
 
+const (
+	defaultWaitTimeoutEnv = "E2E_DEFAULT_WAIT_TIMEOUT"
+	singleWaitTimeoutEnv  = "E2E_SINGLE_WAIT_TIMEOUT"
+)
+
 var (
-	verbosity string
+	verbosity  string
+	failFast   bool
+	noFailFast bool
 )
 
 // Root represents the base command when called without any subcommands
@@ -46,7 +57,17 @@ var Root = &cobra.Command{
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// the timeouts are used when reading the config file
+		if err := durationFromEnv(cmd, "default-wait-timeout", defaultWaitTimeoutEnv, &constant.DefaultWaitTimeout); err != nil {
+			return err
+		}
+		if err := durationFromEnv(cmd, "single-wait-timeout", singleWaitTimeoutEnv, &constant.SingleDefaultWaitTimeout); err != nil {
+			return err
+		}
 		config.ReadGlobalConfigFile()
+		if err := overrideFailFast(cmd); err != nil {
+			return err
+		}
 
 		level, err := logrus.ParseLevel(verbosity)
 		if err != nil {
@@ -66,10 +87,43 @@ var Root = &cobra.Command{
 			return err
 		}
 
+		if util.EnvFileOut != "" {
+			util.EnvFileOut = util.ExpandFilePath(util.EnvFileOut)
+		}
+
 		return nil
 	},
 }
 
+// durationFromEnv sets the duration from the environment variable if the flag is not set explicitly.
+func durationFromEnv(cmd *cobra.Command, flag, env string, duration *time.Duration) error {
+	value, exists := os.LookupEnv(env)
+	if !exists || cmd.Flags().Changed(flag) {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", env, err)
+	}
+	*duration = d
+	return nil
+}
+
+// overrideFailFast overrides the verify.fail-fast in the config file by the flags.
+func overrideFailFast(cmd *cobra.Command) error {
+	failFastChanged, noFailFastChanged := cmd.Flags().Changed("fail-fast"), cmd.Flags().Changed("no-fail-fast")
+	if failFastChanged && noFailFastChanged {
+		return fmt.Errorf("--fail-fast and --no-fail-fast can not be set at the same time")
+	}
+	if failFastChanged {
+		config.GlobalConfig.E2EConfig.Verify.FailFast = failFast
+	}
+	if noFailFastChanged {
+		config.GlobalConfig.E2EConfig.Verify.FailFast = !noFailFast
+	}
+	return nil
+}
+
 func ExpandPathAndCreate(path string) (string, error) {
 	path = util.ExpandFilePath(path)
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -88,11 +142,25 @@ func Execute() error {
 	Root.AddCommand(trigger.Trigger)
 	Root.AddCommand(verify.Verify)
 	Root.AddCommand(cleanup.Cleanup)
+	Root.AddCommand(schema.Schema)
+	Root.AddCommand(doctor.Doctor)
 
 	Root.PersistentFlags().StringVarP(&verbosity, "verbosity", "v", logrus.InfoLevel.String(), "log level (debug, info, warn, error, fatal, panic")
 	Root.PersistentFlags().StringVarP(&util.WorkDir, "work-dir", "w", "~/.skywalking-infra-e2e", "the working directory for skywalking-infra-e2e")
 	Root.PersistentFlags().StringVarP(&util.LogDir, "log-dir", "l", "~/.skywalking-infra-e2e/logs", "the container logs directory for environment")
 	Root.PersistentFlags().StringVarP(&util.CfgFile, "config", "c", constant.E2EDefaultFile, "the config file")
+	Root.PersistentFlags().DurationVar(&constant.DefaultWaitTimeout, "default-wait-timeout", constant.DefaultWaitTimeout,
+		"the default timeout of the setup if setup.timeout is not set, could also be set by env "+defaultWaitTimeoutEnv)
+	Root.PersistentFlags().DurationVar(&constant.SingleDefaultWaitTimeout, "single-wait-timeout", constant.SingleDefaultWaitTimeout,
+		"the timeout of each wait condition in the setup steps, could also be set by env "+singleWaitTimeoutEnv)
+	Root.PersistentFlags().BoolVar(&failFast, "fail-fast", false,
+		"stop verifying the other cases when a case fails, overrides verify.fail-fast in the config file")
+	Root.PersistentFlags().BoolVar(&noFailFast, "no-fail-fast", false,
+		"verify all the cases and report the failures at the end, overrides verify.fail-fast in the config file")
+	Root.PersistentFlags().StringVar(&util.EnvFileOut, "env-file-out", "",
+		"the dotenv file to write all the env vars exported by the setup into as they're set, such as the hosts and ports of the services")
+	Root.PersistentFlags().StringVar(&util.LogsSince, "since", constant.LogsSinceTrigger,
+		"the start point of the logs exported on failure, could be trigger (the time when the trigger started), all, a duration such as 10m or a RFC3339 timestamp")
 	Root.PersistentFlags().BoolVarP(&util.BatchMode, "batch-mode", "B", false,
 		`whether to run in batch mode, if true, all interactive operations are disabled, including real-time progress bar.
 This option is always enabled in concurrency mode and in our GitHub Actions.`)
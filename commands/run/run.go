@@ -18,23 +18,60 @@
 package run
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/apache/skywalking-infra-e2e/commands/cleanup"
 	"github.com/apache/skywalking-infra-e2e/commands/setup"
 	"github.com/apache/skywalking-infra-e2e/commands/trigger"
 	"github.com/apache/skywalking-infra-e2e/commands/verify"
+	s "github.com/apache/skywalking-infra-e2e/internal/components/setup"
 	t "github.com/apache/skywalking-infra-e2e/internal/components/trigger"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	phaseSetup   = "setup"
+	phaseTrigger = "trigger"
+	phaseVerify  = "verify"
 )
 
+var (
+	runTimeout    time.Duration
+	keepOnTimeout bool
+	pauseAfter    []string
+)
+
+func init() {
+	Run.Flags().DurationVar(&runTimeout, "timeout", 0, "the deadline of the whole run, the run fails and cleans up when it's hit, no deadline by default")
+	Run.Flags().BoolVar(&keepOnTimeout, "keep-on-timeout", false,
+		"keep the environment and print the access info instead of cleaning up when the deadline of --timeout is hit, for debugging")
+	Run.Flags().BoolVar(&s.Recreate, "recreate", false,
+		"deploy the compose environments again even if they're running already, rather than reusing them")
+	Run.Flags().StringSliceVar(&pauseAfter, "pause-after", nil,
+		"pause after the phases, such as setup,trigger, and wait for enter before proceeding, for inspecting the live state, only when stdin is a terminal")
+}
+
 var Run = &cobra.Command{
 	Use:   "run",
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		err := runAccordingE2E()
+		if err := validatePauseAfter(pauseAfter, runTimeout); err != nil {
+			return err
+		}
+		err := runWithDeadline(runAccordingMatrix, runTimeout, onDeadline)
 		if err != nil {
 			return err
 		}
@@ -43,10 +80,195 @@ var Run = &cobra.Command{
 	},
 }
 
-func runAccordingE2E() error {
+// runWithDeadline runs the e2e until the deadline, the context of the run is cancelled when the deadline is hit before the run finishes,
+// and the onDeadline is called after the run stops, so that the run doesn't race with the cleanup.
+func runWithDeadline(run func(ctx context.Context) error, timeout time.Duration, onDeadline func()) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if timeout <= 0 {
+		return run(ctx)
+	}
+
+	finished := make(chan error, 1)
+	go func() {
+		finished <- run(ctx)
+	}()
+
+	select {
+	case err := <-finished:
+		return err
+	case <-time.After(timeout):
+		logger.Log.Errorf("the run didn't finish within the deadline %s, stopping it", timeout)
+		cancel()
+		if err := <-finished; err != nil {
+			logger.Log.Warnf("the run stopped by the deadline: %v", err)
+		}
+		onDeadline()
+		return &e2eerrors.WaitTimeoutError{Resource: "run", Condition: "finished", Timeout: timeout}
+	}
+}
+
+// onDeadline keeps the environment for debugging if --keep-on-timeout is set, otherwise cleans up the environment
+// unless the cleanup is disabled by `cleanup.on: never`.
+func onDeadline() {
+	if keepOnTimeout {
+		logger.Log.Warnf("the environment is kept for debugging because of --keep-on-timeout, please clean it up by `e2e cleanup`")
+		printAccessInfo(s.ExportedEnv())
+		// the port-forwards are stopped once the process exits, hold them and the compose projects until the signal
+		if setup.ShouldWaitSignal() {
+			logger.Log.Infof("holding the kept environment, press ctrl+c to exit")
+			setup.WaitSignal()
+			s.KindCleanNotify()
+		}
+		return
+	}
+
+	if config.GlobalConfig.E2EConfig.Cleanup.On != constant.CleanUpNever {
+		doCleanup(nil)
+	}
+}
+
+// validatePauseAfter checks the phases of --pause-after are the phases of the run, and it's not combined with --timeout,
+// as the deadline would fire while waiting for enter and tear down the environment under inspection.
+func validatePauseAfter(phases []string, timeout time.Duration) error {
+	if len(phases) > 0 && timeout > 0 {
+		return fmt.Errorf("--pause-after can't be combined with --timeout, the deadline would be hit while paused")
+	}
+	for _, phase := range phases {
+		if phase != phaseSetup && phase != phaseTrigger && phase != phaseVerify {
+			return fmt.Errorf("unsupported phase %q of --pause-after, should be one of %v", phase, []string{phaseSetup, phaseTrigger, phaseVerify})
+		}
+	}
+	return nil
+}
+
+// pauseAfterPhase blocks with a prompt after the phase until enter is pressed if the phase is in --pause-after, so that the live state
+// could be inspected at the precise moment, such as the timing-dependent issues which vanish once the environment is torn down.
+// It never blocks the non-interactive runs, such as in CI, whose stdin is not a terminal.
+func pauseAfterPhase(phase string, stdin *os.File) {
+	if !shouldPause(pauseAfter, phase) {
+		return
+	}
+	if !term.IsTerminal(int(stdin.Fd())) {
+		logger.Log.Warnf("don't pause after the %s phase because the stdin is not a terminal", phase)
+		return
+	}
+
+	printAccessInfo(s.ExportedEnv())
+	fmt.Fprintf(os.Stderr, "paused after the %s phase, press enter to continue...", phase)
+	_, _ = bufio.NewReader(stdin).ReadString('\n')
+}
+
+func shouldPause(phases []string, phase string) bool {
+	for _, p := range phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// printAccessInfo prints the exported env vars of the kept environment, such as the kubeconfig and the service hosts and ports.
+func printAccessInfo(env map[string]string) {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("export %s=%s", key, env[key]))
+	}
+	logger.Log.Infof("access the kept environment by the env vars:\n%s", strings.Join(lines, "\n"))
+}
+
+// runAccordingMatrix runs the e2e with each variable set of the matrix in order, and reports the result of each one,
+// the e2e is run once if there is no matrix. The run stops when the context is cancelled.
+func runAccordingMatrix(ctx context.Context) error {
 	if config.GlobalConfig.Error != nil {
 		return config.GlobalConfig.Error
 	}
+	defer util.SetRunContext(ctx)()
+
+	matrix := config.GlobalConfig.E2EConfig.Matrix
+	if len(matrix) == 0 {
+		return runAccordingE2E(ctx)
+	}
+
+	failed := make([]string, 0)
+	results := make([]string, 0, len(matrix))
+	for _, variables := range matrix {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := matrixName(variables)
+		logger.Log.Infof("running the matrix combination [%s]", name)
+		if err := runWithVariables(ctx, variables); err != nil {
+			logger.Log.Errorf("the matrix combination [%s] failed: %v", name, err)
+			failed = append(failed, name)
+			results = append(results, fmt.Sprintf("[%s] failed: %v", name, err))
+			continue
+		}
+		results = append(results, fmt.Sprintf("[%s] passed", name))
+	}
+
+	logger.Log.Infof("matrix results, %d passed, %d failed:\n%s",
+		len(matrix)-len(failed), len(failed), strings.Join(results, "\n"))
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d matrix combinations failed: %s", len(failed), len(matrix), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// runWithVariables exports the variables during the run, and restores the previous values after that.
+func runWithVariables(ctx context.Context, variables map[string]string) error {
+	previous := make(map[string]*string, len(variables))
+	defer func() {
+		for key, value := range previous {
+			if value == nil {
+				_ = os.Unsetenv(key)
+			} else {
+				_ = os.Setenv(key, *value)
+			}
+		}
+	}()
+
+	for key, value := range variables {
+		if v, exists := os.LookupEnv(key); exists {
+			previous[key] = &v
+		} else {
+			previous[key] = nil
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("could not export the matrix variable %s: %v", key, err)
+		}
+	}
+	return runAccordingE2E(ctx)
+}
+
+// matrixName formats the variable set as `k1=v1, k2=v2` sorted by the keys.
+func matrixName(variables map[string]string) string {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, variables[key]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func runAccordingE2E(ctx context.Context) error {
+	if config.GlobalConfig.Error != nil {
+		return config.GlobalConfig.Error
+	}
+
+	// the KUBECONFIG is restored even if the environment is not cleaned up, so that the next run of the matrix,
+	// or the process embedding the run, doesn't target the cluster of this run by mistake
+	defer setup.RestoreKubeConfig()
 
 	var action t.Action
 	stopAction := func() {
@@ -57,18 +279,34 @@ func runAccordingE2E() error {
 	// If cleanup.on == Always and there is error in setup step, we should defer cleanup step right now.
 	cleanupOnCondition := config.GlobalConfig.E2EConfig.Cleanup.On
 	if cleanupOnCondition == constant.CleanUpAlways {
-		defer doCleanup(stopAction)
+		defer func() {
+			// the environment is cleaned up or kept by onDeadline if the run is stopped by the deadline
+			if ctx.Err() != nil {
+				stopAction()
+				return
+			}
+			doCleanup(stopAction)
+		}()
 	}
 
 	// setup part
 	err := setup.DoSetupAccordingE2E()
 	if err != nil {
+		setup.ExportLogsOnFailure()
 		return err
 	}
 	logger.Log.Infof("setup part finished successfully")
+	pauseAfterPhase(phaseSetup, os.Stdin)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
 	if cleanupOnCondition != constant.CleanUpAlways {
 		defer func() {
+			if ctx.Err() != nil {
+				stopAction()
+				return
+			}
 			shouldCleanup := (cleanupOnCondition == constant.CleanUpOnSuccess && err == nil) ||
 				(cleanupOnCondition == constant.CleanUpOnFailure && err != nil)
 
@@ -80,30 +318,83 @@ func runAccordingE2E() error {
 			doCleanup(stopAction)
 		}()
 	}
+	// export the logs before the deferred cleanup deletes the clusters
+	defer func() {
+		if err != nil {
+			setup.ExportLogsOnFailure()
+		}
+	}()
 
-	// trigger part
-	action, err = trigger.CreateTriggerAction()
-	if err != nil {
-		return err
-	}
-	if action != nil {
-		err = <-action.Do()
+	err = retryTriggerAndVerify(config.GlobalConfig.E2EConfig.Verify.TriggerRetry, func(attempt int) error {
+		// stop the trigger of the previous attempt before triggering again
+		stopAction()
+		action = nil
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// trigger part
+		newAction, err := trigger.CreateTriggerAction()
 		if err != nil {
 			return err
 		}
-		logger.Log.Infof("trigger part started successfully")
-	} else {
-		logger.Log.Infof("no trigger need to execute")
-	}
+		action = newAction
+		if action != nil {
+			select {
+			case err := <-action.Do():
+				if err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			logger.Log.Infof("trigger part started successfully")
+		} else {
+			logger.Log.Infof("no trigger need to execute")
+		}
+		pauseAfterPhase(phaseTrigger, os.Stdin)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-	// verify part
-	err = verify.DoVerifyAccordingConfig()
-	if err != nil {
-		return err
+		// verify part, the steps are executed only once as they may change the environment, such as deleting a pod
+		if attempt == 0 {
+			if err := verify.DoVerifyStepsAccordingConfig(); err != nil {
+				return err
+			}
+		}
+		if err := verify.DoVerifyAccordingConfig(); err != nil {
+			return err
+		}
+		logger.Log.Infof("verify part finished successfully")
+		return nil
+	})
+	// pause before the deferred cleanup, the failed verification could be inspected too
+	pauseAfterPhase(phaseVerify, os.Stdin)
+	return err
+}
+
+// retryTriggerAndVerify runs the trigger and verify block, and re-runs it up to the count of the retry on failure.
+func retryTriggerAndVerify(retry config.TriggerRetry, block func(attempt int) error) error {
+	var interval time.Duration
+	if retry.Interval != "" {
+		var err error
+		if interval, err = time.ParseDuration(retry.Interval); err != nil {
+			return fmt.Errorf("failed to parse verify.trigger-retry.interval %s: %v", retry.Interval, err)
+		}
 	}
-	logger.Log.Infof("verify part finished successfully")
 
-	return nil
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = block(attempt); err == nil || attempt >= retry.Count {
+			return err
+		}
+		logger.Log.Warnf("the trigger and verify failed at attempt %d/%d: %v, re-triggering in %s",
+			attempt+1, retry.Count+1, err, interval)
+		if sleepErr := util.Sleep(interval); sleepErr != nil {
+			return err
+		}
+	}
 }
 
 func doCleanup(stopAction func()) {
@@ -0,0 +1,152 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package run
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+func TestRunWithDeadline(t *testing.T) {
+	failure := errors.New("verify failed")
+	tests := []struct {
+		name         string
+		run          func(ctx context.Context) error
+		timeout      time.Duration
+		wantErr      error
+		wantDeadline bool
+	}{
+		{name: "should run without deadline", run: func(context.Context) error { return failure }, wantErr: failure},
+		{name: "should return the result before the deadline", run: func(context.Context) error { return nil }, timeout: time.Second},
+		{
+			name:         "should call onDeadline after the run is stopped when the deadline is hit",
+			run:          func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() },
+			timeout:      10 * time.Millisecond,
+			wantDeadline: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadline, stopped := false, false
+			run := func(ctx context.Context) error {
+				defer func() { stopped = true }()
+				return tt.run(ctx)
+			}
+			err := runWithDeadline(run, tt.timeout, func() {
+				if !stopped {
+					t.Errorf("onDeadline is called before the run is stopped")
+				}
+				deadline = true
+			})
+			if deadline != tt.wantDeadline {
+				t.Errorf("runWithDeadline() called onDeadline = %v, want %v", deadline, tt.wantDeadline)
+			}
+			if tt.wantDeadline {
+				if !e2eerrors.IsTimeout(err) {
+					t.Errorf("runWithDeadline() error = %v, want timeout", err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Errorf("runWithDeadline() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryTriggerAndVerify(t *testing.T) {
+	tests := []struct {
+		name         string
+		retry        config.TriggerRetry
+		failedTimes  int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "should run once when it passes", retry: config.TriggerRetry{Count: 3}, wantAttempts: 1},
+		{name: "should run once without retry", failedTimes: 1, wantAttempts: 1, wantErr: true},
+		{name: "should retry until it passes", retry: config.TriggerRetry{Count: 3, Interval: "1ms"}, failedTimes: 2, wantAttempts: 3},
+		{name: "should fail after all the retries", retry: config.TriggerRetry{Count: 2}, failedTimes: 10, wantAttempts: 3, wantErr: true},
+		{name: "should fail when the interval is invalid", retry: config.TriggerRetry{Count: 2, Interval: "1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retryTriggerAndVerify(tt.retry, func(attempt int) error {
+				if attempt != attempts {
+					t.Errorf("attempt = %d, want %d", attempt, attempts)
+				}
+				attempts++
+				if attempts <= tt.failedTimes {
+					return errors.New("verify failed")
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("retryTriggerAndVerify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("retryTriggerAndVerify() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestValidatePauseAfter(t *testing.T) {
+	tests := []struct {
+		phases  []string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{phases: nil},
+		{phases: nil, timeout: time.Minute},
+		{phases: []string{"setup", "trigger", "verify"}},
+		{phases: []string{"setup", "cleanup"}, wantErr: true},
+		{phases: []string{"setup"}, timeout: time.Minute, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validatePauseAfter(tt.phases, tt.timeout); (err != nil) != tt.wantErr {
+			t.Errorf("validatePauseAfter(%v, %s) error = %v, wantErr %v", tt.phases, tt.timeout, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPauseAfterPhaseNotTerminal(t *testing.T) {
+	pauseAfter = []string{"setup"}
+	defer func() { pauseAfter = nil }()
+
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	finished := make(chan struct{})
+	go func() {
+		pauseAfterPhase("setup", stdin)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pauseAfterPhase() should not block when the stdin is not a terminal")
+	}
+}
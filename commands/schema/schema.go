@@ -0,0 +1,42 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+// Schema prints the JSON Schema of the configuration file, which could be used by the editors for completion and validation.
+var Schema = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the e2e configuration file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("[Schema] %s", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
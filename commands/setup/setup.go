@@ -20,34 +20,40 @@ package setup
 
 import (
 	"fmt"
+	"path/filepath"
 	"sync"
 
 	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
 
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	Setup.Flags().BoolVar(&setup.Recreate, "recreate", false,
+		"deploy the compose environments again even if they're running already, rather than reusing them")
+}
+
 var Setup = &cobra.Command{
 	Use:   "setup",
 	Short: "",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := util.CheckDockerDaemon(); err != nil {
+		if err := util.CheckDockerDaemon(config.GlobalConfig.E2EConfig.Setup.Compose.GetDockerHost()); err != nil {
 			return err
 		}
 
 		defer setup.CloseLogFollower()
 		if err := DoSetupAccordingE2E(); err != nil {
-			return fmt.Errorf("[Setup] %s", err)
+			ExportLogsOnFailure()
+			return fmt.Errorf("[Setup] %w", err)
 		}
 
-		if config.GlobalConfig.E2EConfig.Setup.Env == constant.Kind && setup.KindShouldWaitSignal() {
-			wg := sync.WaitGroup{}
-			wg.Add(1)
-			util.AddShutDownHook(wg.Done)
-			wg.Wait()
+		if setup.KindShouldWaitSignal() {
+			WaitSignal()
 
 			setup.KindCleanNotify()
 		}
@@ -60,30 +66,96 @@ func DoSetupAccordingE2E() error {
 		return config.GlobalConfig.Error
 	}
 
+	if err := util.ResetTriggerStartTime(filepath.Join(util.WorkDir, constant.TriggerStartFile)); err != nil {
+		return err
+	}
+	if err := setup.SetExportEnv(config.GlobalConfig.E2EConfig.Setup.ExportEnv); err != nil {
+		return err
+	}
+	setup.InitLogFollower()
+	if err := setup.RunHook("setup.before", config.GlobalConfig.E2EConfig.Setup.Before); err != nil {
+		return err
+	}
+	for _, environment := range config.GlobalConfig.E2EConfig.Setup.GetEnvironments() {
+		// the remaining environments are not set up when the run is stopped by the deadline
+		if err := util.RunContext().Err(); err != nil {
+			return err
+		}
+		if err := setupEnvironment(environment); err != nil {
+			return &e2eerrors.SetupError{Env: environment.Env, Environment: environment.Name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func setupEnvironment(environment *config.Environment) error {
+	// each environment is set up with its own setup config
 	e2eConfig := config.GlobalConfig.E2EConfig
+	e2eConfig.Setup = environment.Setup
+
+	setup.SetEnvironment(environment.Name)
+	defer setup.SetEnvironment("")
+
+	if err := setup.ExportSetupEnvVars(e2eConfig.Setup.EnvVars); err != nil {
+		return err
+	}
 
-	setup.InitLogFollower()
 	switch e2eConfig.Setup.Env {
 	case constant.Kind:
-		err := setup.KindSetup(&e2eConfig)
-		if err != nil {
-			return err
-		}
+		return setup.KindSetup(&e2eConfig)
 	case constant.Compose:
-		err := setup.ComposeSetup(&e2eConfig)
-		if err != nil {
-			return err
-		}
+		return setup.ComposeSetup(&e2eConfig)
 	default:
 		return fmt.Errorf("no such env for setup: [%s]. should use kind or compose instead", e2eConfig.Setup.Env)
 	}
+}
 
-	return nil
+// ExportLogsOnFailure archives the logs of the kind clusters and the compose projects which are configured to export logs on failure.
+func ExportLogsOnFailure() {
+	for _, environment := range config.GlobalConfig.E2EConfig.Setup.GetEnvironments() {
+		e2eConfig := config.GlobalConfig.E2EConfig
+		e2eConfig.Setup = environment.Setup
+		setup.SetEnvironment(environment.Name)
+		switch {
+		// the existing cluster from kubeconfig is not created by kind
+		case environment.Env == constant.Kind && environment.Kubeconfig == "" &&
+			environment.Kind.ExportLogs == constant.ExportLogsOnFailure:
+			if err := setup.ExportKindLogs(&e2eConfig); err != nil {
+				logger.Log.Warnf("%v", err)
+			}
+		case environment.Env == constant.Compose && environment.Compose.ExportLogs == constant.ExportLogsOnFailure:
+			if err := setup.ExportComposeLogs(&e2eConfig); err != nil {
+				logger.Log.Warnf("%v", err)
+			}
+		}
+		setup.SetEnvironment("")
+	}
 }
 
 func DoStopSetup() {
 	// close log follower
 	setup.CloseLogFollower()
-	// notify clean up
+	// notify clean up, the compose projects are torn down with their environments in the cleanup
 	setup.KindCleanNotify()
 }
+
+// RestoreKubeConfig restores the KUBECONFIG of the process overridden by the kind setup, it's a no-op if it's not overridden
+// or restored already, so that the processes embedding the e2e could restore it however the run ends, even if it's not cleaned up.
+func RestoreKubeConfig() {
+	setup.RestoreKubeConfig()
+}
+
+// ShouldWaitSignal returns whether there are resources held by this process, such as the port-forwards
+// and the compose projects, which are waited for the signal before releasing them.
+func ShouldWaitSignal() bool {
+	return setup.KindShouldWaitSignal() || setup.ComposeShouldWaitSignal()
+}
+
+// WaitSignal blocks until the interrupt or terminate signal.
+func WaitSignal() {
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	util.AddShutDownHook(wg.Done)
+	wg.Wait()
+}
@@ -19,12 +19,16 @@ package trigger
 
 import (
 	"fmt"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/apache/skywalking-infra-e2e/internal/components/trigger"
+	"github.com/apache/skywalking-infra-e2e/internal/components/verifier"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
@@ -58,6 +62,14 @@ func CreateTriggerAction() (trigger.Action, error) {
 	if err := config.GlobalConfig.Error; err != nil {
 		return nil, err
 	}
+	// the logs verify cases could only verify the lines written after the trigger started
+	if err := verifier.SaveLogOffsets(util.LogDir, filepath.Join(util.WorkDir, constant.LogOffsetsFile)); err != nil {
+		logger.Log.Warnf("failed to record the offsets of the log files: %v", err)
+	}
+	// the logs exported on failure start from the trigger by default
+	if err := util.SaveTriggerStartTime(filepath.Join(util.WorkDir, constant.TriggerStartFile), time.Now()); err != nil {
+		logger.Log.Warnf("failed to record the start time of the trigger: %v", err)
+	}
 
 	switch t := config.GlobalConfig.E2EConfig.Trigger; t.Action {
 	case "":
@@ -66,11 +78,19 @@ func CreateTriggerAction() (trigger.Action, error) {
 		return trigger.NewHTTPAction(
 			t.Interval,
 			t.Times,
+			t.Concurrency,
 			t.URL,
 			t.Method,
 			t.Body,
 			t.Headers,
+			t.Capture,
+			trigger.SuccessCondition(t.Success),
 		)
+	case constant.ActionCMD:
+		if t.Concurrency > 1 {
+			return nil, fmt.Errorf("trigger.concurrency only supports the http action")
+		}
+		return trigger.NewCommandAction(t.Interval, t.Times, t.Command)
 	default:
 		return nil, fmt.Errorf("unsupported trigger action: %s", t.Action)
 	}
@@ -19,33 +19,48 @@ package verify
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
 	"github.com/apache/skywalking-infra-e2e/internal/components/verifier"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
 	"github.com/apache/skywalking-infra-e2e/pkg/output"
 )
 
 var (
-	query    string
-	actual   string
-	expected string
-	printer  output.Printer
+	query      string
+	actual     string
+	metrics    string
+	expected   string
+	comparator string
+	printer    output.Printer
+
+	watch         bool
+	watchInterval time.Duration
 )
 
 func init() {
 	Verify.Flags().StringVarP(&query, "query", "q", "", "the query to get the actual data, the result of the query should in YAML format")
 	Verify.Flags().StringVarP(&actual, "actual", "a", "", "the actual data file, only YAML file format is supported")
+	Verify.Flags().StringVarP(&metrics, "metrics", "m", "", "the Prometheus/OpenMetrics endpoint to scrape the actual data from")
 	Verify.Flags().StringVarP(&expected, "expected", "e", "", "the expected data file, only YAML file format is supported")
+	Verify.Flags().StringVarP(&comparator, "comparator", "", "", "how the actual data is compared with the expected data, yaml(default), json, text or csv")
 	Verify.Flags().StringVarP(&output.Format, "output", "o", "yaml", "output the verify summary in which format. Currently, only 'yaml' is supported. ")
 	Verify.Flags().BoolVarP(&output.SummaryOnly, "summary-only", "", false, "if true, only 'SUMMARY' part of the verify result will be outputted")
+	Verify.Flags().BoolVarP(&watch, "watch", "", false, "keep verifying against the live environment every interval until interrupted, for local development")
+	Verify.Flags().DurationVarP(&watchInterval, "interval", "", defaultWatchInterval, "the interval between two verifications in the watch mode")
 }
 
 // Verify verifies that the actual data satisfies the expected data pattern.
@@ -53,16 +68,77 @@ var Verify = &cobra.Command{
 	Use:   "verify",
 	Short: "verify if the actual data match the expected data",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if expected != "" {
-			_, err := verifySingleCase(expected, actual, query)
-			return err
+		verifyOnce := func() error {
+			if expected != "" {
+				_, err := verifySingleCase(&config.VerifyCase{
+					Expected:   resolveFlagPath(expected),
+					Actual:     resolveFlagPath(actual),
+					Query:      query,
+					Metrics:    metrics,
+					Comparator: comparator,
+				})
+				return err
+			}
+
+			// If there is no given flags.
+			return DoVerifyAccordingConfig()
+		}
+		// the steps such as deleting a pod are run only once, even in the watch mode
+		if expected == "" {
+			if err := DoVerifyStepsAccordingConfig(); err != nil {
+				return err
+			}
+		}
+		if !watch {
+			return verifyOnce()
 		}
 
-		// If there is no given flags.
-		return DoVerifyAccordingConfig()
+		if expected == "" && config.GlobalConfig.Error != nil {
+			return config.GlobalConfig.Error
+		}
+		stopCh := make(chan struct{})
+		util.AddShutDownHook(func() { close(stopCh) })
+		return watchVerify(verifyOnce, watchInterval, stopCh)
 	},
 }
 
+// watchVerify runs the verification every interval until stopped, the failures of each cycle are reported
+// in a summary rather than returned, so that the environment is kept for the next cycle.
+func watchVerify(verifyOnce func() error, interval time.Duration, stopCh <-chan struct{}) error {
+	if interval <= 0 {
+		return fmt.Errorf("the watch interval should be > 0, but was %s", interval)
+	}
+
+	for cycle := 1; ; cycle++ {
+		logger.Log.Info(watchSummary(cycle, verifyOnce()))
+		select {
+		case <-stopCh:
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// watchSummary returns the compact summary of the verification in the watch mode.
+func watchSummary(cycle int, err error) string {
+	now := formatVerificationTime()
+	if err == nil {
+		return fmt.Sprintf("[watch #%d %s] PASSED", cycle, now)
+	}
+	var verifyErr *e2eerrors.VerifyError
+	if errors.As(err, &verifyErr) {
+		return fmt.Sprintf("[watch #%d %s] FAILED, %d case(s) failed", cycle, now, len(verifyErr.Errs))
+	}
+	return fmt.Sprintf("[watch #%d %s] FAILED, %v", cycle, now, err)
+}
+
+const (
+	defaultStabilizeTimes    = 3
+	defaultStabilizeInterval = 5 * time.Second
+	defaultDeltaInterval     = 10 * time.Second
+	defaultWatchInterval     = 5 * time.Second
+)
+
 // verifyInfo contains necessary information about verification
 type verifyInfo struct {
 	caseNumber int
@@ -71,36 +147,358 @@ type verifyInfo struct {
 	failFast   bool
 }
 
-func verifySingleCase(expectedFile, actualFile, query string) (string, error) {
-	expectedData, err := util.ReadFileContent(expectedFile)
-	if err != nil {
-		return "", fmt.Errorf("failed to read the expected data file: %v", err)
+// resolveFlagPath resolves the file path passed by command line flags against the current working directory.
+func resolveFlagPath(p string) string {
+	if p == "" {
+		return p
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+func verifySingleCase(v *config.VerifyCase) (string, error) {
+	if v.Logs != nil {
+		return "", verifyLogs(v.Logs)
+	}
+	if v.Instances != nil && v.Instances.Mode != constant.InstancesModeMerge {
+		return verifyAnyInstance(v)
+	}
+
+	if v.Swctl != nil && v.Swctl.Series != nil {
+		return verifySeries(v)
 	}
 
-	var actualData, sourceName, stderr string
-	if actualFile != "" {
-		sourceName = actualFile
-		actualData, err = util.ReadFileContent(actualFile)
+	expectedTemplates := make([]verifier.Expected, 0)
+	version := config.GlobalConfig.E2EConfig.Verify.GetVersion()
+	for _, file := range v.GetExpectedFiles() {
+		if version != "" {
+			versioned, err := verifier.VersionedExpected(file, version)
+			if err != nil {
+				return "", fmt.Errorf("failed to select the expected data file of version %s: %v", version, err)
+			}
+			logger.Log.Debugf("selected the expected data file %s of version %s", versioned, version)
+			file = versioned
+		}
+		expectedData, err := util.ReadFileContent(file)
 		if err != nil {
-			return "", fmt.Errorf("failed to read the actual data file: %v", err)
+			return "", fmt.Errorf("failed to read the expected data file: %v", err)
 		}
-	} else if query != "" {
-		sourceName = query
-		actualData, stderr, err = util.ExecuteCommand(query)
+		expectedTemplates = append(expectedTemplates, verifier.Expected{Name: file, Template: expectedData})
+	}
+	if v.ExpectedQuery != "" {
+		// the expected data is generated for every verification, such as the live output of the reference system
+		expectedData, _, err := executeQuery(v.ExpectedQuery, v.FailOnStderr)
 		if err != nil {
-			return "", fmt.Errorf("failed to execute the query: %s, output: %s, error: %v", query, actualData, stderr)
+			return "", fmt.Errorf("failed to generate the expected data: %v", err)
 		}
+		expectedTemplates = append(expectedTemplates, verifier.Expected{Name: v.ExpectedQuery, Template: expectedData})
 	}
 
-	if err = verifier.Verify(actualData, expectedData); err != nil {
+	sourceName := caseSource(v)
+	var stderr string
+	fetch := func() (string, error) { return fetchActualData(v, &stderr) }
+	if v.Stabilize != nil {
+		fetch = func() (string, error) { return fetchStableActualData(v, &stderr) }
+	}
+	if v.Delta != nil {
+		stableFetch := fetch
+		fetch = func() (string, error) { return fetchDeltaActualData(v, stableFetch) }
+	}
+	actualData, err := fetch()
+	if err != nil {
+		return "", err
+	}
+
+	ignorePaths := config.GlobalConfig.E2EConfig.Verify.IgnorePaths
+	caseComparator := v.Comparator
+	if caseComparator == "" {
+		caseComparator = config.GlobalConfig.E2EConfig.Verify.Comparator
+	}
+	if err = verifier.VerifyAnyOf(actualData, expectedTemplates, ignorePaths, caseComparator); err != nil {
 		if me, ok := err.(*verifier.MismatchError); ok {
-			return actualData, fmt.Errorf("failed to verify the output: %s, error:\n%v", sourceName, me.Error())
+			return actualData, &e2eerrors.VerifyMismatchError{Case: sourceName, Diff: me.Error(), Stderr: stderr}
 		}
 		return actualData, fmt.Errorf("failed to verify the output: %s, error:\n%v", sourceName, err)
 	}
 	return actualData, nil
 }
 
+// verifyAndCapture verifies the case, and exports the captured fields of the actual data as env vars if it passes,
+// so that the cases depending on it could refer to them.
+func verifyAndCapture(v *config.VerifyCase) (string, error) {
+	actualData, err := verifySingleCase(v)
+	if err != nil || len(v.Capture) == 0 {
+		return actualData, err
+	}
+	captured, err := verifier.Capture(actualData, v.Capture)
+	if err != nil {
+		return actualData, err
+	}
+	for name, value := range captured {
+		if err := os.Setenv(name, value); err != nil {
+			return actualData, fmt.Errorf("failed to export %s: %v", name, err)
+		}
+		logger.Log.Debugf("captured %s=%s from %s", name, value, caseName(v))
+	}
+	return actualData, nil
+}
+
+// verifySeries verifies the values of the metrics series of the swctl case by the condition.
+func verifySeries(v *config.VerifyCase) (string, error) {
+	var stderr string
+	actualData, err := fetchActualData(v, &stderr)
+	if err != nil {
+		return "", err
+	}
+	series := v.Swctl.Series
+	if err := verifier.VerifySeries(actualData, series.Match, series.Condition); err != nil {
+		if me, ok := err.(*verifier.MismatchError); ok {
+			return actualData, &e2eerrors.VerifyMismatchError{Case: caseSource(v), Diff: me.Error(), Stderr: stderr}
+		}
+		return actualData, fmt.Errorf("failed to verify the series: %s, error:\n%v", caseSource(v), err)
+	}
+	return actualData, nil
+}
+
+// serviceInstances returns the env vars of the instances recorded by the setup of this process, which respect the export-env,
+// otherwise the env vars in the environ, such as the ones sourced from the `--env-file-out` of `e2e setup`, are looked up.
+func serviceInstances(service string) []map[string]string {
+	if instances := setup.InstanceEnv(service); len(instances) > 0 {
+		return instances
+	}
+	return verifier.InstanceEnv(service, os.Environ())
+}
+
+// verifyAnyInstance verifies the case against each instance of the service in order, it passes when any of them matches.
+func verifyAnyInstance(v *config.VerifyCase) (string, error) {
+	instances := serviceInstances(v.Instances.Service)
+	if len(instances) == 0 {
+		return "", fmt.Errorf("no instance of service %s is exported", v.Instances.Service)
+	}
+
+	var actualData string
+	var err error
+	for i, env := range instances {
+		instanceCase := *v
+		instanceCase.Query = verifier.InstanceQuery(v.Query, env)
+		instanceCase.Instances = nil
+		if actualData, err = verifySingleCase(&instanceCase); err == nil {
+			return actualData, nil
+		}
+		logger.Log.Debugf("the instance %d of service %s doesn't match: %v", i+1, v.Instances.Service, err)
+	}
+	return actualData, fmt.Errorf("none of the %d instances of service %s matches, the last one: %w", len(instances), v.Instances.Service, err)
+}
+
+// fetchMergedActualData runs the query against all the instances of the service, and merges the outputs.
+func fetchMergedActualData(v *config.VerifyCase, stderr *string) (string, error) {
+	instances := serviceInstances(v.Instances.Service)
+	if len(instances) == 0 {
+		return "", fmt.Errorf("no instance of service %s is exported", v.Instances.Service)
+	}
+
+	outputs, errOutputs := make([]string, 0, len(instances)), make([]string, 0, len(instances))
+	for i, env := range instances {
+		actualData, errOutput, err := executeQuery(verifier.InstanceQuery(v.Query, env), v.FailOnStderr)
+		if errOutput != "" {
+			errOutputs = append(errOutputs, errOutput)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%v, on the instance %d of service %s", err, i+1, v.Instances.Service)
+		}
+		outputs = append(outputs, actualData)
+	}
+	*stderr = strings.Join(errOutputs, "\n")
+	return verifier.MergeOutputs(outputs)
+}
+
+// verifyLogs verifies the collected log files of the case, the logs are read from the offsets recorded
+// when the trigger started if `since: trigger` is set.
+func verifyLogs(logs *config.VerifyLogs) error {
+	offsets := make(map[string]int64)
+	if logs.Since == constant.LogsSinceTrigger {
+		var err error
+		if offsets, err = verifier.LoadLogOffsets(filepath.Join(util.WorkDir, constant.LogOffsetsFile)); err != nil {
+			return err
+		}
+	}
+	content, err := verifier.ReadLogs(util.LogDir, logs.Files, offsets)
+	if err != nil {
+		return err
+	}
+
+	if err := verifier.VerifyLogs(content, logs.Contains, logs.Absent); err != nil {
+		if me, ok := err.(*verifier.MismatchError); ok {
+			return &e2eerrors.VerifyMismatchError{Case: fmt.Sprintf("logs%v", logs.Files), Diff: me.Error()}
+		}
+		return err
+	}
+	return nil
+}
+
+// caseSource returns the source of the actual data.
+func caseSource(v *config.VerifyCase) string {
+	if actualFile := v.GetActual(); actualFile != "" {
+		return actualFile
+	} else if v.Query != "" {
+		return v.Query
+	} else if v.GraphQL != nil {
+		return fmt.Sprintf("graphql %s", v.GraphQL.URL)
+	} else if v.Swctl != nil {
+		return swctlSource(v.Swctl)
+	}
+	return v.Metrics
+}
+
+// swctlSource returns the metrics name or the expression of the swctl case.
+func swctlSource(s *config.VerifySwctl) string {
+	if s.Expression != "" {
+		return fmt.Sprintf("swctl %s", s.Expression)
+	}
+	return fmt.Sprintf("swctl %s", s.Name)
+}
+
+// fetchActualData reads the actual data from the file, query or metrics of the case,
+// the stderr of the query is captured for the failure messages.
+func fetchActualData(v *config.VerifyCase, stderr *string) (string, error) {
+	if actualFile := v.GetActual(); actualFile != "" {
+		actualData, err := util.ReadFileContent(actualFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the actual data file: %v", err)
+		}
+		return actualData, nil
+	} else if v.Query != "" {
+		if v.Instances != nil {
+			return fetchMergedActualData(v, stderr)
+		}
+		actualData, errOutput, err := executeQuery(v.Query, v.FailOnStderr)
+		*stderr = errOutput
+		return actualData, err
+	} else if v.Metrics != "" {
+		return verifier.FetchMetrics(v.Metrics)
+	} else if v.GraphQL != nil {
+		return verifier.FetchGraphQL(v.GraphQL.URL, v.GraphQL.Query, v.GraphQL.Variables, v.GraphQL.Headers)
+	} else if v.Swctl != nil {
+		// the command is built for every query, so that the time range moves along with the retries
+		query, err := swctlQuery(v.Swctl).Command(time.Now())
+		if err != nil {
+			return "", err
+		}
+		actualData, errOutput, err := executeQuery(query, v.FailOnStderr)
+		*stderr = errOutput
+		return actualData, err
+	}
+	return "", nil
+}
+
+func swctlQuery(s *config.VerifySwctl) *verifier.SwctlMetrics {
+	return &verifier.SwctlMetrics{
+		BaseURL:    s.BaseURL,
+		Name:       s.Name,
+		Expression: s.Expression,
+		Service:    s.Service,
+		Instance:   s.Instance,
+		Endpoint:   s.Endpoint,
+		Start:      s.Start,
+		End:        s.End,
+		Step:       s.Step,
+	}
+}
+
+// executeQuery executes the query and returns the stdout as the actual data, the stderr fails the query if failOnStderr is set,
+// otherwise it's logged and ignored for matching.
+func executeQuery(query string, failOnStderr bool) (actualData, stderr string, err error) {
+	actualData, stderr, err = util.ExecuteCommand(query)
+	if err != nil {
+		return "", stderr, fmt.Errorf("failed to execute the query: %s, output: %s, stderr: %s, error: %v", query, actualData, stderr, err)
+	}
+	if stderr == "" {
+		return actualData, "", nil
+	}
+	if failOnStderr {
+		return "", stderr, fmt.Errorf("the query printed to stderr: %s, output: %s, stderr: %s", query, actualData, stderr)
+	}
+	logger.Log.Debugf("ignored the stderr of the query: %s, stderr: %s", query, stderr)
+	return actualData, stderr, nil
+}
+
+// fetchStableActualData polls the actual data until it's identical across the consecutive polls.
+func fetchStableActualData(v *config.VerifyCase, stderr *string) (string, error) {
+	times := v.Stabilize.Times
+	if times <= 0 {
+		times = defaultStabilizeTimes
+	}
+	interval, err := parseDurationOrDefault(v.Stabilize.Interval, defaultStabilizeInterval)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse stabilize.interval: %v", err)
+	}
+	timeout, err := parseDurationOrDefault(v.Stabilize.Timeout, time.Duration(times)*interval*2)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse stabilize.timeout: %v", err)
+	}
+
+	return waitForStableOutput(func() (string, error) { return fetchActualData(v, stderr) }, times, interval, timeout)
+}
+
+// waitForStableOutput fetches the output until the same output is returned by the given times in a row.
+func waitForStableOutput(fetch func() (string, error), times int, interval, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	last, identical := "", 0
+	for {
+		output, err := fetch()
+		if err != nil {
+			return "", err
+		}
+		if identical > 0 && output == last {
+			identical++
+		} else {
+			last, identical = output, 1
+		}
+		if identical >= times {
+			return output, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return "", fmt.Errorf("the actual data didn't stabilize within %v, identical in %d of %d polls in a row",
+				timeout, identical, times)
+		}
+		logger.Log.Debugf("the actual data is identical in %d of %d polls in a row, polling again", identical, times)
+		if err := util.Sleep(interval); err != nil {
+			return "", err
+		}
+	}
+}
+
+// fetchDeltaActualData fetches the baseline, then fetches again after the interval, and returns the difference of the numbers.
+func fetchDeltaActualData(v *config.VerifyCase, fetch func() (string, error)) (string, error) {
+	interval, err := parseDurationOrDefault(v.Delta.Interval, defaultDeltaInterval)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse delta.interval: %v", err)
+	}
+
+	baseline, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	logger.Log.Debugf("captured the baseline of %s, fetching again after %v", caseSource(v), interval)
+	if err := util.Sleep(interval); err != nil {
+		return "", err
+	}
+	current, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	return verifier.Delta(baseline, current, v.Delta.FromZero)
+}
+
+func parseDurationOrDefault(s string, defaultValue time.Duration) (time.Duration, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(s)
+}
+
 // concurrentlyVerifySingleCase verifies a single case in concurrency mode,
 // it will call the cancel function if the case fails and the fail-fast is enabled.
 func concurrentlyVerifySingleCase(
@@ -111,25 +509,37 @@ func concurrentlyVerifySingleCase(
 ) (res *output.CaseResult) {
 	res = &output.CaseResult{}
 	res.Name = caseName(v)
+	res.Soft = v.Soft
 	defer func() {
-		if res.Err != nil && verifyInfo.failFast {
+		// the failure of the soft case doesn't stop verifying the other cases
+		if res.Err != nil && !res.Soft && verifyInfo.failFast {
 			cancel()
 		}
 	}()
 
-	if v.GetExpected() == "" {
+	if !hasExpectation(v) {
 		res.Msg = fmt.Sprintf("failed to verify %v:", caseName(v))
 		res.Err = fmt.Errorf("the expected data file for %v is not specified", caseName(v))
 		return res
 	}
 
+	if delay := v.GetDelay(); delay > 0 {
+		select {
+		case <-ctx.Done():
+			res.Skip = true
+			return res
+		case <-time.After(delay):
+		}
+	}
+
 	for current := 0; current <= verifyInfo.retryCount; current++ {
 		select {
 		case <-ctx.Done():
 			res.Skip = true
 			return res
 		default:
-			if d, err := verifySingleCase(v.GetExpected(), v.GetActual(), v.Query); err == nil {
+			res.Retries = current
+			if d, err := verifyAndCapture(v); err == nil {
 				if current == 0 {
 					res.Msg = fmt.Sprintf("verified %v\n", caseName(v))
 				} else {
@@ -137,7 +547,10 @@ func concurrentlyVerifySingleCase(
 				}
 				return res
 			} else if current != verifyInfo.retryCount {
-				time.Sleep(verifyInfo.interval)
+				select {
+				case <-ctx.Done():
+				case <-time.After(verifyInfo.interval):
+				}
 			} else {
 				res.Msg = fmt.Sprintf("failed to verify %v, retried %d time(s):", caseName(v), current)
 				if d != "" {
@@ -157,14 +570,33 @@ func verifyCasesConcurrently(verify *config.Verify, verifyInfo *verifyInfo) erro
 	for i := range res {
 		res[i] = &output.CaseResult{}
 	}
-	ctx, cancel := context.WithCancel(context.Background())
+	// the cases are skipped when the run is stopped by the deadline, or a case fails in the fail-fast mode
+	ctx, cancel := context.WithCancel(util.RunContext())
 	defer cancel()
 
+	// the cases wait for the cases they depend on, the independent cases are verified concurrently
+	done := make([]chan struct{}, len(verify.Cases))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
 	var wg sync.WaitGroup
 	for idx := range verify.Cases {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
+			defer close(done[i])
+
+			dependencies := caseDependencies(&verify.Cases[i], verify.Cases)
+			for _, d := range dependencies {
+				<-done[d]
+			}
+			if dependency := failedDependency(dependencies, verify.Cases, res); dependency != "" {
+				res[i] = dependencyFailedResult(&verify.Cases[i], dependency)
+				if res[i].Err != nil && verifyInfo.failFast {
+					cancel()
+				}
+				return
+			}
 
 			// Check if the context is canceled before verifying the case.
 			select {
@@ -185,7 +617,7 @@ func verifyCasesConcurrently(verify *config.Verify, verifyInfo *verifyInfo) erro
 	} else {
 		_, errNum, _ := printer.PrintResult(res)
 		if errNum > 0 {
-			return fmt.Errorf("failed to verify %d case(s)", errNum)
+			return failedCasesError(res)
 		}
 	}
 
@@ -200,6 +632,7 @@ func verifyCasesSerially(verify *config.Verify, verifyInfo *verifyInfo) (err err
 		res[i] = &output.CaseResult{
 			Skip: true,
 			Name: caseName(&verify.Cases[i]),
+			Soft: verify.Cases[i].Soft,
 		}
 	}
 
@@ -209,30 +642,58 @@ func verifyCasesSerially(verify *config.Verify, verifyInfo *verifyInfo) (err err
 		} else {
 			_, errNum, _ := printer.PrintResult(res)
 			if errNum > 0 {
-				err = fmt.Errorf("failed to verify %d case(s)", errNum)
+				err = failedCasesError(res)
 			}
 		}
 	}()
 
-	for idx := range verify.Cases {
+	order, err := verify.CaseOrder()
+	if err != nil {
+		return err
+	}
+	for _, idx := range order {
+		// the remaining cases are skipped when the run is stopped by the deadline
+		if err := util.RunContext().Err(); err != nil {
+			return err
+		}
 		printer.Start()
 		v := &verify.Cases[idx]
 
-		if v.GetExpected() == "" {
+		if dependency := failedDependency(caseDependencies(v, verify.Cases), verify.Cases, res); dependency != "" {
+			res[idx] = dependencyFailedResult(v, dependency)
+			if res[idx].Err == nil {
+				printer.Warning(res[idx].Msg)
+				continue
+			}
+			printCaseFailure(res[idx])
+			if verifyInfo.failFast {
+				return
+			}
+			continue
+		}
+
+		if !hasExpectation(v) {
 			res[idx].Skip = false
 			res[idx].Msg = fmt.Sprintf("%s failed to verify %v", formatVerificationTime(), caseName(v))
 			res[idx].Err = fmt.Errorf("the expected data file for %v is not specified", caseName(v))
 
-			printer.Warning(res[idx].Msg)
-			printer.Fail(res[idx].Err.Error())
-			if verifyInfo.failFast {
+			printCaseFailure(res[idx])
+			if verifyInfo.failFast && !v.Soft {
 				return
 			}
 			continue
 		}
 
+		if delay := v.GetDelay(); delay > 0 {
+			printer.UpdateText(fmt.Sprintf("waiting %s before verifying %v", delay, caseName(v)))
+			if err := util.Sleep(delay); err != nil {
+				return err
+			}
+		}
+
 		for current := 0; current <= verifyInfo.retryCount; current++ {
-			if d, e := verifySingleCase(v.GetExpected(), v.GetActual(), v.Query); e == nil {
+			res[idx].Retries = current
+			if d, e := verifyAndCapture(v); e == nil {
 				if current == 0 {
 					res[idx].Msg = fmt.Sprintf("%s verified %v \n", formatVerificationTime(), caseName(v))
 				} else {
@@ -248,7 +709,10 @@ func verifyCasesSerially(verify *config.Verify, verifyInfo *verifyInfo) (err err
 					printer.UpdateText(fmt.Sprintf("failed to verify %v, retry [%d/%d]", caseName(v), current,
 						verifyInfo.retryCount))
 				}
-				time.Sleep(verifyInfo.interval)
+				// the case is left skipped when the run is stopped by the deadline
+				if util.Sleep(verifyInfo.interval) != nil {
+					break
+				}
 			} else {
 				res[idx].Msg = fmt.Sprintf("%s failed to verify %v, retried %d time(s)", formatVerificationTime(), caseName(v), current)
 				if d != "" {
@@ -257,9 +721,8 @@ func verifyCasesSerially(verify *config.Verify, verifyInfo *verifyInfo) (err err
 				res[idx].Err = e
 				res[idx].Skip = false
 				printer.UpdateText(fmt.Sprintf("failed to verify %v, retry [%d/%d]", caseName(v), current, verifyInfo.retryCount))
-				printer.Warning(res[idx].Msg)
-				printer.Fail(res[idx].Err.Error())
-				if verifyInfo.failFast {
+				printCaseFailure(res[idx])
+				if verifyInfo.failFast && !v.Soft {
 					return
 				}
 			}
@@ -269,6 +732,74 @@ func verifyCasesSerially(verify *config.Verify, verifyInfo *verifyInfo) (err err
 	return nil
 }
 
+// hasExpectation checks the case has the expected data, or the expectation of the logs or the series.
+func hasExpectation(v *config.VerifyCase) bool {
+	return len(v.GetExpectedFiles()) > 0 || v.ExpectedQuery != "" || v.Logs != nil || (v.Swctl != nil && v.Swctl.Series != nil)
+}
+
+// caseDependencies returns the indexes of the cases the case depends on, the names are checked when loading the config.
+func caseDependencies(v *config.VerifyCase, cases []config.VerifyCase) []int {
+	dependencies := make([]int, 0, len(v.DependsOn))
+	for _, name := range v.DependsOn {
+		for i := range cases {
+			if cases[i].Name == name {
+				dependencies = append(dependencies, i)
+				break
+			}
+		}
+	}
+	return dependencies
+}
+
+// failedDependency returns the name of the first dependency which failed or was skipped, empty if all of them passed.
+func failedDependency(dependencies []int, cases []config.VerifyCase, res []*output.CaseResult) string {
+	for _, i := range dependencies {
+		if res[i].Skip || res[i].Err != nil {
+			return cases[i].Name
+		}
+	}
+	return ""
+}
+
+// dependencyFailedResult returns the result of the case whose dependency didn't pass, the soft case is skipped, while the other cases
+// fail, so that their assertions which never ran don't pass silently, such as the ones depending on a failed soft case.
+func dependencyFailedResult(v *config.VerifyCase, dependency string) *output.CaseResult {
+	if v.Soft {
+		return &output.CaseResult{
+			Skip: true,
+			Name: caseName(v),
+			Soft: true,
+			Msg:  fmt.Sprintf("%s skipped %v, its dependency %s didn't pass\n", formatVerificationTime(), caseName(v), dependency),
+		}
+	}
+	return &output.CaseResult{
+		Name: caseName(v),
+		Msg:  fmt.Sprintf("%s failed to verify %v", formatVerificationTime(), caseName(v)),
+		Err:  fmt.Errorf("the dependency %s of %v didn't pass", dependency, caseName(v)),
+	}
+}
+
+// printCaseFailure prints the failure of the case, the failure of the soft case is printed as a warning.
+func printCaseFailure(res *output.CaseResult) {
+	printer.Warning(res.Msg)
+	if res.Soft {
+		printer.Warning(output.SoftFailure(res.Err))
+		return
+	}
+	printer.Fail(res.Err.Error())
+}
+
+// failedCasesError wraps the errors of the failed cases, the soft cases are excluded.
+func failedCasesError(res []*output.CaseResult) error {
+	errs := make([]error, 0)
+	for _, r := range res {
+		if !r.Skip && r.Err != nil && !r.Soft {
+			errs = append(errs, r.Err)
+		}
+	}
+	return &e2eerrors.VerifyError{Errs: errs}
+}
+
 func formatVerificationTime() string {
 	return time.Now().Format(constant.LogTimestampFormat)
 }
@@ -278,6 +809,18 @@ func caseName(v *config.VerifyCase) string {
 		if v.Actual != "" {
 			return fmt.Sprintf("case[%s]", v.Actual)
 		}
+		if v.Metrics != "" {
+			return fmt.Sprintf("case[%s]", v.Metrics)
+		}
+		if v.Logs != nil {
+			return fmt.Sprintf("case%v", v.Logs.Files)
+		}
+		if v.GraphQL != nil {
+			return fmt.Sprintf("case[graphql %s]", v.GraphQL.URL)
+		}
+		if v.Swctl != nil {
+			return fmt.Sprintf("case[%s]", swctlSource(v.Swctl))
+		}
 		return fmt.Sprintf("case[%s]", v.Query)
 	}
 	return v.Name
@@ -310,6 +853,14 @@ func DoVerifyAccordingConfig() error {
 		failFast,
 	}
 
+	// the data is known to be not queryable during the delay, so it's not retried
+	if delay := e2eConfig.Verify.GetDelay(); delay > 0 {
+		logger.Log.Infof("waiting %s before verifying the cases", delay)
+		if err := util.Sleep(delay); err != nil {
+			return err
+		}
+	}
+
 	concurrency := e2eConfig.Verify.Concurrency
 	if concurrency {
 		// enable batch output mode when concurrency is enabled
@@ -320,6 +871,15 @@ func DoVerifyAccordingConfig() error {
 	return verifyCasesSerially(&e2eConfig.Verify, &VerifyInfo)
 }
 
+// DoVerifyStepsAccordingConfig runs the steps of the verify part before verifying the cases.
+func DoVerifyStepsAccordingConfig() error {
+	if config.GlobalConfig.Error != nil {
+		return config.GlobalConfig.Error
+	}
+	e2eConfig := config.GlobalConfig.E2EConfig
+	return setup.RunVerifySteps(&e2eConfig)
+}
+
 // TODO remove this in 2.0.0
 func parseInterval(retryInterval any) (time.Duration, error) {
 	var interval time.Duration
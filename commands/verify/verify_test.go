@@ -18,8 +18,19 @@
 package verify
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+	"github.com/apache/skywalking-infra-e2e/pkg/output"
 )
 
 func Test_parseInterval(t *testing.T) {
@@ -74,3 +85,298 @@ func Test_parseInterval(t *testing.T) {
 		})
 	}
 }
+
+func Test_verifySingleCaseWithMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `http_requests_total{method="post",code="200"} 1027`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "should verify the scraped metrics",
+			expected: `
+metrics:
+{{- contains .metrics }}
+  - name: http_requests_total
+    labels:
+      code: "200"
+      method: post
+    value: {{ gt .value 1000 }}
+{{- end }}
+`,
+		},
+		{
+			name: "should fail when the metrics mismatch",
+			expected: `
+metrics:
+  - name: http_requests_total
+    value: 1
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectedFile := filepath.Join(t.TempDir(), "expected.yaml")
+			if err := os.WriteFile(expectedFile, []byte(tt.expected), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := verifySingleCase(&config.VerifyCase{Metrics: server.URL, Expected: expectedFile})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySingleCase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_verifySingleCaseWithActualFile(t *testing.T) {
+	dir := t.TempDir()
+	actualFile := filepath.Join(dir, "actual.yaml")
+	if err := os.WriteFile(actualFile, []byte(`
+services:
+  - id: c2VydmljZQ==.1
+    name: oap
+    start: 1700000000
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name: "should verify the recorded actual data with the matchers",
+			expected: `
+services:
+{{- contains .services }}
+  - id: {{ notEmpty .id }}
+    name: oap
+    start: {{ gt .start 0 }}
+{{- end }}
+`,
+		},
+		{
+			name: "should fail when the matchers mismatch",
+			expected: `
+services:
+{{- contains .services }}
+  - id: {{ notEmpty .id }}
+    name: ui
+    start: {{ gt .start 0 }}
+{{- end }}
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectedFile := filepath.Join(dir, "expected.yaml")
+			if err := os.WriteFile(expectedFile, []byte(tt.expected), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := verifySingleCase(&config.VerifyCase{Actual: actualFile, Expected: expectedFile})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySingleCase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_verifySingleCaseWithExpectedQuery(t *testing.T) {
+	util.WorkDir = t.TempDir()
+	query := `printf 'name: oap\nversion: 10.0.0\n'`
+
+	tests := []struct {
+		name          string
+		expectedQuery string
+		wantErr       bool
+	}{
+		{
+			name:          "should verify against the output of the reference system",
+			expectedQuery: `printf 'name: oap\nversion: 10.0.0\n'`,
+		},
+		{
+			name:          "should render the generated expected data with the matchers",
+			expectedQuery: `printf 'name: oap\nversion: {{ notEmpty .version }}\n'`,
+		},
+		{
+			name:          "should fail when the outputs are different",
+			expectedQuery: `printf 'name: oap\nversion: 9.7.0\n'`,
+			wantErr:       true,
+		},
+		{
+			name:          "should fail when the expected query fails",
+			expectedQuery: "exit 1",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifySingleCase(&config.VerifyCase{Query: query, ExpectedQuery: tt.expectedQuery})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySingleCase() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_executeQuery(t *testing.T) {
+	util.WorkDir = t.TempDir()
+
+	tests := []struct {
+		name         string
+		query        string
+		failOnStderr bool
+		want         string
+		wantStderr   string
+		wantErr      bool
+	}{
+		{
+			name:       "should ignore the stderr by default",
+			query:      "echo warning >&2; echo foo",
+			want:       "foo\n",
+			wantStderr: "warning\n",
+		},
+		{
+			name:         "should fail on the stderr when failOnStderr is set",
+			query:        "echo warning >&2; echo foo",
+			failOnStderr: true,
+			wantStderr:   "warning\n",
+			wantErr:      true,
+		},
+		{
+			name:         "should pass without the stderr when failOnStderr is set",
+			query:        "echo foo",
+			failOnStderr: true,
+			want:         "foo\n",
+		},
+		{
+			name:       "should capture the stderr when the query fails",
+			query:      "echo error >&2; exit 1",
+			wantStderr: "error\n",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stderr, err := executeQuery(tt.query, tt.failOnStderr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("executeQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || stderr != tt.wantStderr {
+				t.Errorf("executeQuery() = %q, %q, want %q, %q", got, stderr, tt.want, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func Test_waitForStableOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []string
+		times   int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "should return when the output is identical from the start",
+			outputs: []string{"a", "a", "a"},
+			times:   3,
+			want:    "a",
+		},
+		{
+			name:    "should restart counting when the output changes",
+			outputs: []string{"a", "b", "b", "c", "c", "c"},
+			times:   3,
+			want:    "c",
+		},
+		{
+			name:    "should fail when the output keeps changing",
+			outputs: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"},
+			times:   2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polls := 0
+			fetch := func() (string, error) {
+				output := tt.outputs[polls%len(tt.outputs)]
+				polls++
+				return output, nil
+			}
+
+			got, err := waitForStableOutput(fetch, tt.times, time.Millisecond, 50*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("waitForStableOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("waitForStableOutput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_watchVerify(t *testing.T) {
+	stopCh := make(chan struct{})
+	cycles := 0
+	verifyOnce := func() error {
+		cycles++
+		if cycles == 3 {
+			close(stopCh)
+		}
+		// the failures should not stop watching
+		return &e2eerrors.VerifyError{Errs: []error{fmt.Errorf("mismatch")}}
+	}
+
+	if err := watchVerify(verifyOnce, time.Millisecond, stopCh); err != nil {
+		t.Fatalf("watchVerify() error = %v", err)
+	}
+	if cycles != 3 {
+		t.Errorf("watchVerify() verified %d times, want 3", cycles)
+	}
+
+	if err := watchVerify(verifyOnce, 0, stopCh); err == nil {
+		t.Errorf("watchVerify() should fail when the interval is not positive")
+	}
+}
+
+func Test_failedCasesError(t *testing.T) {
+	res := []*output.CaseResult{
+		{Name: "passed"},
+		{Name: "failed", Err: fmt.Errorf("mismatch")},
+		{Name: "soft failed", Soft: true, Err: fmt.Errorf("soft mismatch")},
+		{Name: "skipped", Skip: true, Err: fmt.Errorf("skipped")},
+	}
+
+	var verifyErr *e2eerrors.VerifyError
+	if err := failedCasesError(res); !errors.As(err, &verifyErr) || len(verifyErr.Errs) != 1 || verifyErr.Errs[0].Error() != "mismatch" {
+		t.Errorf("failedCasesError() = %v, want only the error of the hard failed case", err)
+	}
+}
+
+func Test_dependencyFailedResult(t *testing.T) {
+	res := []*output.CaseResult{
+		{Name: "soft failed", Soft: true, Err: fmt.Errorf("soft mismatch")},
+		dependencyFailedResult(&config.VerifyCase{Name: "hard", DependsOn: []string{"soft failed"}}, "soft failed"),
+		dependencyFailedResult(&config.VerifyCase{Name: "soft", Soft: true, DependsOn: []string{"soft failed"}}, "soft failed"),
+	}
+	if !res[2].Skip || res[2].Err != nil {
+		t.Errorf("dependencyFailedResult() = %+v, the soft case should be skipped", res[2])
+	}
+
+	var verifyErr *e2eerrors.VerifyError
+	if err := failedCasesError(res); !errors.As(err, &verifyErr) || len(verifyErr.Errs) != 1 ||
+		verifyErr.Errs[0].Error() != "the dependency soft failed of hard didn't pass" {
+		t.Errorf("failedCasesError() = %v, the hard case depending on the failed soft case should fail", err)
+	}
+}
@@ -29,16 +29,27 @@ import (
 )
 
 func ComposeCleanUp(conf *config.E2EConfig) error {
+	// the project started in this process is torn down by the arguments it's started with,
+	// the project is only torn down by the compose file here if it's started by another process
+	if tracked, err := setup.ComposeCleanNotify(setup.GetIdentity()); tracked {
+		return err
+	}
+
 	composeFilePath := conf.Setup.GetFile()
 	logger.Log.Infof("deleting docker compose cluster...\n")
 
 	if composeFilePath == "" {
 		return fmt.Errorf("no compose config file was provided")
 	}
+	downArgs, err := setup.ComposeDownArgs(conf.Setup.Compose.StopTimeout)
+	if err != nil {
+		return err
+	}
 	composeFilePaths := []string{composeFilePath}
 	identifier := setup.GetIdentity()
 	compose := testcontainers.NewLocalDockerCompose(composeFilePaths, identifier)
-	down := compose.Down()
+	compose.WithEnv(setup.ComposeEnv(conf.Setup.Compose.GetDockerHost()))
+	down := compose.WithCommand(downArgs).Invoke()
 	if down.Error != nil {
 		return down.Error
 	}
@@ -20,12 +20,9 @@ package cleanup
 
 import (
 	"os"
-	"strings"
 	"time"
 
-	kind "sigs.k8s.io/kind/cmd/kind/app"
-	kindcmd "sigs.k8s.io/kind/pkg/cmd"
-
+	"github.com/apache/skywalking-infra-e2e/internal/components/setup"
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
@@ -38,26 +35,36 @@ const (
 )
 
 func KindCleanUp(e2eConfig *config.E2EConfig) error {
-	kindConfigFilePath := e2eConfig.Setup.GetFile()
+	kindConfigFilePath, err := setup.GetKindConfigPath(&e2eConfig.Setup)
+	if err != nil {
+		return err
+	}
+	defer setup.RemoveKindConfigFile(&e2eConfig.Setup)
+
+	if e2eConfig.Setup.Kind.ExportLogs == constant.ExportLogsAlways {
+		// archive the logs before the cluster is gone, the cluster is deleted even if exporting failed
+		if err := setup.ExportKindLogs(e2eConfig); err != nil {
+			logger.Log.Warnf("%v", err)
+		}
+	}
 
 	logger.Log.Infof("deleting kind cluster...\n")
-	if err := cleanKindCluster(kindConfigFilePath); err != nil {
+	if err := cleanKindCluster(&e2eConfig.Setup.Kind, kindConfigFilePath); err != nil {
 		logger.Log.Error("delete kind cluster failed")
 		return err
 	}
 	logger.Log.Info("delete kind cluster succeeded")
 
-	kubeConfigPath := constant.K8sClusterConfigFilePath
+	kubeConfigPath := setup.GetKindKubeConfigPath()
 	logger.Log.Infof("deleting k8s cluster config file:%s", kubeConfigPath)
-	err := os.Remove(kubeConfigPath)
-	if err != nil {
+	if err := os.Remove(kubeConfigPath); err != nil {
 		logger.Log.Infoln("delete k8s cluster config file failed")
 	}
 
 	return nil
 }
 
-func cleanKindCluster(kindConfigFilePath string) (err error) {
+func cleanKindCluster(kindSetup *config.KindSetup, kindConfigFilePath string) (err error) {
 	clusterName, err := util.GetKindClusterName(kindConfigFilePath)
 	if err != nil {
 		return err
@@ -65,11 +72,9 @@ func cleanKindCluster(kindConfigFilePath string) (err error) {
 
 	args := []string{"delete", "cluster", "--name", clusterName}
 
-	logger.Log.Debugf("cluster delete commands: %s %s", constant.KindCommand, strings.Join(args, " "))
-
 	// Sometimes kind delete cluster failed, so we retry it.
 	for i := 0; i < maxRetry; i++ {
-		if err = kind.Run(kindcmd.NewLogger(), kindcmd.StandardIOStreams(), args); err == nil {
+		if err = setup.RunKind(kindSetup, args); err == nil {
 			return nil
 		}
 		time.Sleep(retryInterval * time.Second)
@@ -19,54 +19,72 @@
 package setup
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
 )
 
+// redactedValue replaces the sensitive values of the exported env vars in the logs.
+const redactedValue = "******"
+
 var (
 	logFollower *util.ResourceLogFollower
+
+	// currentEnvironment is the name of the environment in the multi-environment run, empty for the single setup.
+	currentEnvironment string
+
+	// exportPrefix and exportFormat customize the keys of the exported env vars, see SetExportEnv.
+	exportPrefix string
+	exportFormat *template.Template
+
+	// exportedEnv records the env vars exported by the setup, see ExportedEnv.
+	exportedEnv sync.Map
+
+	// instanceEnv records the env vars of every instance of the scaled services, see InstanceEnv.
+	instanceEnv     = make(map[string][]map[string]string)
+	instanceEnvLock sync.Mutex
+
+	// envFileOutLock guards writing the exported env vars into util.EnvFileOut,
+	// envFileOutPath is the file truncated by the first exported env var of the process.
+	envFileOutLock sync.Mutex
+	envFileOutPath string
 )
 
-func RunStepsAndWait(steps []config.Step, waitTimeout time.Duration, k8sCluster *util.K8sClusterInfo) error {
+// exportKey is the data of the export-env format template.
+type exportKey struct {
+	Environment string
+	Key         string
+}
+
+func RunStepsAndWait(steps []config.Step, waitTimeout time.Duration, k8sCluster *util.K8sClusterInfo, composeExecutor *composeServiceExecutor) error {
 	logger.Log.Debugf("wait timeout is %v", waitTimeout.String())
 
 	// record time now
 	timeNow := time.Now()
 
-	for _, step := range steps {
-		logger.Log.Infof("processing setup step [%s]", step.Name)
-
-		if step.Path != "" && step.Command == "" {
-			if k8sCluster == nil {
-				return fmt.Errorf("not support path")
-			}
-			manifest := config.Manifest{
-				Path:  step.Path,
-				Waits: step.Waits,
-			}
-			err := createManifestAndWait(k8sCluster, manifest, waitTimeout)
-			if err != nil {
-				return err
-			}
-		} else if step.Command != "" && step.Path == "" {
-			command := config.Run{
-				Command: step.Command,
-				Waits:   step.Waits,
-			}
-
-			err := RunCommandsAndWait(command, waitTimeout, k8sCluster)
-			if err != nil {
-				return err
-			}
-		} else {
-			return fmt.Errorf("step parameter error, one Path or one Command should be specified, but got %+v", step)
+	for _, group := range stepGroups(steps) {
+		// the remaining steps are not run when the run is stopped by the deadline
+		if err := util.RunContext().Err(); err != nil {
+			return err
+		}
+		if err := runStepGroup(group, waitTimeout, k8sCluster, composeExecutor); err != nil {
+			return err
 		}
 
 		waitTimeout = NewTimeout(timeNow, waitTimeout)
@@ -79,15 +97,137 @@ func RunStepsAndWait(steps []config.Step, waitTimeout time.Duration, k8sCluster
 	return nil
 }
 
+// stepGroups groups the consecutive parallel steps together, the other steps are in their own groups,
+// so that the steps before and after a group are still run in order.
+func stepGroups(steps []config.Step) [][]config.Step {
+	groups := make([][]config.Step, 0, len(steps))
+	for i := range steps {
+		if last := len(groups) - 1; steps[i].Parallel && last >= 0 && groups[last][0].Parallel {
+			groups[last] = append(groups[last], steps[i])
+			continue
+		}
+		groups = append(groups, []config.Step{steps[i]})
+	}
+	return groups
+}
+
+// runStepGroup runs the steps of the group concurrently, and waits for all of them to finish,
+// the errors of the failed steps are joined.
+func runStepGroup(group []config.Step, waitTimeout time.Duration, k8sCluster *util.K8sClusterInfo,
+	composeExecutor *composeServiceExecutor) error {
+	if len(group) == 1 {
+		logger.Log.Infof("processing setup step [%s]", group[0].Name)
+		if err := runStepAndWait(&group[0], waitTimeout, k8sCluster, composeExecutor); err != nil {
+			return &e2eerrors.StepError{Step: group[0].Name, Err: err}
+		}
+		return nil
+	}
+
+	errs := make([]error, len(group))
+	var wg sync.WaitGroup
+	for i := range group {
+		step := &group[i]
+		logger.Log.Infof("processing setup step [%s] in parallel", step.Name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := runStepAndWait(step, waitTimeout, k8sCluster, composeExecutor); err != nil {
+				errs[i] = &e2eerrors.StepError{Step: step.Name, Err: err}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runStepAndWait runs the step of the path, the command, the delete, the sleep or the exec, and waits for the conditions.
+func runStepAndWait(step *config.Step, waitTimeout time.Duration, k8sCluster *util.K8sClusterInfo,
+	composeExecutor *composeServiceExecutor) error {
+	if step.Sleep != "" {
+		if step.Path != "" || step.Command != "" || step.Kustomize != "" || step.Delete != nil || step.Exec != nil || len(step.Waits) > 0 {
+			return fmt.Errorf("step parameter error, Sleep can't be specified with Path, Command, Kustomize, Delete, Exec or Wait, but got %+v", step)
+		}
+		return sleepStep(step.Sleep, waitTimeout)
+	} else if step.Exec != nil {
+		if step.Path != "" || step.Command != "" || step.Kustomize != "" || step.Delete != nil || len(step.Waits) > 0 {
+			return fmt.Errorf("step parameter error, Exec can't be specified with Path, Command, Kustomize, Delete or Wait, but got %+v", step)
+		}
+		if composeExecutor == nil {
+			return fmt.Errorf("not support exec")
+		}
+		return composeExecutor.execInService(step.Exec, waitTimeout)
+	} else if step.Delete != nil {
+		if step.Path != "" || step.Command != "" || step.Kustomize != "" {
+			return fmt.Errorf("step parameter error, Delete can't be specified with Path, Command or Kustomize, but got %+v", step)
+		}
+		if k8sCluster == nil {
+			return fmt.Errorf("not support delete")
+		}
+		return deleteResourcesAndWait(k8sCluster, step.Delete, step.Waits, waitTimeout)
+	} else if step.Kustomize != "" {
+		if step.Path != "" || step.Command != "" {
+			return fmt.Errorf("step parameter error, Kustomize can't be specified with Path or Command, but got %+v", step)
+		}
+		if k8sCluster == nil {
+			return fmt.Errorf("not support kustomize")
+		}
+		return createKustomizationAndWait(k8sCluster, step.Kustomize, step.Waits, waitTimeout)
+	} else if step.Path != "" && step.Command == "" {
+		if k8sCluster == nil {
+			return fmt.Errorf("not support path")
+		}
+		manifest := config.Manifest{
+			Path:      step.Path,
+			Waits:     step.Waits,
+			FileWaits: step.FileWaits,
+			Apply:     step.Apply,
+		}
+		return createManifestAndWait(k8sCluster, manifest, waitTimeout)
+	} else if step.Command != "" && step.Path == "" {
+		command := config.Run{
+			Command: step.Command,
+			Waits:   step.Waits,
+		}
+		return RunCommandsAndWait(command, waitTimeout, k8sCluster)
+	}
+	return fmt.Errorf("step parameter error, one Path, one Command, one Kustomize, one Delete, one Sleep or one Exec should be specified, but got %+v", step)
+}
+
+// sleepStep waits for the duration of the sleep step, fails immediately if the duration exceeds the remaining timeout.
+func sleepStep(sleep string, timeout time.Duration) error {
+	duration, err := time.ParseDuration(sleep)
+	if err != nil {
+		return fmt.Errorf("failed to parse sleep %s: %v", sleep, err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("sleep should be > 0, but was %s", sleep)
+	}
+	if duration > timeout {
+		return &e2eerrors.WaitTimeoutError{Resource: "step", Condition: fmt.Sprintf("sleep %s", duration), Timeout: timeout}
+	}
+
+	logger.Log.Infof("sleeping %s", duration)
+	// the sleep is interrupted when the run is stopped by the deadline
+	if err := util.Sleep(duration); err != nil {
+		return err
+	}
+	logger.Log.Infof("slept %s", duration)
+	return nil
+}
+
 // createManifestAndWait creates manifests in k8s cluster and concurrent waits according to the manifests' wait conditions.
 func createManifestAndWait(c *util.K8sClusterInfo, manifest config.Manifest, timeout time.Duration) error {
-	waitSet := util.NewWaitSet(timeout)
-
-	waits := manifest.Waits
-	err := createByManifest(c, manifest)
+	start := time.Now()
+	err := createByManifest(c, manifest, timeout)
 	if err != nil {
 		return err
 	}
+	return concurrentlyWaitAll(c, manifest.Waits, "manifest", NewTimeout(start, timeout))
+}
+
+// concurrentlyWaitAll concurrently waits for all the conditions of the target.
+func concurrentlyWaitAll(c *util.K8sClusterInfo, waits []config.Wait, target string, timeout time.Duration) error {
+	waitSet := util.NewWaitSet(timeout)
 
 	// len() for nil slices is defined as zero
 	if len(waits) == 0 {
@@ -99,7 +239,7 @@ func createManifestAndWait(c *util.K8sClusterInfo, manifest config.Manifest, tim
 		wait := waits[idx]
 		logger.Log.Infof("waiting for %+v", wait)
 
-		options, err := getWaitOptions(c, &wait)
+		options, err := getWaiter(c, &wait)
 		if err != nil {
 			return err
 		}
@@ -115,12 +255,14 @@ func createManifestAndWait(c *util.K8sClusterInfo, manifest config.Manifest, tim
 
 	select {
 	case <-waitSet.FinishChan:
-		logger.Log.Infof("create and wait for manifest ready success")
+		logger.Log.Infof("wait for %s ready success", target)
 	case err := <-waitSet.ErrChan:
-		logger.Log.Errorf("failed to wait for manifest to be ready")
+		logger.Log.Errorf("failed to wait for %s to be ready", target)
 		return err
 	case <-time.After(waitSet.Timeout):
-		return fmt.Errorf("wait for manifest ready timeout after %d seconds", int(timeout.Seconds()))
+		return &e2eerrors.WaitTimeoutError{Resource: target, Condition: "ready", Timeout: timeout}
+	case <-util.RunContext().Done():
+		return util.RunContext().Err()
 	}
 
 	return nil
@@ -150,7 +292,9 @@ func RunCommandsAndWait(run config.Run, timeout time.Duration, cluster *util.K8s
 		logger.Log.Errorf("execute command error")
 		return err
 	case <-time.After(waitSet.Timeout):
-		return fmt.Errorf("wait for commands run timeout after %d seconds", int(timeout.Seconds()))
+		return &e2eerrors.WaitTimeoutError{Resource: "commands", Condition: "run", Timeout: timeout}
+	case <-util.RunContext().Done():
+		return util.RunContext().Err()
 	}
 
 	return nil
@@ -173,7 +317,7 @@ func executeCommandsAndWait(commands string, waits []config.Wait, waitSet *util.
 		wait := waits[idx]
 		logger.Log.Infof("waiting for %+v", wait)
 
-		options, err := getWaitOptions(cluster, &wait)
+		options, err := getWaiter(cluster, &wait)
 		if err != nil {
 			err = fmt.Errorf("commands: [%s] get wait options error: %s", commands, err)
 			waitSet.ErrChan <- err
@@ -189,6 +333,21 @@ func executeCommandsAndWait(commands string, waits []config.Wait, waitSet *util.
 	}
 }
 
+// RunHook executes the commands of the hook, such as `setup.before` and `cleanup.after`.
+func RunHook(name, commands string) error {
+	if commands == "" {
+		return nil
+	}
+
+	logger.Log.Infof("executing %s commands [%s]", name, strings.ReplaceAll(commands, "\n", "\\n"))
+	result, stderr, err := util.ExecuteCommand(commands)
+	if err != nil {
+		return fmt.Errorf("%s commands: [%s] runs error: %s", name, strings.ReplaceAll(commands, "\n", "\\n"), stderr)
+	}
+	logger.Log.Infof("executed %s commands, result: %s", name, result)
+	return nil
+}
+
 // NewTimeout calculates new timeout since timeBefore.
 func NewTimeout(timeBefore time.Time, timeout time.Duration) time.Duration {
 	elapsed := time.Since(timeBefore)
@@ -199,11 +358,249 @@ func NewTimeout(timeBefore time.Time, timeout time.Duration) time.Duration {
 func GetIdentity() string {
 	runID := os.Getenv("GITHUB_RUN_ID")
 	if runID == "" {
-		return "skywalking_e2e"
+		runID = "skywalking_e2e"
+	}
+	if currentEnvironment != "" {
+		return fmt.Sprintf("%s_%s", runID, currentEnvironment)
 	}
 	return runID
 }
 
+// SetEnvironment switches the environment to set up or clean up, the exported env vars and the
+// created resources are isolated by the environment name in the multi-environment run.
+func SetEnvironment(name string) {
+	currentEnvironment = name
+}
+
+// SetExportEnv sets the prefix and the format template of the exported env var keys,
+// the format is validated here so that formatting the keys never fails.
+func SetExportEnv(exportEnv config.ExportEnv) error {
+	exportPrefix, exportFormat = exportEnv.Prefix, nil
+	if exportEnv.Format == "" {
+		return nil
+	}
+
+	format, err := template.New("export-env").
+		Funcs(template.FuncMap{"upper": strings.ToUpper, "lower": strings.ToLower}).
+		Option("missingkey=error").
+		Parse(exportEnv.Format)
+	if err != nil {
+		return fmt.Errorf("failed to parse setup.export-env.format: %v", err)
+	}
+	if err := format.Execute(io.Discard, exportKey{}); err != nil {
+		return fmt.Errorf("failed to execute setup.export-env.format: %v", err)
+	}
+	exportFormat = format
+	return nil
+}
+
+// environmentKey formats the env var key with the prefix and the current environment name, such as `<env>_<service>_host`.
+func environmentKey(key string) string {
+	if exportFormat != nil {
+		var formatted strings.Builder
+		_ = exportFormat.Execute(&formatted, exportKey{Environment: currentEnvironment, Key: key})
+		return exportPrefix + formatted.String()
+	}
+	if currentEnvironment == "" {
+		return exportPrefix + key
+	}
+	return fmt.Sprintf("%s%s_%s", exportPrefix, currentEnvironment, key)
+}
+
+// ExportSetupEnvVars exports the static env vars of `setup.env-vars` in the order of the keys, the env vars in the values are expanded,
+// so that the values could refer to the env vars exported before, such as `${NAMESPACE}-release`. The values may be the tokens
+// expanded from the CI secrets, so they're treated as sensitive.
+func ExportSetupEnvVars(envVars map[string]string) error {
+	keys := make([]string, 0, len(envVars))
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := exportSensitiveEnv(key, os.ExpandEnv(envVars[key]), fmt.Sprintf("setup.env-vars.%s", key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordExportedEnv records the exported env var, so that the access info of the environment could be printed.
+func recordExportedEnv(key, value string) {
+	exportedEnv.Store(key, value)
+	if err := writeEnvFileOut(key, value); err != nil {
+		logger.Log.Warnf("failed to write the env var %s into %s: %v", key, util.EnvFileOut, err)
+	}
+}
+
+// exportSensitiveEnv exports the env var whose value is sensitive, such as the value of a secret, the value is redacted in the logs
+// and kept out of the access info, it's only written into the file of `--env-file-out` to access the environment.
+func exportSensitiveEnv(key, value, res string) error {
+	key = environmentKey(key)
+	if err := os.Setenv(key, value); err != nil {
+		return fmt.Errorf("could not set env for %s, %v", res, err)
+	}
+	logger.Log.Infof("export %s=%s", key, redactedValue)
+	if err := writeEnvFileOut(key, value); err != nil {
+		logger.Log.Warnf("failed to write the env var %s into %s: %v", key, util.EnvFileOut, err)
+	}
+	return nil
+}
+
+// writeEnvFileOut appends the exported env var to the file of `--env-file-out` in the dotenv format,
+// so that the environment could be accessed by `source` it, the file of the previous run is truncated.
+func writeEnvFileOut(key, value string) error {
+	if util.EnvFileOut == "" {
+		return nil
+	}
+	envFileOutLock.Lock()
+	defer envFileOutLock.Unlock()
+
+	flag := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if envFileOutPath != util.EnvFileOut {
+		flag |= os.O_TRUNC
+		envFileOutPath = util.EnvFileOut
+	}
+	file, err := os.OpenFile(util.EnvFileOut, flag, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(file, "%s=%s\n", key, dotenvValue(value)); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// dotenvValue single-quotes the value unless it only contains the safe characters, such as the hosts and ports.
+func dotenvValue(value string) string {
+	safe := value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@,", r))
+	}) < 0
+	if safe {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// countLogLines counts the log lines matching the pattern.
+func countLogLines(reader io.Reader, pattern *regexp.Regexp) (int, error) {
+	scanner := bufio.NewScanner(reader)
+	// the long lines such as the stack traces are counted as well
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	count := 0
+	for scanner.Scan() {
+		if pattern.Match(scanner.Bytes()) {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// recordInstanceEnv records the env var of the instance of the scaled service by the key of the service,
+// which is the one the query refers to, such as `<service>_host` formatted by the export-env.
+func recordInstanceEnv(service string, number int, key, value string) {
+	if currentEnvironment != "" {
+		service = fmt.Sprintf("%s_%s", currentEnvironment, service)
+	}
+
+	instanceEnvLock.Lock()
+	defer instanceEnvLock.Unlock()
+	instances := instanceEnv[service]
+	for len(instances) < number {
+		instances = append(instances, make(map[string]string))
+	}
+	instances[number-1][key] = value
+	instanceEnv[service] = instances
+}
+
+// InstanceEnv returns the env vars of every instance of the scaled service exported by the setup, the service is prefixed
+// with the environment name in the multi-environment run, such as `<env>_<service>`. It's nil if the service isn't set up
+// in this process, such as running `e2e verify` alone.
+func InstanceEnv(service string) []map[string]string {
+	instanceEnvLock.Lock()
+	defer instanceEnvLock.Unlock()
+	instances := make([]map[string]string, 0, len(instanceEnv[service]))
+	for _, env := range instanceEnv[service] {
+		copied := make(map[string]string, len(env))
+		for key, value := range env {
+			copied[key] = value
+		}
+		instances = append(instances, copied)
+	}
+	if len(instances) == 0 {
+		return nil
+	}
+	return instances
+}
+
+// ExportedEnv returns the env vars exported by the setup, such as the kubeconfig and the hosts and ports of the services.
+func ExportedEnv() map[string]string {
+	env := make(map[string]string)
+	exportedEnv.Range(func(key, value any) bool {
+		env[key.(string)] = value.(string)
+		return true
+	})
+	return env
+}
+
+// GetKindConfigPath returns the kind config file of the setup, the inline `kind.content` is written into a temporary file
+// with the env vars expanded, which is removed by RemoveKindConfigFile.
+func GetKindConfigPath(s *config.Setup) (string, error) {
+	if s.Kind.Content == "" {
+		return s.GetFile(), nil
+	}
+
+	path := inlineKindConfigPath()
+	if err := os.WriteFile(path, []byte(os.ExpandEnv(s.Kind.Content)), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write the inline kind config into %s: %v", path, err)
+	}
+	return path, nil
+}
+
+// RemoveKindConfigFile removes the temporary file of the inline kind config.
+func RemoveKindConfigFile(s *config.Setup) {
+	if s.Kind.Content == "" {
+		return
+	}
+	if err := os.Remove(inlineKindConfigPath()); err != nil && !os.IsNotExist(err) {
+		logger.Log.Warnf("failed to remove the inline kind config file: %v", err)
+	}
+}
+
+func inlineKindConfigPath() string {
+	if currentEnvironment == "" {
+		return filepath.Join(os.TempDir(), "e2e-kind-config.yaml")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("e2e-kind-config-%s.yaml", currentEnvironment))
+}
+
+// contextKubeConfigPath returns the copy of the kubeconfig with the context of `kube-context` switched, the path is fixed by the environment,
+// so that the copy is overwritten by the next setup and removed by RemoveContextKubeConfigFile of the cleanup in another process.
+func contextKubeConfigPath() string {
+	if currentEnvironment == "" {
+		return filepath.Join(os.TempDir(), "e2e-kubeconfig-context.config")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("e2e-kubeconfig-context-%s.config", currentEnvironment))
+}
+
+// RemoveContextKubeConfigFile removes the copy of the kubeconfig with the context of `kube-context` switched, which contains the credentials.
+func RemoveContextKubeConfigFile(s *config.Setup) {
+	if s.GetKubeconfig() == "" || s.KubeContext == "" {
+		return
+	}
+	if err := os.Remove(contextKubeConfigPath()); err != nil && !os.IsNotExist(err) {
+		logger.Log.Warnf("failed to remove the kubeconfig file of the context %s: %v", s.KubeContext, err)
+	}
+}
+
+// GetKindKubeConfigPath returns the kubeconfig file path of the kind cluster created by the current environment.
+func GetKindKubeConfigPath() string {
+	if currentEnvironment == "" {
+		return constant.K8sClusterConfigFilePath
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("e2e-k8s-%s.config", currentEnvironment))
+}
+
 func InitLogFollower() {
 	logFollower = util.NewResourceLogFollower(context.Background(), util.LogDir)
 }
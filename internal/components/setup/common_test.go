@@ -0,0 +1,218 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+func TestEnvironmentKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		exportEnv   config.ExportEnv
+		environment string
+		want        string
+		wantErr     bool
+	}{
+		{name: "should keep the key by default", want: "oap_host"},
+		{name: "should prefix the environment name", environment: "primary", want: "primary_oap_host"},
+		{name: "should prepend the prefix", exportEnv: config.ExportEnv{Prefix: "E2E_"}, environment: "primary", want: "E2E_primary_oap_host"},
+		{
+			name:        "should format the key with the template",
+			exportEnv:   config.ExportEnv{Prefix: "E2E_", Format: "{{ upper .Key }}_{{ upper .Environment }}"},
+			environment: "primary",
+			want:        "E2E_OAP_HOST_PRIMARY",
+		},
+		{name: "should fail when the format is invalid", exportEnv: config.ExportEnv{Format: "{{ .Key"}, wantErr: true},
+		{name: "should fail when the format uses unknown fields", exportEnv: config.ExportEnv{Format: "{{ .Service }}"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				_ = SetExportEnv(config.ExportEnv{})
+				SetEnvironment("")
+			}()
+			if err := SetExportEnv(tt.exportEnv); (err != nil) != tt.wantErr {
+				t.Fatalf("SetExportEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			SetEnvironment(tt.environment)
+			if got := environmentKey("oap_host"); got != tt.want {
+				t.Errorf("environmentKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportSetupEnvVars(t *testing.T) {
+	t.Setenv("E2E_NAMESPACE", "")
+	t.Setenv("E2E_RELEASE", "")
+	t.Setenv("E2E_primary_NAMESPACE", "")
+	defer func() {
+		_ = SetExportEnv(config.ExportEnv{})
+		SetEnvironment("")
+	}()
+	if err := SetExportEnv(config.ExportEnv{Prefix: "E2E_"}); err != nil {
+		t.Fatal(err)
+	}
+
+	envVars := map[string]string{"RELEASE": "${E2E_NAMESPACE}-oap", "NAMESPACE": "skywalking"}
+	if err := ExportSetupEnvVars(envVars); err != nil {
+		t.Fatalf("ExportSetupEnvVars() error = %v", err)
+	}
+	if got := os.Getenv("E2E_RELEASE"); got != "skywalking-oap" {
+		t.Errorf("E2E_RELEASE = %q, want %q", got, "skywalking-oap")
+	}
+
+	SetEnvironment("primary")
+	if err := ExportSetupEnvVars(map[string]string{"NAMESPACE": "primary"}); err != nil {
+		t.Fatalf("ExportSetupEnvVars() error = %v", err)
+	}
+	if got := os.Getenv("E2E_primary_NAMESPACE"); got != "primary" {
+		t.Errorf("E2E_primary_NAMESPACE = %q, want %q", got, "primary")
+	}
+	// the values may be the tokens, which are kept out of the access info
+	if got, exists := ExportedEnv()["E2E_primary_NAMESPACE"]; exists {
+		t.Errorf("ExportedEnv() = %q, should not contain the value of setup.env-vars", got)
+	}
+}
+
+func TestSleepStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		sleep   string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "should sleep for the duration", sleep: "10ms", timeout: time.Second},
+		{name: "should fail when the duration is invalid", sleep: "10", timeout: time.Second, wantErr: true},
+		{name: "should fail when the duration is not positive", sleep: "-1s", timeout: time.Second, wantErr: true},
+		{name: "should fail when the duration exceeds the timeout", sleep: "1m", timeout: time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := sleepStep(tt.sleep, tt.timeout); (err != nil) != tt.wantErr {
+				t.Errorf("sleepStep() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSleepStepStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer util.SetRunContext(ctx)()
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	start := time.Now()
+	if err := sleepStep("1m", time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleepStep() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("sleepStep() took %s after the run is stopped", elapsed)
+	}
+}
+
+func TestStepGroups(t *testing.T) {
+	steps := []config.Step{
+		{Name: "namespace"},
+		{Name: "oap", Parallel: true},
+		{Name: "ui", Parallel: true},
+		{Name: "init"},
+		{Name: "mysql", Parallel: true},
+	}
+	got := make([][]string, 0)
+	for _, group := range stepGroups(steps) {
+		names := make([]string, 0, len(group))
+		for i := range group {
+			names = append(names, group[i].Name)
+		}
+		got = append(got, names)
+	}
+	want := [][]string{{"namespace"}, {"oap", "ui"}, {"init"}, {"mysql"}}
+	if !cmp.Equal(got, want) {
+		t.Errorf("stepGroups() diff (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestRunStepGroup(t *testing.T) {
+	start := time.Now()
+	group := []config.Step{
+		{Name: "oap", Sleep: "200ms", Parallel: true},
+		{Name: "ui", Sleep: "200ms", Parallel: true},
+	}
+	if err := runStepGroup(group, time.Second, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 400*time.Millisecond {
+		t.Errorf("runStepGroup() took %v, the steps should run in parallel", elapsed)
+	}
+
+	group = []config.Step{
+		{Name: "oap", Sleep: "invalid", Parallel: true},
+		{Name: "ui", Sleep: "10ms", Parallel: true},
+		{Name: "mysql", Sleep: "-1s", Parallel: true},
+	}
+	err := runStepGroup(group, time.Second, nil, nil)
+	if err == nil {
+		t.Fatal("runStepGroup() should fail")
+	}
+	var failed []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var stepErr *e2eerrors.StepError
+		if errors.As(e, &stepErr) {
+			failed = append(failed, stepErr.Step)
+		}
+	}
+	if want := []string{"oap", "mysql"}; !cmp.Equal(failed, want) {
+		t.Errorf("runStepGroup() failed steps = %v, want %v", failed, want)
+	}
+}
+
+func TestWriteEnvFileOut(t *testing.T) {
+	util.EnvFileOut = filepath.Join(t.TempDir(), "e2e.env")
+	defer func() { util.EnvFileOut = "" }()
+	if err := os.WriteFile(util.EnvFileOut, []byte("stale=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	recordExportedEnv("oap_host", "127.0.0.1")
+	recordExportedEnv("oap_12800", "32768")
+	recordExportedEnv("token", "it's a secret")
+
+	content, err := os.ReadFile(util.EnvFileOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "oap_host=127.0.0.1\noap_12800=32768\ntoken='it'\\''s a secret'\n"
+	if string(content) != want {
+		t.Errorf("env file = %q, want %q", content, want)
+	}
+}
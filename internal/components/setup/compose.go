@@ -19,13 +19,19 @@
 package setup
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -33,10 +39,12 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
 
 	"github.com/testcontainers/testcontainers-go"
@@ -49,12 +57,78 @@ const (
 	// SeparatorV2 is the separator used in docker-compose v2
 	// refer to https://github.com/docker/compose/blob/981aea674d052ee1ab252f71c3ca1f9f8a7e32de/pkg/compose/convergence.go#L252-L257
 	SeparatorV2 = "-"
+
+	// the container may not be listed immediately after `up -d` on the busy docker daemons
+	findContainerTimeout  = 10 * time.Second
+	findContainerInterval = 500 * time.Millisecond
+
+	readinessInterval = time.Second
+
+	// the labels of the containers created by compose, whose values are the project and the service names
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
 )
 
 var (
 	containerNamePattern = regexp.MustCompile(`.*_(?P<containerNum>\d+)$`)
+
+	// defaultUpFlags removes the containers left by the previous runs, so that each run starts clean.
+	defaultUpFlags = []string{"--remove-orphans"}
+
+	// composeProjects are the compose projects started in this process, which are torn down by ComposeCleanNotify
+	// when their environments are cleaned up.
+	composeProjects []*composeProject
+
+	// Recreate tears down the running compose project and deploys it again, rather than reusing it.
+	Recreate bool
 )
 
+// composeProject is the compose project started in this process with the arguments to tear it down.
+type composeProject struct {
+	compose  *testcontainers.LocalDockerCompose
+	downArgs []string
+}
+
+// ComposeShouldWaitSignal returns whether there are compose projects started in this process.
+func ComposeShouldWaitSignal() bool {
+	return len(composeProjects) > 0
+}
+
+// ComposeCleanNotify tears down the compose project of the identity by the arguments it's started with when clean up,
+// returns false if the project is not started in this process, such as cleaning up by a separate `e2e cleanup`.
+func ComposeCleanNotify(identity string) (bool, error) {
+	for i, project := range composeProjects {
+		if project.compose.Identifier != identity {
+			continue
+		}
+		composeProjects = append(composeProjects[:i], composeProjects[i+1:]...)
+		logger.Log.Infof("tearing down docker compose project %s", identity)
+		if down := project.compose.WithCommand(project.downArgs).Invoke(); down.Error != nil {
+			return true, fmt.Errorf("tear down docker compose project %s error: %v", identity, down.Error)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// ComposeDownArgs builds the arguments of `compose down`, the containers are killed after the stop timeout if it's set.
+func ComposeDownArgs(stopTimeout string) ([]string, error) {
+	args := []string{"down", "--remove-orphans"}
+	if stopTimeout == "" {
+		return args, nil
+	}
+	timeout, err := time.ParseDuration(stopTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compose.stop-timeout %s: %v", stopTimeout, err)
+	}
+	if timeout < 0 {
+		return nil, fmt.Errorf("compose.stop-timeout should be >= 0, but was %s", stopTimeout)
+	}
+	// the timeout of compose is in seconds, round it up so that a short timeout doesn't become 0
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	return append(args, "--timeout", strconv.Itoa(seconds)), nil
+}
+
 // ComposeSetup sets up environment according to e2e.yaml.
 func ComposeSetup(e2eConfig *config.E2EConfig) error {
 	composeConfigPath := e2eConfig.Setup.GetFile()
@@ -62,8 +136,9 @@ func ComposeSetup(e2eConfig *config.E2EConfig) error {
 		return fmt.Errorf("no compose config file was provided")
 	}
 
-	// build docker client
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	// build docker client, the compose environment could target its own docker host
+	dockerHost := e2eConfig.Setup.Compose.GetDockerHost()
+	cli, err := newDockerClient(dockerHost)
 	if err != nil {
 		return err
 	}
@@ -74,6 +149,12 @@ func ComposeSetup(e2eConfig *config.E2EConfig) error {
 	}
 	identifier := GetIdentity()
 	compose := testcontainers.NewLocalDockerCompose(composeFilePaths, identifier)
+	compose.WithEnv(ComposeEnv(dockerHost))
+
+	downArgs, err := ComposeDownArgs(e2eConfig.Setup.Compose.StopTimeout)
+	if err != nil {
+		return err
+	}
 
 	// bind wait port
 	services, err := buildComposeServices(e2eConfig, compose)
@@ -89,33 +170,80 @@ func ComposeSetup(e2eConfig *config.E2EConfig) error {
 		util.ExportEnvVars(profilePath)
 	}
 	cmd = append(cmd, "up", "-d")
+	cmd = append(cmd, upFlags(e2eConfig.Setup.Compose.UpFlags)...)
+	cmd = append(cmd, nativeWaitArgs(e2eConfig.Setup.Compose.NativeWait, cmd)...)
+	cmd = append(cmd, scaleArgs(e2eConfig.Setup.Compose.Scale)...)
+
+	if Recreate {
+		logger.Log.Infof("tearing down docker compose project %s to recreate it", identifier)
+		if down := compose.WithCommand(downArgs).Invoke(); down.Error != nil {
+			return fmt.Errorf("failed to tear down docker compose project %s: %v", identifier, down.Error)
+		}
+	} else if running, err := composeProjectRunning(cli, identifier, services); err != nil {
+		return err
+	} else if running {
+		// the stack of the previous run is reused, only the env vars are exported again without waiting or running the steps
+		logger.Log.Infof("docker compose project %s is running already, use --recreate to deploy it again", identifier)
+		composeProjects = append(composeProjects, &composeProject{compose: compose, downArgs: downArgs})
+		return exposeComposeService(services, cli, identifier, e2eConfig, false)
+	}
+
+	// the ports of the remote docker host are not checked
+	if e2eConfig.Setup.CheckPorts && dockerHost == "" {
+		if err := checkPortsAvailable(composeFixedPorts(compose.Services), cli); err != nil {
+			return err
+		}
+	}
+
+	// load the images of the archives before pulling, so that they're not pulled
+	if err := loadImageArchives(context.Background(), cli, e2eConfig.Setup.Compose.LoadImages); err != nil {
+		return err
+	}
+
+	// pull the images which have registry credentials, so that the compose could use them directly,
+	// the other images are pulled by the compose itself
+	auths := newRegistryAuths(e2eConfig.Setup.Registries)
+	if images := auths.authenticatedImages(getComposeImages(compose)); len(images) > 0 {
+		if err := pullImages(context.Background(), images, auths, dockerHost, false); err != nil {
+			return err
+		}
+	}
 
 	// Listen container create
 	listener := NewComposeContainerListener(context.Background(), cli, services)
 	defer listener.Stop()
 	err = listener.Listen(func(container *ComposeContainer) {
-		if err = exposeComposeLog(cli, container.Service, container.ID, logFollower); err == nil {
-			container.Service.beenFollowLog = true
+		if err = exposeComposeLog(cli, container.Service, container.Number, container.ID, logFollower); err == nil {
+			container.Service.followedLogs.Store(container.Number, true)
 		}
 	})
 	if err != nil {
 		return err
 	}
 
-	// setup
-	execError := compose.WithCommand(cmd).Invoke()
-	if execError.Error != nil {
-		return execError.Error
+	// setup, the project is tracked even if it's failed to start, so that the started containers could be torn down
+	composeProjects = append(composeProjects, &composeProject{compose: compose, downArgs: downArgs})
+	err = composeUpWithRetry(e2eConfig.Setup.Compose.UpRetry, func() error {
+		return invokeCompose(compose, cmd)
+	}, func() error {
+		return invokeCompose(compose, downArgs)
+	})
+	if err != nil {
+		return err
 	}
 
 	// find exported port and build env
-	err = exposeComposeService(services, cli, identifier, e2eConfig)
+	err = exposeComposeService(services, cli, identifier, e2eConfig, true)
 	if err != nil {
 		return err
 	}
 
 	// run steps
-	err = RunStepsAndWait(e2eConfig.Setup.Steps, e2eConfig.Setup.GetTimeout(), nil)
+	executor := &composeServiceExecutor{
+		provider: newDockerProvider(cli, identifier, &e2eConfig.Setup.Compose),
+		identity: identifier,
+	}
+	err = RunStepsAndWait(e2eConfig.Setup.Steps, e2eConfig.Setup.GetTimeout(), nil, executor)
 	if err != nil {
 		logger.Log.Errorf("execute steps error: %v", err)
 		return err
@@ -124,46 +252,155 @@ func ComposeSetup(e2eConfig *config.E2EConfig) error {
 	return nil
 }
 
+// composeUpWithRetry runs up and re-runs it up to the count of the retry on failure, the partial state of the failed
+// attempt is torn down by down before the next attempt, the errors of all the attempts are returned if all of them failed.
+func composeUpWithRetry(retry config.ComposeUpRetry, up, down func() error) error {
+	var interval time.Duration
+	if retry.Interval != "" {
+		var err error
+		if interval, err = time.ParseDuration(retry.Interval); err != nil {
+			return fmt.Errorf("failed to parse compose.up-retry.interval %s: %v", retry.Interval, err)
+		}
+	}
+
+	var errs []error
+	for attempt := 0; ; attempt++ {
+		err := up()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("attempt %d/%d: %v", attempt+1, retry.Count+1, err))
+		if attempt >= retry.Count {
+			return fmt.Errorf("failed to start docker compose project: %w", errors.Join(errs...))
+		}
+		logger.Log.Warnf("docker compose up failed at attempt %d/%d: %v, retrying in %s", attempt+1, retry.Count+1, err, interval)
+		if err := down(); err != nil {
+			logger.Log.Warnf("failed to tear down the partially started docker compose project: %v", err)
+		}
+		if util.Sleep(interval) != nil {
+			return fmt.Errorf("failed to start docker compose project: %w", errors.Join(errs...))
+		}
+	}
+}
+
 type ComposeService struct {
-	Name           string
+	Name string
+	// Replicas is the number of the containers of the service, all of them are waited for and exported.
+	Replicas       int
 	waitStrategies []*hostPortCachedStrategy
-	beenFollowLog  bool
+	// readiness is checked inside the containers instead of waiting for the published ports if set.
+	readiness *config.ComposeReadiness
+	// healthchecked is true if the service is waited for by its healthcheck through `compose up --wait`,
+	// so that neither the readiness nor the published ports are checked again.
+	healthchecked bool
+	// followedLogs records the replica numbers whose logs have been followed.
+	followedLogs sync.Map
 }
 
+// newDockerProvider creates the provider of the compose project, which resolves the hosts and ports by the compose config.
+func newDockerProvider(cli *client.Client, identity string, compose *config.ComposeSetup) *DockerProvider {
+	return &DockerProvider{
+		client:   cli,
+		host:     compose.GetHost(),
+		ipFamily: compose.IPFamily,
+		network:  composeNetwork(compose.Network, identity),
+	}
+}
+
+// exposeComposeService exports the env vars of the services, the readiness and the ports are waited for if waitReady is true.
 func exposeComposeService(services []*ComposeService, cli *client.Client,
-	identity string, e2eConfig *config.E2EConfig) error {
-	dockerProvider := &DockerProvider{client: cli}
+	identity string, e2eConfig *config.E2EConfig, waitReady bool) error {
+	dockerProvider := newDockerProvider(cli, identity, &e2eConfig.Setup.Compose)
 
 	// find exported port and build env
 	for _, service := range services {
-		// expose port
-		if err := exposeComposePort(dockerProvider, service, cli, identity, e2eConfig); err != nil {
-			return err
-		}
-
-		// if service log not follow, expose log
-		if !service.beenFollowLog {
-			c, err := service.FindContainer(cli, identity)
+		serviceWaitReady := waitReady && !service.healthchecked
+		for number := 1; number <= service.Replicas; number++ {
+			container, err := service.FindContainer(cli, identity, number)
 			if err != nil {
-				logger.Log.Warn(err)
-				continue
+				return fmt.Errorf("could not find the container %d of service %s: %v", number, service.Name, err)
+			}
+			// expose container name and id
+			if err := exposeComposeContainer(service, number, container); err != nil {
+				return err
+			}
+
+			if serviceWaitReady && service.readiness != nil {
+				target := &DockerContainer{ID: container.ID, provider: dockerProvider}
+				if err := waitContainerReady(target, service.Name, service.readiness, e2eConfig.Setup.GetTimeout()); err != nil {
+					return err
+				}
 			}
-			if err := exposeComposeLog(dockerProvider.client, service, c.ID, logFollower); err != nil {
+
+			// expose port
+			if err := exposeComposePort(dockerProvider, service, number, container, e2eConfig, serviceWaitReady); err != nil {
 				return err
 			}
-			service.beenFollowLog = true
+
+			// if service log not follow, expose log
+			if _, followed := service.followedLogs.Load(number); !followed {
+				if err := exposeComposeLog(dockerProvider.client, service, number, container.ID, logFollower); err != nil {
+					return err
+				}
+				service.followedLogs.Store(number, true)
+			}
 		}
 	}
 	return nil
 }
 
-func (c *ComposeService) FindContainer(cli *client.Client, identity string) (*types.Container, error) {
+// FindContainer finds the container of the replica number of the service.
+func (c *ComposeService) FindContainer(cli *client.Client, identity string, number int) (*types.Container, error) {
 	serviceName, num := getInstanceName(c.Name)
-	return findContainer(cli, identity, serviceName, num)
+	if c.Replicas > 1 {
+		num = number
+	}
+	return findContainer(cli, identity, serviceName, num, findContainerTimeout)
+}
+
+// envNames returns the names of the replica used in the exported env vars, the first replica is exported
+// as `<service_name>` for compatibility, and every replica is also exported as `<service_name>_<number>` if scaled.
+func (c *ComposeService) envNames(number int) []string {
+	if c.Replicas <= 1 {
+		return []string{c.Name}
+	}
+	names := []string{fmt.Sprintf("%s_%d", c.Name, number)}
+	if number == 1 {
+		names = append([]string{c.Name}, names...)
+	}
+	return names
 }
 
-func exposeComposePort(dockerProvider *DockerProvider, service *ComposeService, cli *client.Client, identity string,
-	e2eConfig *config.E2EConfig) error {
+// exportComposeInstanceEnv exports the env var `<name>_<suffix>` of every env name of the replica, and records the one of the
+// scaled service as the instance env, so that the verify cases of `instances` find them whatever the export-env format is.
+func exportComposeInstanceEnv(service *ComposeService, number int, suffix, value string) error {
+	for _, name := range service.envNames(number) {
+		if err := exportComposeEnv(fmt.Sprintf("%s_%s", name, suffix), value, service.Name); err != nil {
+			return err
+		}
+	}
+	if service.Replicas > 1 {
+		recordInstanceEnv(service.Name, number, environmentKey(fmt.Sprintf("%s_%s", service.Name, suffix)), value)
+	}
+	return nil
+}
+
+// exposeComposeContainer exports the container name and id of the service, which could be used in
+// `docker logs` or `docker exec` without reconstructing the container name of different compose versions.
+func exposeComposeContainer(service *ComposeService, number int, container *types.Container) error {
+	// format: <service_name>_container
+	if len(container.Names) > 0 {
+		if err := exportComposeInstanceEnv(service, number, "container", strings.TrimPrefix(container.Names[0], "/")); err != nil {
+			return err
+		}
+	}
+
+	// format: <service_name>_container_id
+	return exportComposeInstanceEnv(service, number, "container_id", container.ID)
+}
+
+func exposeComposePort(dockerProvider *DockerProvider, service *ComposeService, number int, container *types.Container,
+	e2eConfig *config.E2EConfig, waitReady bool) error {
 	if len(service.waitStrategies) == 0 {
 		return nil
 	}
@@ -174,43 +411,68 @@ func exposeComposePort(dockerProvider *DockerProvider, service *ComposeService,
 		return err
 	}
 
-	container, err := service.FindContainer(cli, identity)
-	if err != nil {
-		return err
-	}
-
 	// format: <service_name>_host
-	if err := exportComposeEnv(fmt.Sprintf("%s_host", service.Name), host, service.Name); err != nil {
+	if err := exportComposeInstanceEnv(service, number, "host", hostForURL(host)); err != nil {
 		return err
 	}
 
 	for inx := range service.waitStrategies {
-		for _, containerPort := range container.Ports {
-			if int(containerPort.PrivatePort) != service.waitStrategies[inx].expectPort {
-				continue
-			}
+		expectPort := service.waitStrategies[inx].expectPort
+		containerPort := selectPublishedPort(container.Ports, expectPort, dockerProvider.ipFamily)
+		if containerPort == nil && container.HostConfig.NetworkMode == hostNetworkMode {
+			// the ports of the host network container are not published, they are listened on the host directly
+			containerPort = &types.Port{PrivatePort: uint16(expectPort), PublicPort: uint16(expectPort)}
+		}
+		if containerPort == nil {
+			continue
+		}
 
-			if err := waitPortUntilReady(e2eConfig, container, dockerProvider, service.waitStrategies[inx].expectPort); err != nil {
+		// the service with the readiness check is ready already, the published port is exported directly
+		if waitReady && service.readiness == nil {
+			if err := waitPortUntilReady(e2eConfig, container, dockerProvider, expectPort); err != nil {
 				return err
 			}
+		}
 
-			// expose env config to env
-			// format: <service_name>_<port>
-			if err := exportComposeEnv(
-				fmt.Sprintf("%s_%d", service.Name, containerPort.PrivatePort),
-				fmt.Sprintf("%d", containerPort.PublicPort),
-				service.Name); err != nil {
-				return err
-			}
-			break
+		// expose env config to env
+		// format: <service_name>_<port>
+		if err := exportComposeInstanceEnv(service, number,
+			fmt.Sprintf("%d", containerPort.PrivatePort), fmt.Sprintf("%d", containerPort.PublicPort)); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// export container log to local path
-func exposeComposeLog(cli *client.Client, service *ComposeService, containerID string, logFollower *util.ResourceLogFollower) error {
+// selectPublishedPort selects the published port of the container port, the port may be published on both
+// the IPv4 and IPv6 addresses in the dual-stack networks, the one of the preferred family is selected.
+func selectPublishedPort(ports []types.Port, privatePort int, family string) *types.Port {
+	candidates := make(map[string]*types.Port)
+	addresses := make([]string, 0)
+	for i := range ports {
+		if int(ports[i].PrivatePort) != privatePort {
+			continue
+		}
+		// the port without host address is published on all the addresses
+		address := ports[i].IP
+		if address == "" {
+			address = "0.0.0.0"
+		}
+		if _, exists := candidates[address]; !exists {
+			candidates[address] = &ports[i]
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+	return candidates[selectAddress(addresses, family)]
+}
+
+// export container log to local path, the logs of the scaled replicas are in `std_<number>.log`
+func exposeComposeLog(cli *client.Client, service *ComposeService, number int, containerID string,
+	logFollower *util.ResourceLogFollower) error {
 	logs, err := cli.ContainerLogs(logFollower.Ctx, containerID, types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -220,7 +482,11 @@ func exposeComposeLog(cli *client.Client, service *ComposeService, containerID s
 	if err != nil {
 		return err
 	}
-	writer, err := logFollower.BuildLogWriter(fmt.Sprintf("%s/std.log", service.Name))
+	logFile := "std.log"
+	if number > 1 {
+		logFile = fmt.Sprintf("std_%d.log", number)
+	}
+	writer, err := logFollower.BuildLogWriter(filepath.Join(currentEnvironment, service.Name, logFile))
 	if err != nil {
 		return err
 	}
@@ -239,21 +505,34 @@ func exposeComposeLog(cli *client.Client, service *ComposeService, containerID s
 }
 
 func exportComposeEnv(key, value, service string) error {
+	key = environmentKey(key)
 	err := os.Setenv(key, value)
 	if err != nil {
 		return fmt.Errorf("could not set env for %s, %v", service, err)
 	}
 	logger.Log.Infof("export %s=%s", key, value)
+	recordExportedEnv(key, value)
 	return nil
 }
 
 func buildComposeServices(e2eConfig *config.E2EConfig, compose *testcontainers.LocalDockerCompose) ([]*ComposeService, error) {
+	if err := validateReadiness(e2eConfig.Setup.Compose.Readiness, compose.Services); err != nil {
+		return nil, err
+	}
+
 	waitTimeout := e2eConfig.Setup.GetTimeout()
 	services := make([]*ComposeService, 0)
 	for service, content := range compose.Services {
 		serviceConfig := content.(map[any]any)
 		ports := serviceConfig["ports"]
-		serviceContext := &ComposeService{Name: service}
+		serviceContext := &ComposeService{Name: service, Replicas: getReplicas(serviceConfig, e2eConfig.Setup.Compose.Scale[service])}
+		if readiness, ok := e2eConfig.Setup.Compose.Readiness[service]; ok {
+			serviceContext.readiness = &readiness
+		}
+		if e2eConfig.Setup.Compose.NativeWait && hasHealthcheck(serviceConfig) {
+			logger.Log.Infof("service %s is waited for by its healthcheck through compose up --wait", service)
+			serviceContext.healthchecked = true
+		}
 		services = append(services, serviceContext)
 		if ports == nil {
 			continue
@@ -278,6 +557,257 @@ func buildComposeServices(e2eConfig *config.E2EConfig, compose *testcontainers.L
 	return services, nil
 }
 
+// hasHealthcheck returns whether the healthcheck of the service is declared in the compose file and not disabled
+// by `disable: true` or the `NONE` test, the healthchecks inherited from the images are not detected.
+func hasHealthcheck(serviceConfig map[any]any) bool {
+	healthcheck, ok := serviceConfig["healthcheck"].(map[any]any)
+	if !ok {
+		return false
+	}
+	if disable, ok := healthcheck["disable"].(bool); ok && disable {
+		return false
+	}
+	switch test := healthcheck["test"].(type) {
+	case nil:
+		return false
+	case string:
+		return test != "NONE"
+	case []any:
+		return len(test) > 0 && test[0] != "NONE"
+	}
+	return true
+}
+
+// validateReadiness checks the readiness of every service is declared in the compose file and has a port or a command.
+func validateReadiness(readiness map[string]config.ComposeReadiness, services map[string]any) error {
+	for service, r := range readiness {
+		if _, ok := services[service]; !ok {
+			return fmt.Errorf("the service %s of the readiness is not declared in the compose file", service)
+		}
+		if r.Port <= 0 && r.Command == "" && r.Log == nil {
+			return fmt.Errorf("the port, the command or the log of the readiness of service %s should be provided", service)
+		}
+		if r.Log != nil {
+			if _, err := regexp.Compile(r.Log.Pattern); err != nil || r.Log.Pattern == "" {
+				return fmt.Errorf("the log pattern %q of the readiness of service %s is invalid: %v", r.Log.Pattern, service, err)
+			}
+			if r.Log.Count < 0 {
+				return fmt.Errorf("the log count of the readiness of service %s should be >= 0, but was %d", service, r.Log.Count)
+			}
+		}
+	}
+	return nil
+}
+
+// readinessCommand builds the command checking the readiness inside the container, both the port and the command
+// should succeed if they're set.
+func readinessCommand(readiness *config.ComposeReadiness) string {
+	commands := make([]string, 0, 2)
+	if readiness.Port > 0 {
+		commands = append(commands, buildInternalCheckCommand(readiness.Port))
+	}
+	if readiness.Command != "" {
+		commands = append(commands, fmt.Sprintf("(%s)", readiness.Command))
+	}
+	return strings.Join(commands, " && ")
+}
+
+// commandExecutor executes the command inside the container, which is implemented by the DockerContainer.
+type commandExecutor interface {
+	Exec(ctx context.Context, cmd []string) (int, error)
+}
+
+// readinessTarget is the container checked by the readiness, which is implemented by the DockerContainer.
+type readinessTarget interface {
+	commandExecutor
+	Logs(ctx context.Context) (io.ReadCloser, error)
+}
+
+// waitContainerReady executes the readiness command inside the container until it exits with 0 and the matching log lines
+// reach the count, or timeout.
+func waitContainerReady(target readinessTarget, service string, readiness *config.ComposeReadiness, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(util.RunContext(), timeout)
+	defer cancel()
+
+	logger.Log.Infof("waiting for the readiness of service %s", service)
+	command := readinessCommand(readiness)
+	for {
+		var exitCode int
+		var err error
+		if command != "" {
+			exitCode, err = target.Exec(ctx, []string{"/bin/sh", "-c", command})
+		}
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("failed to check the readiness of service %s: %v", service, err)
+		}
+		if err == nil && exitCode == 126 {
+			return errors.New("/bin/sh command not executable")
+		}
+		if err == nil && exitCode == 0 {
+			ready, logErr := logReady(ctx, target, service, readiness.Log)
+			if logErr != nil && ctx.Err() == nil {
+				return fmt.Errorf("failed to check the readiness of service %s: %v", service, logErr)
+			}
+			if ready {
+				logger.Log.Infof("service %s is ready", service)
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := util.RunContext().Err(); err != nil {
+				return err
+			}
+			return &e2eerrors.WaitTimeoutError{Resource: fmt.Sprintf("service %s", service), Condition: "ready", Timeout: timeout}
+		case <-time.After(readinessInterval):
+		}
+	}
+}
+
+// logReady checks the log lines of the container matching the pattern reach the count, true if the log is not checked.
+func logReady(ctx context.Context, target readinessTarget, service string, log *config.ComposeReadinessLog) (bool, error) {
+	if log == nil {
+		return true, nil
+	}
+	logs, err := target.Logs(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer logs.Close()
+	data, err := io.ReadAll(logs)
+	if err != nil {
+		return false, err
+	}
+	// the logs of the containers without tty are multiplexed, the ones with tty are read as they are
+	var demuxed bytes.Buffer
+	if _, err := stdcopy.StdCopy(&demuxed, &demuxed, bytes.NewReader(data)); err == nil {
+		data = demuxed.Bytes()
+	}
+
+	want := log.Count
+	if want <= 0 {
+		want = 1
+	}
+	// the pattern is validated by validateReadiness
+	count, err := countLogLines(bytes.NewReader(data), regexp.MustCompile(log.Pattern))
+	if err != nil {
+		return false, err
+	}
+	if count < want {
+		logger.Log.Debugf("waiting for %d log lines matching %s of service %s, got %d", want, log.Pattern, service, count)
+		return false, nil
+	}
+	return true, nil
+}
+
+// composeServiceExecutor executes the commands of the exec steps inside the containers of the compose services.
+type composeServiceExecutor struct {
+	provider *DockerProvider
+	identity string
+}
+
+// execInService executes the command of the exec step inside the first container of the service.
+func (e *composeServiceExecutor) execInService(exec *config.ComposeExec, timeout time.Duration) error {
+	if exec.Service == "" || exec.Command == "" {
+		return fmt.Errorf("the service and the command of the exec step should be provided")
+	}
+	container, err := findContainer(e.provider.client, e.identity, exec.Service, 1, findContainerTimeout)
+	if err != nil {
+		return fmt.Errorf("could not find the container of service %s: %v", exec.Service, err)
+	}
+	return execInContainer(&DockerContainer{ID: container.ID, provider: e.provider}, exec.Service, exec.Command, timeout)
+}
+
+// execInContainer executes the command inside the container once, fails if it doesn't exit with 0 before timeout.
+func execInContainer(executor commandExecutor, service, command string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	logger.Log.Infof("executing command %q in service %s", command, service)
+	exitCode, err := executor.Exec(ctx, []string{"/bin/sh", "-c", command})
+	if ctx.Err() != nil {
+		return &e2eerrors.WaitTimeoutError{Resource: fmt.Sprintf("service %s", service), Condition: fmt.Sprintf("exec %q", command), Timeout: timeout}
+	} else if err != nil {
+		return fmt.Errorf("failed to execute command %q in service %s: %v", command, service, err)
+	} else if exitCode != 0 {
+		return fmt.Errorf("command %q in service %s exited with %d", command, service, exitCode)
+	}
+	logger.Log.Infof("executed command %q in service %s", command, service)
+	return nil
+}
+
+// getReplicas returns the number of the containers of the service, the scale in the e2e config takes
+// precedence over the `deploy.replicas` and `scale` in the compose file.
+func getReplicas(serviceConfig map[any]any, scale int) int {
+	if scale > 0 {
+		return scale
+	}
+	if deploy, ok := serviceConfig["deploy"].(map[any]any); ok {
+		if replicas, ok := deploy["replicas"].(int); ok && replicas > 0 {
+			return replicas
+		}
+	}
+	if replicas, ok := serviceConfig["scale"].(int); ok && replicas > 0 {
+		return replicas
+	}
+	return 1
+}
+
+// upFlags returns the extra flags of `compose up`, the default flags are used if the flags are not set,
+// an empty list disables them.
+func upFlags(flags []string) []string {
+	if flags == nil {
+		return defaultUpFlags
+	}
+	result := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		result = append(result, os.ExpandEnv(flag))
+	}
+	return result
+}
+
+// nativeWaitArgs returns the `--wait` flag of `compose up` if the native wait is enabled and the flag is not in the args yet,
+// such as set by the `compose.up-flags`.
+func nativeWaitArgs(nativeWait bool, args []string) []string {
+	if !nativeWait {
+		return nil
+	}
+	for _, arg := range args {
+		if arg == "--wait" {
+			return nil
+		}
+	}
+	return []string{"--wait"}
+}
+
+// scaleArgs builds the `--scale` arguments of `compose up` in the order of the service names.
+func scaleArgs(scale map[string]int) []string {
+	services := make([]string, 0, len(scale))
+	for service := range scale {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	args := make([]string, 0, len(scale)*2)
+	for _, service := range services {
+		args = append(args, "--scale", fmt.Sprintf("%s=%d", service, scale[service]))
+	}
+	return args
+}
+
+// getComposeImages finds all the images declared in the compose services.
+func getComposeImages(compose *testcontainers.LocalDockerCompose) []string {
+	images := make([]string, 0)
+	for _, content := range compose.Services {
+		serviceConfig := content.(map[any]any)
+		if image, ok := serviceConfig["image"].(string); ok && image != "" {
+			images = append(images, os.ExpandEnv(image))
+		}
+	}
+	return images
+}
+
 func getExpectPort(portConfig any) (int, error) {
 	switch conf := portConfig.(type) {
 	case int:
@@ -292,7 +822,115 @@ func getExpectPort(portConfig any) (int, error) {
 	return 0, fmt.Errorf("unknown port information: %v", portConfig)
 }
 
-func findContainer(c *client.Client, projectName, serviceName string, number int) (*types.Container, error) {
+// listComposeProject lists all the containers of the compose project, including the stopped ones.
+func listComposeProject(ctx context.Context, c containerLister, identity string) ([]types.Container, error) {
+	// the project name is normalized to lower case by compose
+	project := strings.ToLower(identity)
+	return c.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", composeProjectLabel, project))),
+	})
+}
+
+// imageLoader loads the images from the archives, which is implemented by the docker client.
+type imageLoader interface {
+	ImageLoad(ctx context.Context, input io.Reader, quiet bool) (types.ImageLoadResponse, error)
+}
+
+// loadImageArchives loads the images from the archives into the docker daemon like `docker load -i`,
+// so that the images not in any registry could be used without pulling.
+func loadImageArchives(ctx context.Context, loader imageLoader, archives []string) error {
+	files, err := imageArchiveFiles(archives)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		logger.Log.Infof("loading images from archive %s", file)
+		loaded, err := loadImageArchive(ctx, loader, file)
+		if err != nil {
+			return fmt.Errorf("failed to load images from archive %s: %v", file, err)
+		}
+		if loaded != "" {
+			logger.Log.Info(loaded)
+		}
+	}
+	return nil
+}
+
+// loadImageArchive loads the images from the archive, returns the output of loading such as `Loaded image: foo:1.0`.
+func loadImageArchive(ctx context.Context, loader imageLoader, file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	resp, err := loader.ImageLoad(ctx, f, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out bytes.Buffer
+	if !resp.JSON {
+		_, err = io.Copy(&out, resp.Body)
+	} else {
+		// the errors of loading, such as the invalid archive, are reported in the JSON messages
+		err = jsonmessage.DisplayJSONMessagesStream(resp.Body, &out, 0, false, nil)
+	}
+	return strings.TrimSpace(out.String()), err
+}
+
+// imageArchiveFiles resolves the image archives against the config file with the env vars expanded,
+// each of the glob patterns should match at least one file.
+func imageArchiveFiles(archives []string) ([]string, error) {
+	files := make([]string, 0, len(archives))
+	for _, archive := range archives {
+		matches, err := filepath.Glob(util.ResolveAbs(os.ExpandEnv(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid image archive pattern %s: %v", archive, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no image archive matches %s", archive)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// composeProjectRunning returns whether all the replicas of the services are running and healthy,
+// so that the project deployed by the previous run could be reused.
+func composeProjectRunning(c containerLister, identity string, services []*ComposeService) (bool, error) {
+	containers, err := listComposeProject(context.Background(), c, identity)
+	if err != nil {
+		return false, fmt.Errorf("failed to list the containers of compose project %s: %v", identity, err)
+	}
+	running := make(map[string]int)
+	for i := range containers {
+		container := &containers[i]
+		// the status of the container with the health check is like `Up 1 minute (healthy)`
+		if container.State != "running" || strings.Contains(container.Status, "(unhealthy)") ||
+			strings.Contains(container.Status, "(health: starting)") {
+			return false, nil
+		}
+		running[container.Labels[composeServiceLabel]]++
+	}
+	for _, service := range services {
+		name, _ := getInstanceName(service.Name)
+		if running[name] < service.Replicas {
+			return false, nil
+		}
+	}
+	return len(containers) > 0, nil
+}
+
+// containerLister lists the containers, which is implemented by the docker client.
+type containerLister interface {
+	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
+}
+
+// findContainer finds the container of the service, retries until the container is listed or timeout.
+func findContainer(c containerLister, projectName, serviceName string, number int, timeout time.Duration) (*types.Container, error) {
 	nameV1 := strings.Join([]string{projectName, serviceName, strconv.Itoa(number)}, SeparatorV1)
 	nameV2 := strings.Join([]string{projectName, serviceName, strconv.Itoa(number)}, SeparatorV2)
 	// filter either names
@@ -300,15 +938,21 @@ func findContainer(c *client.Client, projectName, serviceName string, number int
 	// 2) {project}-{service}-{number}
 	f := filters.NewArgs(filters.Arg("name", nameV1), filters.Arg("name", nameV2))
 	containerListOptions := types.ContainerListOptions{Filters: f}
-	containers, err := c.ContainerList(context.Background(), containerListOptions)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(containers) == 0 {
-		return nil, fmt.Errorf("could not found container: %s(docker-compose v1) or %s(docker-compose v2)", nameV1, nameV2)
+	deadline := time.Now().Add(timeout)
+	for {
+		containers, err := c.ContainerList(context.Background(), containerListOptions)
+		if err != nil {
+			return nil, err
+		}
+		if len(containers) > 0 {
+			return &containers[0], nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("could not found container: %s(docker-compose v1) or %s(docker-compose v2) after %v",
+				nameV1, nameV2, timeout)
+		}
+		time.Sleep(findContainerInterval)
 	}
-	return &containers[0], nil
 }
 
 func getInstanceName(serviceName string) (service string, number int) {
@@ -344,5 +988,5 @@ func waitPortUntilReady(e2eConfig *config.E2EConfig, container *types.Container,
 		ID:         container.ID,
 		WaitingFor: wait.NewHostPortStrategy(waitPort),
 		provider:   dockerProvider}
-	return WaitPort(context.Background(), target, waitPort, waitTimeout)
+	return WaitPort(util.RunContext(), target, waitPort, waitTimeout)
 }
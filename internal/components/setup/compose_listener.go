@@ -20,6 +20,7 @@ package setup
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/docker/docker/api/types/events"
 
@@ -40,6 +41,8 @@ type ComposeContainerListener struct {
 type ComposeContainer struct {
 	Service *ComposeService
 	ID      string
+	// Number is the replica number of the container in the service, starting from 1.
+	Number int
 }
 
 func NewComposeContainerListener(ctx context.Context, cli *client.Client, services []*ComposeService) *ComposeContainerListener {
@@ -91,11 +94,16 @@ func (c *ComposeContainerListener) Stop() {
 
 func (c *ComposeContainerListener) foundMessage(message *events.Message) *ComposeContainer {
 	serviceName := message.Actor.Attributes["com.docker.compose.service"]
+	number, err := strconv.Atoi(message.Actor.Attributes["com.docker.compose.container-number"])
+	if err != nil {
+		number = 1
+	}
 	for _, service := range c.services {
 		if service.Name == serviceName {
 			return &ComposeContainer{
 				Service: service,
 				ID:      message.ID,
+				Number:  number,
 			}
 		}
 	}
@@ -19,6 +19,7 @@
 package setup
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -27,6 +28,7 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -35,7 +37,10 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
+	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
 )
 
 const (
@@ -43,7 +48,14 @@ const (
 	ReaperDefault = "reaper_default" // Default network name when bridge is not available
 	localhost     = "localhost"
 
+	hostNetworkMode = "host"
+
 	TestcontainerLabel = "org.testcontainers.golang"
+
+	dockerHostEnv = "DOCKER_HOST"
+
+	composeProjectNameEnv = "COMPOSE_PROJECT_NAME"
+	composeFileEnv        = "COMPOSE_FILE"
 )
 
 // NetworkRequest represents the parameters used to get a network
@@ -143,7 +155,7 @@ func (c *DockerContainer) MappedPort(ctx context.Context, port nat.Port) (nat.Po
 	if err != nil {
 		return "", err
 	}
-	if inspect.HostConfig.NetworkMode == "host" {
+	if inspect.HostConfig.NetworkMode == hostNetworkMode {
 		return port, nil
 	}
 	ports, err := c.Ports(ctx)
@@ -291,7 +303,12 @@ func (c *DockerContainer) Exec(ctx context.Context, cmd []string) (int, error) {
 			break
 		}
 
-		time.Sleep(100 * time.Millisecond)
+		// abort the inspect loop when the caller's deadline is reached, otherwise a hung exec blocks forever
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("exec %v in container %s was interrupted before finishing: %w", cmd, c.ID, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
 	}
 
 	return exitCode, nil
@@ -307,18 +324,26 @@ type DockerNetwork struct {
 // DockerProvider implements the ContainerProvider interface
 type DockerProvider struct {
 	client         *client.Client
+	host           string // the configured host of the published ports, which overrides the detected one
 	hostCache      string
 	defaultNetwork string // default container network
+	network        string // the network to resolve the gateway against, the default network is detected if empty
+	ipFamily       string // the preferred address family, ipv4 or ipv6, ipv4 is preferred if empty
 }
 
 // daemonHost gets the host or ip of the Docker daemon where ports are exposed on
 // Warning: this is based on your Docker host setting. Will fail if using an SSH tunnel
-// You can use the "TC_HOST" env variable to set this yourself
+// You can use the "TC_HOST" env variable or the `compose.host` to set this yourself
 func (p *DockerProvider) daemonHost(ctx context.Context) (string, error) {
 	if p.hostCache != "" {
 		return p.hostCache, nil
 	}
 
+	if p.host != "" {
+		p.hostCache = p.host
+		return p.hostCache, nil
+	}
+
 	host, exists := os.LookupEnv("TC_HOST")
 	if exists {
 		p.hostCache = host
@@ -335,8 +360,15 @@ func (p *DockerProvider) daemonHost(ctx context.Context) (string, error) {
 	case "http", "https", "tcp":
 		p.hostCache = parsedURL.Hostname()
 	case "unix", "npipe":
-		if inAContainer() {
+		if inAContainer() && p.network == "" && p.isDockerDesktop(ctx) {
+			// the gateways of Docker Desktop are inside its VM, which are not reachable from the containers
+			p.hostCache = constant.DockerDesktopHost
+		} else if inAContainer() {
 			ip, err := p.GetGatewayIP(ctx)
+			// the explicit network is expected to be resolved, rather than falling back to the other hosts silently
+			if err != nil && p.network != "" {
+				return "", fmt.Errorf("failed to get the gateway IP of network %s: %v", p.network, err)
+			}
 			if err != nil {
 				// fallback to getDefaultGatewayIP
 				ip, err = getDefaultGatewayIP()
@@ -355,6 +387,19 @@ func (p *DockerProvider) daemonHost(ctx context.Context) (string, error) {
 	return p.hostCache, nil
 }
 
+// isDockerDesktop checks whether the daemon is Docker Desktop for Mac and Windows, false if the daemon info is unavailable.
+func (p *DockerProvider) isDockerDesktop(ctx context.Context) bool {
+	info, err := p.client.Info(ctx)
+	if err != nil {
+		return false
+	}
+	return isDockerDesktop(info.OperatingSystem)
+}
+
+func isDockerDesktop(operatingSystem string) bool {
+	return strings.Contains(operatingSystem, constant.DockerDesktop)
+}
+
 // GetNetwork returns the object representing the network identified by its name
 func (p *DockerProvider) GetNetwork(ctx context.Context, req NetworkRequest) (types.NetworkResource, error) {
 	networkResource, err := p.client.NetworkInspect(ctx, req.Name, types.NetworkInspectOptions{
@@ -368,26 +413,28 @@ func (p *DockerProvider) GetNetwork(ctx context.Context, req NetworkRequest) (ty
 }
 
 func (p *DockerProvider) GetGatewayIP(ctx context.Context) (string, error) {
-	// Use a default network as defined in the DockerProvider
+	// Use the configured network, or a default network as defined in the DockerProvider
 	var err error
-	if p.defaultNetwork == "" {
-		p.defaultNetwork, err = getDefaultNetwork(ctx, p.client)
-		if err != nil {
-			return "", err
+	network := p.network
+	if network == "" {
+		if p.defaultNetwork == "" {
+			p.defaultNetwork, err = getDefaultNetwork(ctx, p.client)
+			if err != nil {
+				return "", err
+			}
 		}
+		network = p.defaultNetwork
 	}
-	nw, err := p.GetNetwork(ctx, NetworkRequest{Name: p.defaultNetwork})
+	nw, err := p.GetNetwork(ctx, NetworkRequest{Name: network})
 	if err != nil {
 		return "", err
 	}
 
-	var ip string
+	gateways := make([]string, 0, len(nw.IPAM.Config))
 	for _, config := range nw.IPAM.Config {
-		if config.Gateway != "" {
-			ip = config.Gateway
-			break
-		}
+		gateways = append(gateways, config.Gateway)
 	}
+	ip := selectAddress(gateways, p.ipFamily)
 	if ip == "" {
 		return "", errors.New("failed to get gateway IP from network settings")
 	}
@@ -395,6 +442,38 @@ func (p *DockerProvider) GetGatewayIP(ctx context.Context) (string, error) {
 	return ip, nil
 }
 
+// selectAddress selects the first address of the preferred family, falls back to the first address of the other family,
+// the IPv4 address is preferred if the family is empty. The unspecified addresses `0.0.0.0` and `::` are selected too.
+func selectAddress(addresses []string, family string) string {
+	var fallback string
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			continue
+		}
+		if isIPv6(ip) == (family == constant.IPv6) {
+			return address
+		}
+		if fallback == "" {
+			fallback = address
+		}
+	}
+	return fallback
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip.To4() == nil
+}
+
+// hostForURL brackets the IPv6 address, so that the exported host could be used in the URLs directly,
+// such as `http://${service_host}:${service_8080}`.
+func hostForURL(host string) string {
+	if ip := net.ParseIP(host); ip != nil && isIPv6(ip) {
+		return "[" + host + "]"
+	}
+	return host
+}
+
 func inAContainer() bool {
 	if _, err := os.Stat("/.dockerenv"); err == nil {
 		return true
@@ -416,6 +495,69 @@ func getDefaultGatewayIP() (string, error) {
 	return ip, nil
 }
 
+// newDockerClient creates the docker client from the env vars, the host overrides the `DOCKER_HOST` if it's set.
+func newDockerClient(host string) (*client.Client, error) {
+	opts := []client.Opt{client.FromEnv}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	return client.NewClientWithOpts(opts...)
+}
+
+// invokeCompose runs the compose command of the project like LocalDockerCompose.Invoke, the output is still printed,
+// and the stderr is also returned in the error, so that the cause of the failure, such as a pull rate limit, is kept.
+func invokeCompose(compose *testcontainers.LocalDockerCompose, args []string) error {
+	files := make([]string, 0, len(compose.ComposeFilePaths))
+	cmdArgs := make([]string, 0, 2*len(compose.ComposeFilePaths)+len(args))
+	for _, path := range compose.ComposeFilePaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, abs)
+		cmdArgs = append(cmdArgs, "-f", abs)
+	}
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd := exec.Command(compose.Executable, cmdArgs...)
+	if len(files) > 0 {
+		cmd.Dir = filepath.Dir(files[0])
+	}
+	cmd.Env = append(os.Environ(), composeProjectNameEnv+"="+compose.Identifier,
+		composeFileEnv+"="+strings.Join(files, string(os.PathListSeparator)))
+	for key, value := range compose.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	if err := cmd.Run(); err != nil {
+		if output := strings.TrimSpace(stderr.String()); output != "" {
+			return fmt.Errorf("%s %s: %v, stderr:\n%s", compose.Executable, strings.Join(args, " "), err, output)
+		}
+		return fmt.Errorf("%s %s: %v", compose.Executable, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// ComposeEnv returns the env vars of the compose command, which targets the docker host if it's set.
+func ComposeEnv(host string) map[string]string {
+	if host == "" {
+		return nil
+	}
+	return map[string]string{dockerHostEnv: host}
+}
+
+// composeNetwork resolves the configured network to the name of the docker network, `project` is the default network
+// created by compose, which is named `<project>_default` with the project name normalized to lower case.
+func composeNetwork(network, identity string) string {
+	if network == constant.ComposeNetworkProject {
+		return strings.ToLower(identity) + "_default"
+	}
+	return network
+}
+
 func getDefaultNetwork(ctx context.Context, cli *client.Client) (string, error) {
 	// Get list of available networks
 	networkResources, err := cli.NetworkList(ctx, types.NetworkListOptions{})
@@ -496,6 +638,14 @@ func WaitPort(ctx context.Context, target wait.StrategyTarget, waitPort nat.Port
 		break
 	}
 
+	// the internal check is skipped for the host network container, the port is listened on the host directly,
+	// which has been checked by the external dial above
+	if hostNetwork, err := isHostNetwork(ctx, target); err != nil {
+		return err
+	} else if hostNetwork {
+		return nil
+	}
+
 	// internal check
 	command := buildInternalCheckCommand(waitPort.Int())
 	for {
@@ -517,6 +667,18 @@ func WaitPort(ctx context.Context, target wait.StrategyTarget, waitPort nat.Port
 	return nil
 }
 
+func isHostNetwork(ctx context.Context, target wait.StrategyTarget) (bool, error) {
+	container, ok := target.(*DockerContainer)
+	if !ok {
+		return false, nil
+	}
+	inspect, err := container.inspectContainer(ctx)
+	if err != nil {
+		return false, err
+	}
+	return inspect.HostConfig.NetworkMode == hostNetworkMode, nil
+}
+
 func findMappedPort(ctx context.Context, target wait.StrategyTarget, waitPort nat.Port) (nat.Port, error) {
 	var waitInterval = 100 * time.Millisecond
 
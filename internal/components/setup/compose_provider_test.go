@@ -0,0 +1,157 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestSelectAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses []string
+		family    string
+		want      string
+	}{
+		{name: "should prefer ipv4 by default", addresses: []string{"fd00::1", "172.17.0.1"}, want: "172.17.0.1"},
+		{name: "should prefer the ipv6 family", addresses: []string{"172.17.0.1", "fd00::1"}, family: "ipv6", want: "fd00::1"},
+		{name: "should fall back to the other family", addresses: []string{"", "fd00::1"}, family: "ipv4", want: "fd00::1"},
+		{name: "should return empty without valid address", addresses: []string{""}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectAddress(tt.addresses, tt.family); got != tt.want {
+				t.Errorf("selectAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDockerDesktop(t *testing.T) {
+	tests := []struct {
+		operatingSystem string
+		want            bool
+	}{
+		{operatingSystem: "Docker Desktop", want: true},
+		{operatingSystem: "Ubuntu 22.04.3 LTS", want: false},
+		{operatingSystem: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operatingSystem, func(t *testing.T) {
+			if got := isDockerDesktop(tt.operatingSystem); got != tt.want {
+				t.Errorf("isDockerDesktop() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaemonHostConfigured(t *testing.T) {
+	// the configured host is used without inspecting the docker host
+	provider := &DockerProvider{host: "localhost"}
+	if got, err := provider.daemonHost(context.Background()); err != nil || got != "localhost" {
+		t.Errorf("daemonHost() = %v, %v, want localhost", got, err)
+	}
+}
+
+func TestHostForURL(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "localhost", want: "localhost"},
+		{host: "172.17.0.1", want: "172.17.0.1"},
+		{host: "fd00::1", want: "[fd00::1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := hostForURL(tt.host); got != tt.want {
+				t.Errorf("hostForURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeNetwork(t *testing.T) {
+	tests := []struct {
+		network string
+		want    string
+	}{
+		{network: "", want: ""},
+		{network: "corp-net", want: "corp-net"},
+		{network: "project", want: "skywalking_e2e_mysql_default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			if got := composeNetwork(tt.network, "Skywalking_E2E_mysql"); got != tt.want {
+				t.Errorf("composeNetwork() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDockerClient(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///var/run/local.sock")
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "", want: "unix:///var/run/local.sock"},
+		{host: "tcp://builder:2375", want: "tcp://builder:2375"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			cli, err := newDockerClient(tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cli.DaemonHost(); got != tt.want {
+				t.Errorf("newDockerClient().DaemonHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectPublishedPort(t *testing.T) {
+	ports := []types.Port{
+		{IP: "::", PrivatePort: 8080, PublicPort: 49154},
+		{IP: "0.0.0.0", PrivatePort: 8080, PublicPort: 49153},
+		{IP: "0.0.0.0", PrivatePort: 9090, PublicPort: 49155},
+	}
+	tests := []struct {
+		name        string
+		privatePort int
+		family      string
+		want        uint16
+	}{
+		{name: "should select the ipv4 port by default", privatePort: 8080, want: 49153},
+		{name: "should select the ipv6 port", privatePort: 8080, family: "ipv6", want: 49154},
+		{name: "should fall back to the ipv4 port", privatePort: 9090, family: "ipv6", want: 49155},
+		{name: "should return nil when the port is not published", privatePort: 7070},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectPublishedPort(ports, tt.privatePort, tt.family)
+			if (got == nil) != (tt.want == 0) || (got != nil && got.PublicPort != tt.want) {
+				t.Errorf("selectPublishedPort() = %v, want public port %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,575 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/testcontainers/testcontainers-go"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+// fakeContainerLister lists no container until the given times of calls.
+type fakeContainerLister struct {
+	emptyTimes int
+	calls      int
+}
+
+func (f *fakeContainerLister) ContainerList(_ context.Context, _ types.ContainerListOptions) ([]types.Container, error) {
+	f.calls++
+	if f.calls <= f.emptyTimes {
+		return nil, nil
+	}
+	return []types.Container{{ID: "foo"}}, nil
+}
+
+func TestFindContainer(t *testing.T) {
+	tests := []struct {
+		name       string
+		emptyTimes int
+		timeout    time.Duration
+		wantErr    bool
+	}{
+		{name: "should find the listed container", timeout: time.Second},
+		{name: "should retry until the container is listed", emptyTimes: 2, timeout: 5 * time.Second},
+		{name: "should fail when the container is not listed before timeout", emptyTimes: 100, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container, err := findContainer(&fakeContainerLister{emptyTimes: tt.emptyTimes}, "e2e", "oap", 1, tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findContainer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && container.ID != "foo" {
+				t.Errorf("findContainer() = %v, want container foo", container.ID)
+			}
+		})
+	}
+}
+
+func TestGetReplicas(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceConfig map[any]any
+		scale         int
+		want          int
+	}{
+		{name: "should be 1 by default", serviceConfig: map[any]any{"image": "oap"}, want: 1},
+		{name: "should use the deploy replicas", serviceConfig: map[any]any{"deploy": map[any]any{"replicas": 3}}, want: 3},
+		{name: "should use the scale", serviceConfig: map[any]any{"scale": 2}, want: 2},
+		{name: "should prefer the scale of the e2e config", serviceConfig: map[any]any{"deploy": map[any]any{"replicas": 3}}, scale: 4, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getReplicas(tt.serviceConfig, tt.scale); got != tt.want {
+				t.Errorf("getReplicas() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeServiceEnvNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas int
+		number   int
+		want     []string
+	}{
+		{name: "should keep the service name of the single container", replicas: 1, number: 1, want: []string{"oap"}},
+		{name: "should export the first replica in both names", replicas: 3, number: 1, want: []string{"oap", "oap_1"}},
+		{name: "should export the other replicas with number", replicas: 3, number: 2, want: []string{"oap_2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &ComposeService{Name: "oap", Replicas: tt.replicas}
+			if got := service.envNames(tt.number); !cmp.Equal(got, tt.want) {
+				t.Errorf("envNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportComposeInstanceEnv(t *testing.T) {
+	defer func() {
+		_ = SetExportEnv(config.ExportEnv{})
+		SetEnvironment("")
+	}()
+	if err := SetExportEnv(config.ExportEnv{Prefix: "E2E_", Format: "{{ upper .Environment }}_{{ upper .Key }}"}); err != nil {
+		t.Fatal(err)
+	}
+	SetEnvironment("primary")
+
+	service := &ComposeService{Name: "instance_env", Replicas: 2}
+	for number := 1; number <= service.Replicas; number++ {
+		if err := exportComposeInstanceEnv(service, number, "host", fmt.Sprintf("10.0.0.%d", number)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exportComposeInstanceEnv(&ComposeService{Name: "single", Replicas: 1}, 1, "host", "10.0.0.9"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]string{
+		{"E2E_PRIMARY_INSTANCE_ENV_HOST": "10.0.0.1"},
+		{"E2E_PRIMARY_INSTANCE_ENV_HOST": "10.0.0.2"},
+	}
+	if diff := cmp.Diff(want, InstanceEnv("primary_instance_env")); diff != "" {
+		t.Errorf("InstanceEnv() mismatch (-want +got):\n%s", diff)
+	}
+	if got := InstanceEnv("primary_single"); got != nil {
+		t.Errorf("InstanceEnv() = %v of the service not scaled, want nil", got)
+	}
+}
+
+func TestScaleArgs(t *testing.T) {
+	got := scaleArgs(map[string]int{"oap": 2, "banyandb": 3})
+	want := []string{"--scale", "banyandb=3", "--scale", "oap=2"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("scaleArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestUpFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		want  []string
+	}{
+		{name: "should remove the orphans by default", want: []string{"--remove-orphans"}},
+		{name: "should disable the default flags by the empty list", flags: []string{}, want: []string{}},
+		{name: "should use the configured flags", flags: []string{"--force-recreate", "--remove-orphans"}, want: []string{"--force-recreate", "--remove-orphans"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upFlags(tt.flags); !cmp.Equal(got, tt.want) {
+				t.Errorf("upFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeWaitArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		nativeWait bool
+		args       []string
+		want       []string
+	}{
+		{name: "should not wait natively by default", args: []string{"up", "-d"}},
+		{name: "should add the wait flag", nativeWait: true, args: []string{"up", "-d"}, want: []string{"--wait"}},
+		{name: "should not duplicate the wait flag of the up flags", nativeWait: true, args: []string{"up", "-d", "--wait"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nativeWaitArgs(tt.nativeWait, tt.args); !cmp.Equal(got, tt.want) {
+				t.Errorf("nativeWaitArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasHealthcheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceConfig map[any]any
+		want          bool
+	}{
+		{name: "should not have healthcheck if not declared", serviceConfig: map[any]any{"image": "oap"}},
+		{name: "should have the healthcheck of the list test",
+			serviceConfig: map[any]any{"healthcheck": map[any]any{"test": []any{"CMD", "curl", "-f", "localhost"}}}, want: true},
+		{name: "should have the healthcheck of the string test",
+			serviceConfig: map[any]any{"healthcheck": map[any]any{"test": "curl -f localhost"}}, want: true},
+		{name: "should not have the disabled healthcheck",
+			serviceConfig: map[any]any{"healthcheck": map[any]any{"test": "curl -f localhost", "disable": true}}},
+		{name: "should not have the healthcheck of the none test",
+			serviceConfig: map[any]any{"healthcheck": map[any]any{"test": []any{"NONE"}}}},
+		{name: "should not have the healthcheck without test",
+			serviceConfig: map[any]any{"healthcheck": map[any]any{"interval": "5s"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasHealthcheck(tt.serviceConfig); got != tt.want {
+				t.Errorf("hasHealthcheck() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeDownArgs(t *testing.T) {
+	tests := []struct {
+		stopTimeout string
+		want        []string
+		wantErr     bool
+	}{
+		{stopTimeout: "", want: []string{"down", "--remove-orphans"}},
+		{stopTimeout: "0s", want: []string{"down", "--remove-orphans", "--timeout", "0"}},
+		{stopTimeout: "500ms", want: []string{"down", "--remove-orphans", "--timeout", "1"}},
+		{stopTimeout: "1m", want: []string{"down", "--remove-orphans", "--timeout", "60"}},
+		{stopTimeout: "-1s", wantErr: true},
+		{stopTimeout: "10", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stopTimeout, func(t *testing.T) {
+			got, err := ComposeDownArgs(tt.stopTimeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ComposeDownArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("ComposeDownArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReadiness(t *testing.T) {
+	services := map[string]any{"oap": nil}
+	tests := []struct {
+		name      string
+		readiness map[string]config.ComposeReadiness
+		wantErr   bool
+	}{
+		{name: "should pass with the port", readiness: map[string]config.ComposeReadiness{"oap": {Port: 11800}}},
+		{name: "should pass with the command", readiness: map[string]config.ComposeReadiness{"oap": {Command: "curl -f localhost:12800"}}},
+		{name: "should fail with the unknown service", readiness: map[string]config.ComposeReadiness{"ui": {Port: 8080}}, wantErr: true},
+		{name: "should pass with the log", readiness: map[string]config.ComposeReadiness{"oap": {Log: &config.ComposeReadinessLog{Pattern: "processed"}}}},
+		{name: "should fail without the port or the command", readiness: map[string]config.ComposeReadiness{"oap": {}}, wantErr: true},
+		{
+			name:      "should fail with the invalid log pattern",
+			readiness: map[string]config.ComposeReadiness{"oap": {Log: &config.ComposeReadinessLog{Pattern: "processed("}}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateReadiness(tt.readiness, services); (err != nil) != tt.wantErr {
+				t.Errorf("validateReadiness() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeCommandExecutor fails the command until the given times of calls, and returns the logs.
+type fakeCommandExecutor struct {
+	failedTimes int
+	calls       int
+	commands    []string
+	logs        string
+}
+
+func (f *fakeCommandExecutor) Logs(_ context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(f.logs)), nil
+}
+
+func (f *fakeCommandExecutor) Exec(_ context.Context, cmd []string) (int, error) {
+	f.calls++
+	f.commands = cmd
+	if f.calls <= f.failedTimes {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func TestWaitContainerReady(t *testing.T) {
+	tests := []struct {
+		name        string
+		failedTimes int
+		timeout     time.Duration
+		wantErr     bool
+	}{
+		{name: "should pass when the command succeeds", timeout: time.Second},
+		{name: "should retry until the command succeeds", failedTimes: 2, timeout: 5 * time.Second},
+		{name: "should fail when the command doesn't succeed before timeout", failedTimes: 100, timeout: 1500 * time.Millisecond, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &fakeCommandExecutor{failedTimes: tt.failedTimes}
+			err := waitContainerReady(executor, "oap", &config.ComposeReadiness{Command: "test -f /ready"}, tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("waitContainerReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := []string{"/bin/sh", "-c", "(test -f /ready)"}; !cmp.Equal(executor.commands, want) {
+				t.Errorf("waitContainerReady() executed %v, want %v", executor.commands, want)
+			}
+		})
+	}
+}
+
+func TestWaitContainerReadyWithLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		logs    string
+		log     config.ComposeReadinessLog
+		wantErr bool
+	}{
+		{name: "should pass when the matching lines reach the count", logs: "processed 1\nprocessed 2\nidle\n",
+			log: config.ComposeReadinessLog{Pattern: "^processed", Count: 2}},
+		{name: "should pass with the default count", logs: "processed 1\n", log: config.ComposeReadinessLog{Pattern: "processed"}},
+		{name: "should fail when the matching lines don't reach the count", logs: "processed 1\nidle\n",
+			log: config.ComposeReadinessLog{Pattern: "^processed", Count: 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &fakeCommandExecutor{logs: tt.logs}
+			err := waitContainerReady(executor, "pipeline", &config.ComposeReadiness{Log: &tt.log}, 1500*time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("waitContainerReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if executor.calls != 0 {
+				t.Errorf("waitContainerReady() executed %d commands, want 0", executor.calls)
+			}
+		})
+	}
+}
+
+func TestExecInContainer(t *testing.T) {
+	tests := []struct {
+		name        string
+		failedTimes int
+		wantErr     bool
+	}{
+		{name: "should pass when the command exits with 0"},
+		{name: "should fail when the command exits with non-zero", failedTimes: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &fakeCommandExecutor{failedTimes: tt.failedTimes}
+			if err := execInContainer(executor, "mysql", "mysql < /seed.sql", time.Second); (err != nil) != tt.wantErr {
+				t.Fatalf("execInContainer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if executor.calls != 1 {
+				t.Errorf("execInContainer() executed %d times, want 1", executor.calls)
+			}
+			if want := []string{"/bin/sh", "-c", "mysql < /seed.sql"}; !cmp.Equal(executor.commands, want) {
+				t.Errorf("execInContainer() executed %v, want %v", executor.commands, want)
+			}
+		})
+	}
+}
+
+// staticContainerLister lists the given containers.
+type staticContainerLister []types.Container
+
+func (s staticContainerLister) ContainerList(_ context.Context, _ types.ContainerListOptions) ([]types.Container, error) {
+	return s, nil
+}
+
+func TestComposeProjectRunning(t *testing.T) {
+	container := func(service, state, status string) types.Container {
+		return types.Container{State: state, Status: status, Labels: map[string]string{composeServiceLabel: service}}
+	}
+	services := []*ComposeService{{Name: "oap", Replicas: 2}, {Name: "mysql", Replicas: 1}}
+	tests := []struct {
+		name       string
+		containers []types.Container
+		want       bool
+	}{
+		{name: "not deployed", containers: nil, want: false},
+		{name: "running", containers: []types.Container{
+			container("oap", "running", "Up 1 minute"),
+			container("oap", "running", "Up 1 minute"),
+			container("mysql", "running", "Up 1 minute (healthy)"),
+		}, want: true},
+		{name: "missing replica", containers: []types.Container{
+			container("oap", "running", "Up 1 minute"),
+			container("mysql", "running", "Up 1 minute"),
+		}, want: false},
+		{name: "exited", containers: []types.Container{
+			container("oap", "running", "Up 1 minute"),
+			container("oap", "exited", "Exited (1) 10 seconds ago"),
+			container("mysql", "running", "Up 1 minute"),
+		}, want: false},
+		{name: "unhealthy", containers: []types.Container{
+			container("oap", "running", "Up 1 minute"),
+			container("oap", "running", "Up 1 minute"),
+			container("mysql", "running", "Up 1 minute (unhealthy)"),
+		}, want: false},
+		{name: "health starting", containers: []types.Container{
+			container("oap", "running", "Up 1 minute"),
+			container("oap", "running", "Up 1 minute"),
+			container("mysql", "running", "Up 5 seconds (health: starting)"),
+		}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := composeProjectRunning(staticContainerLister(tt.containers), "e2e", services)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("composeProjectRunning() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeUpWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		retry     config.ComposeUpRetry
+		failTimes int
+		wantUps   int
+		wantDowns int
+		wantErr   string
+	}{
+		{name: "should not retry by default", failTimes: 1, wantUps: 1,
+			wantErr: "failed to start docker compose project: attempt 1/1: pull rate limit"},
+		{name: "should succeed at the first attempt", retry: config.ComposeUpRetry{Count: 2}, wantUps: 1},
+		{name: "should tear down and retry on failure", retry: config.ComposeUpRetry{Count: 2, Interval: "1ms"},
+			failTimes: 2, wantUps: 3, wantDowns: 2},
+		{name: "should return the errors of all the attempts", retry: config.ComposeUpRetry{Count: 1}, failTimes: 3,
+			wantUps: 2, wantDowns: 1,
+			wantErr: "failed to start docker compose project: attempt 1/2: pull rate limit\nattempt 2/2: pull rate limit"},
+		{name: "should fail with invalid interval", retry: config.ComposeUpRetry{Count: 1, Interval: "1"},
+			wantErr: "failed to parse compose.up-retry.interval 1: time: missing unit in duration \"1\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ups, downs := 0, 0
+			err := composeUpWithRetry(tt.retry, func() error {
+				ups++
+				if ups <= tt.failTimes {
+					return errors.New("pull rate limit")
+				}
+				return nil
+			}, func() error {
+				downs++
+				return nil
+			})
+			gotErr := ""
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("composeUpWithRetry() error = %q, want %q", gotErr, tt.wantErr)
+			}
+			if ups != tt.wantUps || downs != tt.wantDowns {
+				t.Errorf("composeUpWithRetry() ups = %d, downs = %d, want %d, %d", ups, downs, tt.wantUps, tt.wantDowns)
+			}
+		})
+	}
+}
+
+func TestInvokeCompose(t *testing.T) {
+	dir := t.TempDir()
+	composeFile := filepath.Join(dir, "docker-compose.yml")
+	if err := os.WriteFile(composeFile, []byte("services: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// the fake compose prints the project name and fails on `up`
+	executable := filepath.Join(dir, "compose")
+	script := "#!/bin/sh\nif [ \"$3\" = up ]; then echo \"$COMPOSE_PROJECT_NAME: toomanyrequests\" >&2; exit 1; fi\n"
+	if err := os.WriteFile(executable, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	compose := testcontainers.NewLocalDockerCompose([]string{composeFile}, "E2E_Project")
+	compose.Executable = executable
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "should succeed", args: []string{"down"}},
+		{name: "should return the stderr on failure", args: []string{"up", "-d"},
+			wantErr: executable + " up -d: exit status 1, stderr:\ne2e_project: toomanyrequests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr := ""
+			if err := invokeCompose(compose, tt.args); err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("invokeCompose() error = %q, want %q", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeImageLoader responds the loading of the archives by the JSON messages.
+type fakeImageLoader struct {
+	messages string
+	loaded   []string
+}
+
+func (f *fakeImageLoader) ImageLoad(_ context.Context, input io.Reader, _ bool) (types.ImageLoadResponse, error) {
+	content, err := io.ReadAll(input)
+	if err != nil {
+		return types.ImageLoadResponse{}, err
+	}
+	f.loaded = append(f.loaded, string(content))
+	return types.ImageLoadResponse{Body: io.NopCloser(strings.NewReader(f.messages)), JSON: true}, nil
+}
+
+func TestLoadImageArchives(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"oap.tar", "ui.tar"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name       string
+		archives   []string
+		messages   string
+		wantLoaded []string
+		wantErr    bool
+	}{
+		{
+			name:       "glob pattern",
+			archives:   []string{filepath.Join(dir, "*.tar")},
+			messages:   `{"stream":"Loaded image: apache/skywalking-oap-server:latest\n"}`,
+			wantLoaded: []string{"oap.tar", "ui.tar"},
+		},
+		{
+			name:     "no archive matches",
+			archives: []string{filepath.Join(dir, "*.tgz")},
+			wantErr:  true,
+		},
+		{
+			name:       "invalid archive",
+			archives:   []string{filepath.Join(dir, "oap.tar")},
+			messages:   `{"errorDetail":{"message":"unexpected EOF"},"error":"unexpected EOF"}`,
+			wantLoaded: []string{"oap.tar"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &fakeImageLoader{messages: tt.messages}
+			err := loadImageArchives(context.Background(), loader, tt.archives)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadImageArchives() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantLoaded, loader.loaded); diff != "" {
+				t.Errorf("loadImageArchives() loaded mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+const exportLogsTimeout = 2 * time.Minute
+
+// logsSince resolves the start time of the exported logs by `--since`, the zero time means the whole logs.
+func logsSince() (time.Time, error) {
+	return util.LogsSinceTime(util.LogsSince, filepath.Join(util.WorkDir, constant.TriggerStartFile), time.Now())
+}
+
+// exportPodLogs writes the logs of all the containers since the time into `<namespace>/<pod>/<container>.log` under the dir,
+// the failure of a single container is only logged so that the others are still exported.
+func exportPodLogs(client kubernetes.Interface, dir string, since time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), exportLogsTimeout)
+	defer cancel()
+
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list the pods: %v", err)
+	}
+	sinceTime := metav1.NewTime(since)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for j := range containers {
+			container := containers[j].Name
+			file := filepath.Join(dir, pod.Namespace, pod.Name, container+".log")
+			logs, err := client.CoreV1().Pods(pod.Namespace).
+				GetLogs(pod.Name, &corev1.PodLogOptions{Container: container, SinceTime: &sinceTime}).Stream(ctx)
+			if err != nil {
+				logger.Log.Warnf("failed to export the logs of %s/%s/%s: %v", pod.Namespace, pod.Name, container, err)
+				continue
+			}
+			err = writeLogFile(file, func(w io.Writer) error {
+				_, err := io.Copy(w, logs)
+				return err
+			})
+			logs.Close()
+			if err != nil {
+				logger.Log.Warnf("failed to export the logs of %s/%s/%s: %v", pod.Namespace, pod.Name, container, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ExportComposeLogs archives the logs of the containers of the compose project since the time of `--since`,
+// the logs are exported into the `compose` directory under the log directory.
+func ExportComposeLogs(e2eConfig *config.E2EConfig) error {
+	since, err := logsSince()
+	if err != nil {
+		return err
+	}
+	cli, err := newDockerClient(e2eConfig.Setup.Compose.GetDockerHost())
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportLogsTimeout)
+	defer cancel()
+
+	project := GetIdentity()
+	containers, err := listComposeProject(ctx, cli, project)
+	if err != nil {
+		return fmt.Errorf("failed to list the containers of compose project %s: %v", project, err)
+	}
+
+	dir := filepath.Join(util.LogDir, currentEnvironment, "compose")
+	options := types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true}
+	if !since.IsZero() {
+		options.Since = since.Format(time.RFC3339Nano)
+	}
+	logger.Log.Infof("exporting logs of compose project %s into %s", project, dir)
+	for i := range containers {
+		name := containers[i].ID
+		if len(containers[i].Names) > 0 {
+			name = strings.TrimPrefix(containers[i].Names[0], "/")
+		}
+		logs, err := cli.ContainerLogs(ctx, containers[i].ID, options)
+		if err != nil {
+			logger.Log.Warnf("failed to export the logs of container %s: %v", name, err)
+			continue
+		}
+		err = writeLogFile(filepath.Join(dir, name+".log"), func(w io.Writer) error {
+			_, err := stdcopy.StdCopy(w, w, logs)
+			return err
+		})
+		logs.Close()
+		if err != nil {
+			logger.Log.Warnf("failed to export the logs of container %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// writeLogFile creates the log file and its parent directories, and writes the logs into it.
+func writeLogFile(file string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f)
+}
@@ -0,0 +1,51 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestExportPodLogs(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "oap", Namespace: metav1.NamespaceDefault},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "oap"}},
+		},
+	})
+	dir := t.TempDir()
+	if err := exportPodLogs(client, dir, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, container := range []string{"init", "oap"} {
+		file := filepath.Join(dir, metav1.NamespaceDefault, "oap", container+".log")
+		// the logs of the fake client are always `fake logs`
+		if data, err := os.ReadFile(file); err != nil || string(data) != "fake logs" {
+			t.Errorf("logs of container %s = %q, %v, want %q", container, data, err, "fake logs")
+		}
+	}
+}
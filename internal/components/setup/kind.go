@@ -19,8 +19,6 @@
 package setup
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -50,20 +48,23 @@ import (
 
 	"github.com/docker/docker/api/types"
 	docker "github.com/docker/docker/client"
-	kind "sigs.k8s.io/kind/cmd/kind/app"
-	kindcmd "sigs.k8s.io/kind/pkg/cmd"
 
 	"github.com/apache/skywalking-infra-e2e/internal/config"
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
 )
 
+// defaultForwardAddress is the default local address the forwarded ports bind to.
+const defaultForwardAddress = "localhost"
+
 var (
 	kindConfigPath string
 	kubeConfigPath string
+	kubeContext    string
 
-	portForwardContext *kindPortForwardContext
+	portForwardContexts []*kindPortForwardContext
 )
 
 type kindPortForwardContext struct {
@@ -94,8 +95,9 @@ func listLocalImages(ctx context.Context, cli *docker.Client) (map[string]struct
 }
 
 // pullImages pulls docker image from a docker repository
-func pullImages(ctx context.Context, images []string) error {
-	cli, err := docker.NewClientWithOpts(docker.FromEnv)
+// pullImages pulls the images which don't exist locally, or all the images if always is true.
+func pullImages(ctx context.Context, images []string, auths registryAuths, dockerHost string, always bool) error {
+	cli, err := newDockerClient(dockerHost)
 	if err != nil {
 		return err
 	}
@@ -121,7 +123,10 @@ func pullImages(ctx context.Context, images []string) error {
 		return res
 	}
 
-	filterResult := filter(images)
+	filterResult := images
+	if !always {
+		filterResult = filter(images)
+	}
 	if len(filterResult) == 0 {
 		return nil
 	}
@@ -132,8 +137,17 @@ func pullImages(ctx context.Context, images []string) error {
 		wg.Add(1)
 		go func(image string) {
 			defer wg.Done()
-			logger.Log.Infof("image %s does not exist, will pull from remote", image)
-			out, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+			if always {
+				logger.Log.Infof("pulling image %s from remote by the image pull policy %s", image, constant.ImagePullAlways)
+			} else {
+				logger.Log.Infof("image %s does not exist, will pull from remote", image)
+			}
+			auth, err := auths.encodedAuthFor(image)
+			if err != nil {
+				logger.Log.WithError(err).Errorf("failed to encode registry credential for image: %s", image)
+				return
+			}
+			out, err := cli.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: auth})
 			if err != nil {
 				logger.Log.WithError(err).Errorf("failed pull image: %s", image)
 				return
@@ -163,15 +177,19 @@ func pullImages(ctx context.Context, images []string) error {
 //
 //nolint:gocyclo // skip the cyclomatic complexity check here
 func KindSetup(e2eConfig *config.E2EConfig) error {
-	kindConfigPath = e2eConfig.Setup.GetFile()
+	var err error
+	if kindConfigPath, err = GetKindConfigPath(&e2eConfig.Setup); err != nil {
+		return err
+	}
 	kubeConfigPath = e2eConfig.Setup.GetKubeconfig()
+	kubeContext = e2eConfig.Setup.KubeContext
 	if err := checkKubeConfig(kindConfigPath); err != nil {
 		return err
 	}
 
 	steps := e2eConfig.Setup.Steps
-	// if no steps was provided, then no need to create the cluster.
-	if steps == nil {
+	// if no steps and deploy manifests was provided, then no need to create the cluster.
+	if steps == nil && len(e2eConfig.Setup.Kind.Deploy.Manifests) == 0 {
 		logger.Log.Info("no steps is provided")
 		return nil
 	}
@@ -183,17 +201,30 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 	}
 
 	// if there is an existing cluster, don't create a new kind cluster here.
-	if kubeConfigPath == "" {
+	createCluster := kubeConfigPath == ""
+	if e2eConfig.Setup.CheckPorts {
+		if err := checkKindPorts(&e2eConfig.Setup.Kind, createCluster); err != nil {
+			return err
+		}
+	}
+	if createCluster {
 		if err := createKindCluster(kindConfigPath, e2eConfig); err != nil {
 			return err
 		}
 	} else {
+		// the command lines use the current context, so switch it in a copy of the kubeconfig
+		if kubeContext != "" {
+			path := contextKubeConfigPath()
+			if err := util.KubeConfigWithContext(kubeConfigPath, kubeContext, path); err != nil {
+				return err
+			}
+			logger.Log.Infof("switch to the context %s of kubeconfig %s", kubeContext, kubeConfigPath)
+			kubeConfigPath = path
+		}
 		// export the kubeconfig path for command line
-		err := os.Setenv("KUBECONFIG", kubeConfigPath)
-		if err != nil {
-			return fmt.Errorf("could not export kubeconfig file path, %v", err)
+		if err := exportKubeConfig(kubeConfigPath); err != nil {
+			return err
 		}
-		logger.Log.Infof("export KUBECONFIG=%s", kubeConfigPath)
 	}
 
 	// import images
@@ -202,8 +233,9 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 		for _, image := range e2eConfig.Setup.Kind.ImportImages {
 			images = append(images, os.ExpandEnv(image))
 		}
-		// pull images if this image not exist
-		if err := pullImages(context.Background(), images); err != nil {
+		// pull the images not existing locally, or all of them by the image pull policy `always`
+		always := e2eConfig.Setup.Kind.ImagePullPolicy == constant.ImagePullAlways
+		if err := pullImages(context.Background(), images, newRegistryAuths(e2eConfig.Setup.Registries), "", always); err != nil {
 			return err
 		}
 
@@ -215,18 +247,30 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 			args := []string{"load", "docker-image", image, "--name", clusterName}
 
 			logger.Log.Infof("import docker images: %s", image)
-			if err := kind.Run(kindcmd.NewLogger(), kindcmd.StandardIOStreams(), args); err != nil {
+			if err := RunKind(&e2eConfig.Setup.Kind, args); err != nil {
 				return err
 			}
 		}
 	}
 
-	cluster, err := util.ConnectToK8sCluster(kubeConfigPath)
+	cluster, err := util.ConnectToK8sCluster(kubeConfigPath, kubeContext)
 	if err != nil {
-		logger.Log.Errorf("connect to k8s cluster failed according to config file: %s", kubeConfigPath)
+		logger.Log.Errorf("connect to k8s cluster failed according to config file: %s, context: %s", kubeConfigPath, kubeContext)
 		return err
 	}
 
+	if len(e2eConfig.Setup.Kind.Patches) > 0 {
+		cluster.AddObjectTransform(manifestPatchTransform(e2eConfig.Setup.Kind.Patches))
+	}
+
+	// the nodes of the created cluster are ready already by `--wait`, and the steps start after the DNS is serving too
+	if createCluster && !e2eConfig.Setup.Kind.NoWait {
+		if err = waitForCoreDNS(cluster.Client); err != nil {
+			writeFailureReport(cluster.Client, phaseSetup, err)
+			return err
+		}
+	}
+
 	listener := NewKindContainerListener(context.Background(), cluster)
 	defer listener.Stop()
 	err = listener.Listen(func(pod *v1.Pod) {
@@ -238,23 +282,55 @@ func KindSetup(e2eConfig *config.E2EConfig) error {
 		logger.Log.Warnf("listen kubernetes pod event failure: %v", err)
 	}
 
-	// run steps
-	err = RunStepsAndWait(e2eConfig.Setup.Steps, e2eConfig.Setup.GetTimeout(), cluster)
+	if e2eConfig.Setup.Validate {
+		if err = validateManifests(cluster, &e2eConfig.Setup); err != nil {
+			logger.Log.Errorf("validate manifests error: %v", err)
+			writeFailureReport(cluster.Client, phaseSetup, err)
+			return err
+		}
+	}
+
+	var exposePorts []config.KindExposePort
+	err = runWithCrashGate(cluster.Client, e2eConfig.Setup.Kind.CrashGate, func(ctx context.Context) error {
+		// deploy manifests, the deploy and the steps share the same setup timeout
+		deployStart := time.Now()
+		if err := deployManifests(cluster, &e2eConfig.Setup.Kind.Deploy, e2eConfig.Setup.GetTimeout()); err != nil {
+			logger.Log.Errorf("deploy manifests error: %v", err)
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stepsTimeout := e2eConfig.Setup.GetTimeout() - time.Since(deployStart)
+		if stepsTimeout <= 0 && len(e2eConfig.Setup.Steps) > 0 {
+			return fmt.Errorf("no time left to run steps after deploying manifests, timeout: %v", e2eConfig.Setup.GetTimeout())
+		}
+
+		// run steps, the ports exposed before the steps are interleaved with them
+		var err error
+		if exposePorts, err = runStepsWithExposes(e2eConfig.Setup.Steps, e2eConfig.Setup.Kind.ExposePorts, stepsTimeout, cluster); err != nil {
+			logger.Log.Errorf("execute steps error: %v", err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		logger.Log.Errorf("execute steps error: %v", err)
+		writeFailureReport(cluster.Client, phaseSetup, err)
 		return err
 	}
 
 	// expose logs
 	if err = exposeLogs(cluster, listener, e2eConfig.Setup.GetTimeout()); err != nil {
 		logger.Log.Errorf("export logs error: %v", err)
+		writeFailureReport(cluster.Client, phaseExposeLogs, err)
 		return err
 	}
 
 	// expose ports
-	err = exposeKindService(e2eConfig.Setup.Kind.ExposePorts, e2eConfig.Setup.GetTimeout(), cluster)
+	err = exposeKindService(exposePorts, e2eConfig.Setup.GetTimeout(), cluster)
 	if err != nil {
 		logger.Log.Errorf("export ports error: %v", err)
+		writeFailureReport(cluster.Client, phaseExposePorts, err)
 		return err
 	}
 	return nil
@@ -268,27 +344,149 @@ func checkKubeConfig(kindConfigPath string) error {
 	if kindConfigPath != "" && kubeConfigPath != "" {
 		return fmt.Errorf("the kind config file and kubeconfig file cannot be provided at the same time")
 	}
+
+	if kubeContext != "" && kubeConfigPath == "" {
+		return fmt.Errorf("the kube context can only be provided with the kubeconfig file")
+	}
 	return nil
 }
 
 func KindShouldWaitSignal() bool {
-	return portForwardContext != nil && portForwardContext.resourceCount > 0
+	for _, forwardContext := range portForwardContexts {
+		if forwardContext.resourceCount > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // KindCleanNotify notify when clean up
 func KindCleanNotify() {
-	if portForwardContext != nil {
-		close(portForwardContext.stopChannel)
+	for _, forwardContext := range portForwardContexts {
+		close(forwardContext.stopChannel)
 		// wait all stopped
-		for i := 0; i < portForwardContext.resourceCount; i++ {
-			<-portForwardContext.resourceFinishedChannel
+		for i := 0; i < forwardContext.resourceCount; i++ {
+			<-forwardContext.resourceFinishedChannel
+		}
+	}
+	portForwardContexts = nil
+}
+
+// savedEnv is the value of the env var before it's overridden.
+type savedEnv struct {
+	value  string
+	exists bool
+}
+
+// userKubeConfig is the KUBECONFIG of the process before the setup overrides it, which is restored by RestoreKubeConfig.
+var userKubeConfig *savedEnv
+
+// exportKubeConfig exports the kubeconfig path for command line, the path is also exported as
+// `<env>_KUBECONFIG` in the multi-environment run, so that the steps could access other clusters.
+func exportKubeConfig(path string) error {
+	if userKubeConfig == nil {
+		value, exists := os.LookupEnv("KUBECONFIG")
+		userKubeConfig = &savedEnv{value: value, exists: exists}
+		// the steps and the verify cases run kubectl by the env var, so the real cluster of the user is never targeted by mistake
+		if exists && value != path {
+			logger.Log.Warnf("the KUBECONFIG=%s of the process is overridden by %s during the run, it's restored after the cleanup", value, path)
+		}
+	}
+	if err := os.Setenv("KUBECONFIG", path); err != nil {
+		return fmt.Errorf("could not export kubeconfig file path, %v", err)
+	}
+	logger.Log.Infof("export KUBECONFIG=%s", path)
+	recordExportedEnv("KUBECONFIG", path)
+
+	if currentEnvironment != "" {
+		return exportKindEnv("KUBECONFIG", path, "kubeconfig")
+	}
+	return nil
+}
+
+// RestoreKubeConfig restores the KUBECONFIG of the process overridden by the setup after the cleanup, so that the process
+// embedding the e2e, and the commands it runs later, target the cluster of the user again rather than the deleted one.
+func RestoreKubeConfig() {
+	if userKubeConfig == nil {
+		return
+	}
+	if userKubeConfig.exists {
+		_ = os.Setenv("KUBECONFIG", userKubeConfig.value)
+		logger.Log.Debugf("restore KUBECONFIG=%s", userKubeConfig.value)
+	} else {
+		_ = os.Unsetenv("KUBECONFIG")
+	}
+	userKubeConfig = nil
+}
+
+// ExportKindLogs archives the logs of the pods since the time of `--since` in the kind cluster, or the logs of all
+// the nodes and pods by `kind export logs` if it's `all`, the logs are exported into the `kind` directory under the log directory.
+func ExportKindLogs(e2eConfig *config.E2EConfig) error {
+	kindConfig, err := GetKindConfigPath(&e2eConfig.Setup)
+	if err != nil {
+		return err
+	}
+	clusterName, err := util.GetKindClusterName(kindConfig)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(util.LogDir, currentEnvironment, "kind")
+	since, err := logsSince()
+	if err != nil {
+		return err
+	}
+	if !since.IsZero() {
+		client, err := util.ConnectToK8sCluster(GetKindKubeConfigPath(), "")
+		if err != nil {
+			return fmt.Errorf("failed to connect to kind cluster %s: %v", clusterName, err)
 		}
+		logger.Log.Infof("exporting logs of the pods in kind cluster %s since %s into %s", clusterName, since.Format(time.RFC3339), dir)
+		return exportPodLogs(client.Client, dir, since)
 	}
+
+	args := []string{"export", "logs", dir, "--name", clusterName}
+
+	logger.Log.Infof("exporting logs of kind cluster %s into %s", clusterName, dir)
+	if err := RunKind(&e2eConfig.Setup.Kind, args); err != nil {
+		return fmt.Errorf("failed to export logs of kind cluster %s: %v", clusterName, err)
+	}
+	return nil
+}
+
+// checkKindPorts checks the fixed local ports of the kind setup are available, the containers publishing the ports,
+// such as the nodes of the stale clusters, are reported.
+func checkKindPorts(kindSetup *config.KindSetup, createCluster bool) error {
+	ports, err := kindFixedPorts(kindSetup, kindConfigPath, createCluster)
+	if err != nil || len(ports) == 0 {
+		return err
+	}
+	var lister containerLister
+	if cli, err := newDockerClient(""); err == nil {
+		defer cli.Close()
+		lister = cli
+	}
+	return checkPortsAvailable(ports, lister)
 }
 
 func createKindCluster(kindConfigPath string, e2eConfig *config.E2EConfig) error {
+	version, err := KindVersion(&e2eConfig.Setup.Kind)
+	if err != nil {
+		return err
+	}
+	if binary := e2eConfig.Setup.Kind.GetBinary(); binary != "" {
+		logger.Log.Infof("using kind binary %s %s", binary, version)
+	}
+	images, err := util.GetKindNodeImages(kindConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the node images of the kind config %s: %v", kindConfigPath, err)
+	}
+	for _, warning := range checkKindNodeImages(version, images) {
+		logger.Log.Warnf("%s", warning)
+	}
+
 	// the config file name of the k8s cluster that kind create
-	kubeConfigPath = constant.K8sClusterConfigFilePath
+	kubeConfigPath = GetKindKubeConfigPath()
 	args := []string{
 		"create", "cluster",
 		"--config", kindConfigPath,
@@ -299,24 +497,18 @@ func createKindCluster(kindConfigPath string, e2eConfig *config.E2EConfig) error
 	}
 
 	logger.Log.Info("creating kind cluster...")
-	logger.Log.Debugf("cluster create commands: %s %s", constant.KindCommand, strings.Join(args, " "))
-	if err := kind.Run(kindcmd.NewLogger(), kindcmd.StandardIOStreams(), args); err != nil {
+	if err := RunKind(&e2eConfig.Setup.Kind, args); err != nil {
 		return err
 	}
 	logger.Log.Info("create kind cluster succeeded")
 
 	// export kubeconfig path for command line
-	err := os.Setenv("KUBECONFIG", kubeConfigPath)
-	if err != nil {
-		return fmt.Errorf("could not export kubeconfig file path, %v", err)
-	}
-	logger.Log.Infof("export KUBECONFIG=%s", kubeConfigPath)
-	return nil
+	return exportKubeConfig(kubeConfigPath)
 }
 
 func getWaitOptions(cluster *util.K8sClusterInfo, wait *config.Wait) (options *ctlwait.WaitOptions, err error) {
-	if strings.Contains(wait.Resource, "/") && wait.LabelSelector != "" {
-		return nil, fmt.Errorf("when passing resource.group/resource.name in Resource, the labelSelector can not be set at the same time")
+	if err := validateWaitResource(wait); err != nil {
+		return nil, err
 	}
 
 	restClientGetter := cluster.CopyClusterToNamespace(wait.Namespace)
@@ -327,13 +519,8 @@ func getWaitOptions(cluster *util.K8sClusterInfo, wait *config.Wait) (options *c
 	waitFlags.Timeout = constant.SingleDefaultWaitTimeout
 	waitFlags.ForCondition = wait.For
 
-	var args []string
 	// resource.group/resource.name OR resource.group
-	if wait.Resource != "" {
-		args = append(args, wait.Resource)
-	} else {
-		return nil, fmt.Errorf("resource must be provided in wait block")
-	}
+	args := []string{wait.Resource}
 
 	if wait.LabelSelector != "" {
 		waitFlags.ResourceBuilderFlags.LabelSelector = &wait.LabelSelector
@@ -341,6 +528,9 @@ func getWaitOptions(cluster *util.K8sClusterInfo, wait *config.Wait) (options *c
 		// if labelSelector is nil and resource only provide resource.group, check all resources.
 		waitFlags.ResourceBuilderFlags.All = &constant.True
 	}
+	if wait.AllNamespaces {
+		waitFlags.ResourceBuilderFlags.AllNamespaces = &constant.True
+	}
 
 	options, err = waitFlags.ToOptions(args)
 	if err != nil {
@@ -349,25 +539,62 @@ func getWaitOptions(cluster *util.K8sClusterInfo, wait *config.Wait) (options *c
 	return options, nil
 }
 
-func createByManifest(c *util.K8sClusterInfo, manifest config.Manifest) error {
+// createByManifest creates the manifest files in order, and waits for the conditions of the file before creating the next one.
+func createByManifest(c *util.K8sClusterInfo, manifest config.Manifest, timeout time.Duration) error {
 	files, err := util.GetManifests(manifest.Path)
 	if err != nil {
 		logger.Log.Error("get manifests failed")
 		return err
 	}
+	fileWaits, err := manifestFileWaits(files, manifest.FileWaits)
+	if err != nil {
+		return err
+	}
 
+	start := time.Now()
 	for _, f := range files {
-		logger.Log.Infof("creating manifest %s", f)
-		err = util.OperateManifest(c.Client, c.Interface, f, apiv1.Create)
+		if manifest.Apply != nil {
+			logger.Log.Infof("applying manifest %s", f)
+			err = util.ApplyManifest(c, f, util.ApplyOptions{FieldManager: manifest.Apply.FieldManager, Force: manifest.Apply.Force})
+		} else {
+			logger.Log.Infof("creating manifest %s", f)
+			err = util.OperateManifest(c, f, apiv1.Create)
+		}
 		if err != nil {
 			logger.Log.Errorf("create manifest %s failed", f)
 			return err
 		}
+
+		if waits := fileWaits[f]; len(waits) > 0 {
+			if err := concurrentlyWaitAll(c, waits, f, NewTimeout(start, timeout)); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
-func concurrentlyWait(wait *config.Wait, options *ctlwait.WaitOptions, waitSet *util.WaitSet) {
+// manifestFileWaits groups the conditions by the absolute paths of the manifest files, the files must be the ones of the manifest.
+func manifestFileWaits(files []string, fileWaits []config.FileWait) (map[string][]config.Wait, error) {
+	result := make(map[string][]config.Wait, len(fileWaits))
+	for _, fileWait := range fileWaits {
+		file := util.ResolveAbs(fileWait.File)
+		found := false
+		for _, f := range files {
+			if f == file {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("the file %s of the file-wait is not one of the manifest files %v", fileWait.File, files)
+		}
+		result[file] = append(result[file], fileWait.Waits...)
+	}
+	return result, nil
+}
+
+func concurrentlyWait(wait *config.Wait, options waiter, waitSet *util.WaitSet) {
 	defer waitSet.WaitGroup.Done()
 
 	err := options.RunWait()
@@ -444,7 +671,7 @@ func buildKindPort(port string, ro runtime.Object, pod *v1.Pod) (*kindPort, erro
 
 func exposePerKindService(port config.KindExposePort, timeout time.Duration, cluster *util.K8sClusterInfo,
 	client *rest.RESTClient, roundTripper http.RoundTripper, upgrader spdy.Upgrader, forward *kindPortForwardContext) error {
-	// find resource
+	// find resource, the name without the type is a pod
 	builder := resource.NewBuilder(cluster).
 		WithScheme(scheme.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
 		ContinueOnError().
@@ -454,20 +681,19 @@ func exposePerKindService(port config.KindExposePort, timeout time.Duration, clu
 	if err != nil {
 		return err
 	}
-	forwardablePod, err := polymorphichelpers.AttachablePodForObjectFn(cluster, obj, timeout)
+	if err := checkExposeResource(port.Resource, obj); err != nil {
+		return err
+	}
+	var forwardablePod *v1.Pod
+	if port.WaitReady {
+		forwardablePod, err = waitReadyForwardPod(cluster.Client, port.Resource, obj, timeout)
+	} else {
+		forwardablePod, err = polymorphichelpers.AttachablePodForObjectFn(cluster, obj, timeout)
+	}
 	if err != nil {
 		return err
 	}
 
-	// build port forward request
-	req := client.Post().
-		Resource("pods").
-		Namespace(forwardablePod.Namespace).
-		Name(forwardablePod.Name).
-		SubResource("portforward")
-
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, req.URL())
-
 	// build ports
 	ports := strings.Split(port.Port, ",")
 	convertedPorts := make([]*kindPort, len(ports))
@@ -479,60 +705,136 @@ func exposePerKindService(port config.KindExposePort, timeout time.Duration, clu
 		exposePorts[i] = convertedPorts[i].waitExpose
 	}
 
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	readyChannel := make(chan struct{}, 1)
-	forwardErrorChannel := make(chan error, 1)
+	address := port.Address
+	if address == "" {
+		address = defaultForwardAddress
+	}
+	forwarder := &podForwarder{
+		cluster:      cluster,
+		client:       client,
+		roundTripper: roundTripper,
+		upgrader:     upgrader,
+		address:      address,
+		resource:     port.Resource,
+		object:       obj,
+		waitReady:    port.WaitReady,
+	}
+	forwarding, err := forwarder.start(forwardablePod, exposePorts)
+	if err != nil {
+		return err
+	}
 
-	forwarder, err := portforward.New(dialer, exposePorts, forward.stopChannel, readyChannel,
-		bufio.NewWriter(&stdout), bufio.NewWriter(&stderr))
+	// wait port forward result
+	select {
+	case <-forwarding.ready:
+	case <-forwarding.done:
+		return fmt.Errorf("create forward error, %s : %v", forwarding.stderr.String(), forwarding.err)
+	}
+	exportedPorts, err := forwarding.forwarder.GetPorts()
+	if err == nil {
+		err = exportForwardedPorts(port, address, exportedPorts, convertedPorts, timeout)
+	}
 	if err != nil {
+		close(forwarding.stop)
+		<-forwarding.done
 		return err
 	}
 
-	// start forward
+	// the same local ports are forwarded to the current pod after the pod restarts
+	localPorts := make([]string, len(exportedPorts))
+	for i, p := range exportedPorts {
+		localPorts[i] = fmt.Sprintf("%d:%d", p.Local, p.Remote)
+	}
 	go func() {
-		if err = forwarder.ForwardPorts(); err != nil {
-			forwardErrorChannel <- err
-		}
+		forwarder.keepForwarding(forwarding, localPorts, forward.stopChannel)
 		forward.resourceFinishedChannel <- struct{}{}
 	}()
+	return nil
+}
 
-	// wait port forward result
-	select {
-	case <-readyChannel:
-		exportedPorts, err1 := forwarder.GetPorts()
-		if err1 != nil {
-			return err1
+// exportForwardedPorts probes the forwarded ports if needed, and exports the host and the local ports of the resource.
+func exportForwardedPorts(port config.KindExposePort, address string, exportedPorts []portforward.ForwardedPort,
+	convertedPorts []*kindPort, timeout time.Duration) error {
+	if port.Ready != nil {
+		for _, p := range exportedPorts {
+			if err := probeForwardedPort(port.Ready, address, p.Local, timeout); err != nil {
+				return err
+			}
 		}
+	}
 
-		// format: <resource>_host
-		resourceName := port.Resource
-		resourceName = strings.ReplaceAll(resourceName, "/", "_")
-		resourceName = strings.ReplaceAll(resourceName, "-", "_")
-		if err1 := exportKindEnv(fmt.Sprintf("%s_host", resourceName),
-			"localhost", port.Resource); err1 != nil {
-			return err1
-		}
+	// format: <resource>_host
+	resourceName := envResourceName(port.Resource)
+	if err := exportKindEnv(fmt.Sprintf("%s_host", resourceName), hostForURL(address), port.Resource); err != nil {
+		return err
+	}
 
-		// format: <resource>_<need_export_port>
-		for _, p := range exportedPorts {
-			for _, kp := range convertedPorts {
-				if int(p.Remote) == kp.realPort {
-					if err1 := exportKindEnv(fmt.Sprintf("%s_%s", resourceName, kp.inputPort),
-						fmt.Sprintf("%d", p.Local), port.Resource); err1 != nil {
-						return err1
-					}
+	// format: <resource>_<need_export_port>
+	for _, p := range exportedPorts {
+		for _, kp := range convertedPorts {
+			if int(p.Remote) == kp.realPort {
+				if err := exportKindEnv(fmt.Sprintf("%s_%s", resourceName, kp.inputPort),
+					fmt.Sprintf("%d", p.Local), port.Resource); err != nil {
+					return err
 				}
 			}
 		}
-
-	case err = <-forwardErrorChannel:
-		return fmt.Errorf("create forward error, %s : %v", stderr.String(), err)
 	}
 	return nil
 }
 
+// runStepsWithExposes runs the steps and exposes the ports declared before the steps,
+// returns the rest ports which should be exposed after all the steps.
+func runStepsWithExposes(steps []config.Step, exposes []config.KindExposePort, timeout time.Duration,
+	cluster *util.K8sClusterInfo) ([]config.KindExposePort, error) {
+	before, after, err := stepExposePorts(steps, exposes)
+	if err != nil {
+		return nil, err
+	}
+
+	start, from := time.Now(), 0
+	for i := range steps {
+		ports := before[steps[i].Name]
+		if len(ports) == 0 {
+			continue
+		}
+		if err := RunStepsAndWait(steps[from:i], NewTimeout(start, timeout), cluster, nil); err != nil {
+			return nil, err
+		}
+		logger.Log.Infof("exposing the ports before setup step [%s]", steps[i].Name)
+		if err := exposeKindService(ports, NewTimeout(start, timeout), cluster); err != nil {
+			return nil, &e2eerrors.StepError{Step: steps[i].Name, Err: err}
+		}
+		from = i
+	}
+	if err := RunStepsAndWait(steps[from:], NewTimeout(start, timeout), cluster, nil); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// stepExposePorts groups the exposed ports by the step which they are exposed before,
+// the ports without the step are exposed after all the steps.
+func stepExposePorts(steps []config.Step, exposes []config.KindExposePort) (before map[string][]config.KindExposePort,
+	after []config.KindExposePort, err error) {
+	names := make(map[string]bool, len(steps))
+	for i := range steps {
+		names[steps[i].Name] = true
+	}
+	before = make(map[string][]config.KindExposePort)
+	for _, p := range exposes {
+		if p.Before == "" {
+			after = append(after, p)
+			continue
+		}
+		if !names[p.Before] {
+			return nil, nil, fmt.Errorf("the step %s to expose %s before is not found in the setup steps", p.Before, p.Resource)
+		}
+		before[p.Before] = append(before[p.Before], p)
+	}
+	return before, after, nil
+}
+
 func exposeKindService(exports []config.KindExposePort, timeout time.Duration, cluster *util.K8sClusterInfo) error {
 	restConf, err := cluster.ToRESTConfig()
 	if err != nil {
@@ -562,20 +864,32 @@ func exposeKindService(exports []config.KindExposePort, timeout time.Duration, c
 		waitTimeout = timeout
 	}
 
+	// the node-port exports don't forward the ports
+	forwards := make([]config.KindExposePort, 0, len(exports))
+	for _, p := range exports {
+		if p.Mode != constant.ExposeModeNodePort {
+			forwards = append(forwards, p)
+			continue
+		}
+		if err := exposeNodePortService(p, waitTimeout, cluster); err != nil {
+			return err
+		}
+	}
+
 	// stop port-forward channel
 	forwardContext := &kindPortForwardContext{
 		stopChannel:             make(chan struct{}, 1),
-		resourceFinishedChannel: make(chan struct{}, len(exports)),
-		resourceCount:           len(exports),
+		resourceFinishedChannel: make(chan struct{}, len(forwards)),
+		resourceCount:           len(forwards),
 	}
-	for _, p := range exports {
+	for _, p := range forwards {
 		if err := exposePerKindService(p, waitTimeout, cluster, client, tripperFor, upgrader, forwardContext); err != nil {
 			return err
 		}
 	}
 
 	// bind context
-	portForwardContext = forwardContext
+	portForwardContexts = append(portForwardContexts, forwardContext)
 	return nil
 }
 
@@ -584,7 +898,7 @@ func exposePerContainerLog(clientGetter *util.K8sClusterInfo, pod *v1.Pod, timeo
 		return nil
 	}
 
-	file := filepath.Join(pod.Namespace, fmt.Sprintf("%s.log", pod.Name))
+	file := filepath.Join(currentEnvironment, pod.Namespace, fmt.Sprintf("%s.log", pod.Name))
 	// check is followed
 	if logFollower.IsFollowed(file) {
 		return nil
@@ -641,11 +955,20 @@ func exposeLogs(clientGetter *util.K8sClusterInfo, listener *KindContainerListen
 	return nil
 }
 
+// envResourceName converts the resource into the prefix of the env vars, all the `/` or `-` are replaced as `_`,
+// such as `service_foo` of `service/foo`.
+func envResourceName(resource string) string {
+	resourceName := strings.ReplaceAll(resource, "/", "_")
+	return strings.ReplaceAll(resourceName, "-", "_")
+}
+
 func exportKindEnv(key, value, res string) error {
+	key = environmentKey(key)
 	err := os.Setenv(key, value)
 	if err != nil {
 		return fmt.Errorf("could not set env for %s, %v", res, err)
 	}
 	logger.Log.Infof("export %s=%s", key, value)
+	recordExportedEnv(key, value)
 	return nil
 }
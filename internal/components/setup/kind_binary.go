@@ -0,0 +1,140 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	kind "sigs.k8s.io/kind/cmd/kind/app"
+	kindcmd "sigs.k8s.io/kind/pkg/cmd"
+	kindversion "sigs.k8s.io/kind/pkg/cmd/kind/version"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+)
+
+// kindNodeImage is the repository of the node images released with the kind.
+const kindNodeImage = "kindest/node"
+
+// kindNodeMinorVersions are the ranges of the kubernetes minor versions of the node images released with the kind versions,
+// see the release notes of kind, such as https://github.com/kubernetes-sigs/kind/releases/tag/v0.27.0.
+var kindNodeMinorVersions = map[string][2]int{
+	"v0.17": {19, 25},
+	"v0.18": {19, 26},
+	"v0.19": {21, 27},
+	"v0.20": {21, 27},
+	"v0.21": {23, 29},
+	"v0.22": {23, 29},
+	"v0.23": {25, 30},
+	"v0.24": {25, 31},
+	"v0.25": {26, 32},
+	"v0.26": {26, 32},
+	"v0.27": {29, 32},
+}
+
+// RunKind runs the kind command by the external binary of `kind.binary` if it's set, otherwise by the built-in kind.
+func RunKind(kindSetup *config.KindSetup, args []string) error {
+	binary := kindSetup.GetBinary()
+	if binary == "" {
+		logger.Log.Debugf("kind commands: %s %s", constant.KindCommand, strings.Join(args, " "))
+		return kind.Run(kindcmd.NewLogger(), kindcmd.StandardIOStreams(), args)
+	}
+
+	logger.Log.Debugf("kind commands: %s %s", binary, strings.Join(args, " "))
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run kind binary %s: %v", binary, err)
+	}
+	return nil
+}
+
+// KindVersion returns the version of the kind to run, and checks it's the pinned `kind.version`. The node images are built for
+// the specific kind versions, the mismatched kind fails creating the cluster from the node images of the kind config with the cryptic errors.
+func KindVersion(kindSetup *config.KindSetup) (string, error) {
+	binary := kindSetup.GetBinary()
+	version := "v" + kindversion.Version()
+	if binary != "" {
+		output, err := exec.Command(binary, "version").Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to get the version of kind binary %s: %v", binary, err)
+		}
+		if version, err = parseKindVersion(string(output)); err != nil {
+			return "", err
+		}
+	}
+
+	if kindSetup.Version != "" && !sameKindVersion(version, kindSetup.Version) {
+		if binary == "" {
+			return version, fmt.Errorf("the built-in kind is %s, but %s is required by kind.version, set kind.binary to the kind binary of the version",
+				version, kindSetup.Version)
+		}
+		return version, fmt.Errorf("the kind binary %s is %s, but %s is required by kind.version", binary, version, kindSetup.Version)
+	}
+	return version, nil
+}
+
+// checkKindNodeImages checks the kubernetes versions of the node images are released with the kind version, the warnings are returned
+// for the images out of the range, as the node images of the other kind versions may fail creating the cluster with the cryptic errors.
+// The custom images, the images of the unknown kind versions and the default image are not checked.
+func checkKindNodeImages(kindVersion string, images []string) []string {
+	major, minor, _ := strings.Cut(strings.TrimPrefix(kindVersion, "v"), ".")
+	minor, _, _ = strings.Cut(minor, ".")
+	supported, ok := kindNodeMinorVersions[fmt.Sprintf("v%s.%s", major, minor)]
+	if !ok {
+		logger.Log.Debugf("the node images of kind %s are not checked, the kubernetes versions it supports are unknown", kindVersion)
+		return nil
+	}
+
+	warnings := make([]string, 0)
+	for _, image := range images {
+		image, _, _ = strings.Cut(image, "@")
+		repository, tag, found := strings.Cut(image, ":")
+		if !found || repository != kindNodeImage {
+			continue
+		}
+		var k8sMajor, k8sMinor int
+		if _, err := fmt.Sscanf(tag, "v%d.%d", &k8sMajor, &k8sMinor); err != nil || k8sMajor != 1 {
+			continue
+		}
+		if k8sMinor < supported[0] || k8sMinor > supported[1] {
+			warnings = append(warnings, fmt.Sprintf("the node image %s is not released with kind %s, which supports kubernetes v1.%d to v1.%d, "+
+				"creating the cluster may fail, please use the node image of the kind release or pin kind.version", image, kindVersion, supported[0], supported[1]))
+		}
+	}
+	return warnings
+}
+
+// parseKindVersion parses the version from the output of `kind version`, such as `kind v0.20.0 go1.20.4 linux/amd64`.
+func parseKindVersion(output string) (string, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 2 || fields[0] != constant.KindCommand {
+		return "", fmt.Errorf("unexpected output of kind version: %s", strings.TrimSpace(output))
+	}
+	return fields[1], nil
+}
+
+// sameKindVersion compares the versions regardless of the `v` prefix.
+func sameKindVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
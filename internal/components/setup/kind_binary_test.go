@@ -0,0 +1,94 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	kindversion "sigs.k8s.io/kind/pkg/cmd/kind/version"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+func TestKindVersion(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "kind")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho 'kind v0.20.0 go1.20.4 linux/amd64'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		kindSetup config.KindSetup
+		want      string
+		wantErr   bool
+	}{
+		{name: "built-in", kindSetup: config.KindSetup{}, want: "v" + kindversion.Version()},
+		{name: "built-in pinned", kindSetup: config.KindSetup{Version: kindversion.Version()}, want: "v" + kindversion.Version()},
+		{name: "built-in mismatched", kindSetup: config.KindSetup{Version: "v0.1.0"}, want: "v" + kindversion.Version(), wantErr: true},
+		{name: "binary", kindSetup: config.KindSetup{Binary: binary}, want: "v0.20.0"},
+		{name: "binary pinned", kindSetup: config.KindSetup{Binary: binary, Version: "v0.20.0"}, want: "v0.20.0"},
+		{name: "binary mismatched", kindSetup: config.KindSetup{Binary: binary, Version: "0.22.0"}, want: "v0.20.0", wantErr: true},
+		{name: "binary not found", kindSetup: config.KindSetup{Binary: filepath.Join(t.TempDir(), "kind")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := KindVersion(&tt.kindSetup)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("KindVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("KindVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckKindNodeImages(t *testing.T) {
+	tests := []struct {
+		name        string
+		kindVersion string
+		images      []string
+		want        int
+	}{
+		{name: "default image", kindVersion: "v0.27.0", images: []string{""}},
+		{name: "released image", kindVersion: "v0.27.0",
+			images: []string{"kindest/node:v1.32.2@sha256:f226345927d7e348497136874b6d207e0b32cc52154ad8323129352923a3142f", "kindest/node:v1.29.14"}},
+		{name: "image of the newer kind", kindVersion: "v0.20.0", images: []string{"kindest/node:v1.32.2", "kindest/node:v1.27.3"}, want: 1},
+		{name: "image of the older kind", kindVersion: "0.27.0", images: []string{"kindest/node:v1.25.3"}, want: 1},
+		{name: "custom image", kindVersion: "v0.27.0", images: []string{"registry.local/node:v1.20.0"}},
+		{name: "unknown kind version", kindVersion: "v0.99.0", images: []string{"kindest/node:v1.20.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkKindNodeImages(tt.kindVersion, tt.images); len(got) != tt.want {
+				t.Errorf("checkKindNodeImages() = %v, want %d warnings", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKindVersion(t *testing.T) {
+	if got, err := parseKindVersion("kind v0.27.0 go1.23.4 linux/amd64\n"); err != nil || got != "v0.27.0" {
+		t.Errorf("parseKindVersion() = %s, %v, want v0.27.0", got, err)
+	}
+	if _, err := parseKindVersion("command not found"); err == nil {
+		t.Errorf("parseKindVersion() should fail on the unexpected output")
+	}
+}
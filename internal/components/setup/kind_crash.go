@@ -0,0 +1,116 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+const crashLoopBackOff = "CrashLoopBackOff"
+
+// runWithCrashGate runs the setup function, and fails fast if any pod crashes before it finishes,
+// instead of waiting for the whole timeout of the readiness waits.
+func runWithCrashGate(client kubernetes.Interface, gate *config.KindCrashGate, setup func(ctx context.Context) error) error {
+	if gate == nil {
+		return setup(util.RunContext())
+	}
+
+	// the context is also the run context during the setup, so that the waits and the steps are stopped when any pod crashes
+	ctx, cancel := context.WithCancel(util.RunContext())
+	defer cancel()
+	defer util.SetRunContext(ctx)()
+
+	crashed := make(chan error, 1)
+	go watchCrashedPods(ctx, client, gate, crashed)
+
+	finished := make(chan error, 1)
+	go func() {
+		finished <- setup(ctx)
+	}()
+
+	select {
+	case err := <-finished:
+		return err
+	case err := <-crashed:
+		// wait for the setup to stop, so that it doesn't apply the manifests during the failure handling and the cleanup
+		cancel()
+		if setupErr := <-finished; setupErr != nil {
+			logger.Log.Debugf("the setup stopped by the crashed pods: %v", setupErr)
+		}
+		return err
+	}
+}
+
+// watchCrashedPods polls the pods until any container crashes, or the context is done.
+func watchCrashedPods(ctx context.Context, client kubernetes.Interface, gate *config.KindCrashGate, crashed chan<- error) {
+	namespaces := gate.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceDefault}
+	}
+
+	ticker := time.NewTicker(workloadPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, namespace := range namespaces {
+			pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: gate.LabelSelector})
+			if err != nil {
+				logger.Log.Debugf("failed to list the pods in namespace %s for crash check: %v", namespace, err)
+				continue
+			}
+			if containers := crashedContainers(pods.Items, gate.MaxRestarts); len(containers) > 0 {
+				crashed <- fmt.Errorf("pods crashed in namespace %s: %v", namespace, containers)
+				return
+			}
+		}
+	}
+}
+
+// crashedContainers returns the containers in CrashLoopBackOff, or restarted more than the max restarts if it's positive.
+func crashedContainers(pods []corev1.Pod, maxRestarts int32) []string {
+	crashed := make([]string, 0)
+	for i := range pods {
+		pod := &pods[i]
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for j := range statuses {
+			status := &statuses[j]
+			if status.State.Waiting != nil && status.State.Waiting.Reason == crashLoopBackOff {
+				crashed = append(crashed, fmt.Sprintf("%s/%s(%s, restarts: %d)", pod.Name, status.Name, crashLoopBackOff, status.RestartCount))
+			} else if maxRestarts > 0 && status.RestartCount > maxRestarts {
+				crashed = append(crashed, fmt.Sprintf("%s/%s(restarts: %d)", pod.Name, status.Name, status.RestartCount))
+			}
+		}
+	}
+	return crashed
+}
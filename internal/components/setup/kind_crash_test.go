@@ -0,0 +1,93 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func newPodWithContainer(name string, state corev1.ContainerState, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", State: state, RestartCount: restarts},
+		}},
+	}
+}
+
+func TestCrashedContainers(t *testing.T) {
+	crashLoop := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOff}}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	tests := []struct {
+		name        string
+		pod         *corev1.Pod
+		maxRestarts int32
+		wantCrashed bool
+	}{
+		{name: "should pass the running container", pod: newPodWithContainer("foo", running, 0)},
+		{name: "should fail the container in CrashLoopBackOff", pod: newPodWithContainer("foo", crashLoop, 1), wantCrashed: true},
+		{name: "should ignore the restarts by default", pod: newPodWithContainer("foo", running, 5)},
+		{name: "should pass the restarts within the max restarts", pod: newPodWithContainer("foo", running, 2), maxRestarts: 2},
+		{name: "should fail the restarts over the max restarts", pod: newPodWithContainer("foo", running, 3), maxRestarts: 2, wantCrashed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := crashedContainers([]corev1.Pod{*tt.pod}, tt.maxRestarts); (len(got) > 0) != tt.wantCrashed {
+				t.Errorf("crashedContainers() = %v, wantCrashed %v", got, tt.wantCrashed)
+			}
+		})
+	}
+}
+
+func TestRunWithCrashGate(t *testing.T) {
+	crashLoop := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOff}}
+	client := fake.NewSimpleClientset(newPodWithContainer("foo", crashLoop, 3))
+
+	start := time.Now()
+	stopped := false
+	err := runWithCrashGate(client, &config.KindCrashGate{}, func(context.Context) error {
+		defer func() { stopped = true }()
+		// the waits of the setup are stopped by the run context
+		if err := util.Sleep(time.Minute); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("runWithCrashGate() should fail when the pod crashes")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("runWithCrashGate() should fail fast, but took %v", elapsed)
+	}
+	if !stopped {
+		t.Errorf("runWithCrashGate() should wait for the setup to stop before returning")
+	}
+
+	if err := runWithCrashGate(client, nil, func(context.Context) error { return nil }); err != nil {
+		t.Errorf("runWithCrashGate() without gate error = %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/resource"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// RunVerifySteps runs the steps after the trigger and before verifying the cases,
+// the steps with path or delete run against the cluster of the kind environment.
+func RunVerifySteps(e2eConfig *config.E2EConfig) error {
+	steps := e2eConfig.Verify.Steps
+	if len(steps) == 0 {
+		return nil
+	}
+
+	cluster, err := connectToKindEnvironment(&e2eConfig.Setup)
+	if err != nil {
+		return err
+	}
+	return RunStepsAndWait(steps, e2eConfig.Setup.GetTimeout(), cluster, nil)
+}
+
+// DeleteSetupManifests deletes the resources applied by the setup from the existing cluster of the kubeconfig, the manifests
+// and the resources in each manifest are deleted in the reverse applying order, so that the dependent resources, such as
+// the custom resources, are deleted before the ones they depend on, such as the operators, the CRDs and the namespaces.
+func DeleteSetupManifests(s *config.Setup) error {
+	manifests, err := setupManifests(&s.Kind.Deploy, s.Steps)
+	if err != nil {
+		return err
+	}
+	cluster, err := util.ConnectToK8sCluster(s.GetKubeconfig(), s.KubeContext)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for i := len(manifests) - 1; i >= 0; i-- {
+		objects, err := manifests[i].objects()
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		logger.Log.Infof("deleting manifest %s", manifests[i].path)
+		for j := len(objects) - 1; j >= 0; j-- {
+			err := util.OperateObjects(cluster, objects[j:j+1], apiv1.Delete)
+			// the resources may be deleted already, such as the ones in the deleted namespaces or of the deleted CRDs
+			if err == nil || apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("failed to delete %s %s of manifest %s: %v",
+				objects[j].GetKind(), objects[j].GetName(), manifests[i].path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// connectToKindEnvironment connects to the cluster of the only kind environment, returns nil if there is no kind environment.
+func connectToKindEnvironment(s *config.Setup) (*util.K8sClusterInfo, error) {
+	var kindEnvironment *config.Environment
+	for _, environment := range s.GetEnvironments() {
+		if environment.Env != constant.Kind {
+			continue
+		}
+		if kindEnvironment != nil {
+			return nil, fmt.Errorf("verify.steps only support one kind environment, but got %s and %s",
+				kindEnvironment.Name, environment.Name)
+		}
+		kindEnvironment = environment
+	}
+	if kindEnvironment == nil {
+		return nil, nil
+	}
+
+	SetEnvironment(kindEnvironment.Name)
+	defer SetEnvironment("")
+
+	// the kind cluster created by setup writes the kubeconfig to the default path
+	path := kindEnvironment.GetKubeconfig()
+	if path == "" {
+		path = GetKindKubeConfigPath()
+	}
+	cluster, err := util.ConnectToK8sCluster(path, kindEnvironment.KubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("connect to k8s cluster failed according to config file: %s, error: %v", path, err)
+	}
+	return cluster, nil
+}
+
+// deleteResourcesAndWait deletes the resources and concurrently waits for the conditions, such as the recreated pod is ready.
+func deleteResourcesAndWait(c *util.K8sClusterInfo, del *config.DeleteResource, waits []config.Wait, timeout time.Duration) error {
+	deleted, err := deleteResources(c, del)
+	if err != nil {
+		return err
+	}
+	logger.Log.Infof("deleted resources %s", strings.Join(deleted, ", "))
+
+	return concurrentlyWaitAll(c, waits, del.Resource, timeout)
+}
+
+// deleteResources deletes the resources selected by the name or the label selector, returns the names of the deleted resources.
+func deleteResources(c *util.K8sClusterInfo, del *config.DeleteResource) ([]string, error) {
+	if del.Resource == "" {
+		return nil, fmt.Errorf("the resource to delete must be provided")
+	}
+	named := strings.Contains(del.Resource, "/")
+	if named == (del.LabelSelector != "") {
+		return nil, fmt.Errorf("one of the resource name or the label selector should be specified to delete %s", del.Resource)
+	}
+
+	builder := resource.NewBuilder(c.CopyClusterToNamespace(del.Namespace)).
+		Unstructured().
+		NamespaceParam(del.Namespace).DefaultNamespace()
+	if del.LabelSelector != "" {
+		builder.LabelSelectorParam(del.LabelSelector)
+	}
+	infos, err := builder.ResourceTypeOrNameArgs(false, del.Resource).Flatten().Do().Infos()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, fmt.Errorf("no %s matches the label selector %s", del.Resource, del.LabelSelector)
+	}
+
+	deleted := make([]string, 0, len(infos))
+	for _, info := range infos {
+		helper := resource.NewHelper(info.Client, info.Mapping)
+		if _, err := helper.Delete(info.Namespace, info.Name); err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete %s: %v", info.ObjectName(), err)
+		}
+		deleted = append(deleted, info.ObjectName())
+	}
+	return deleted, nil
+}
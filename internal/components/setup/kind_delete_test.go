@@ -0,0 +1,58 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"testing"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+func TestDeleteResourcesParameters(t *testing.T) {
+	tests := []struct {
+		name string
+		del  config.DeleteResource
+	}{
+		{name: "should fail without the resource", del: config.DeleteResource{LabelSelector: "app=oap"}},
+		{name: "should fail without the name or the label selector", del: config.DeleteResource{Resource: "pod"}},
+		{name: "should fail with both the name and the label selector", del: config.DeleteResource{Resource: "pod/oap", LabelSelector: "app=oap"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := deleteResources(nil, &tt.del); err == nil {
+				t.Errorf("deleteResources() should fail for %+v", tt.del)
+			}
+		})
+	}
+}
+
+func TestConnectToKindEnvironment(t *testing.T) {
+	s := config.Setup{Environments: []config.Environment{
+		{Name: "primary", Setup: config.Setup{Env: constant.Kind}},
+		{Name: "secondary", Setup: config.Setup{Env: constant.Kind}},
+	}}
+	if _, err := connectToKindEnvironment(&s); err == nil {
+		t.Errorf("connectToKindEnvironment() should fail with multiple kind environments")
+	}
+
+	s = config.Setup{Env: constant.Compose}
+	if cluster, err := connectToKindEnvironment(&s); err != nil || cluster != nil {
+		t.Errorf("connectToKindEnvironment() = %v, %v, want no cluster for the compose environment", cluster, err)
+	}
+}
@@ -0,0 +1,203 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	apiv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+const (
+	kindDeployment  = "Deployment"
+	kindStatefulSet = "StatefulSet"
+	kindPVC         = "PersistentVolumeClaim"
+	kindPod         = "Pod"
+	kindService     = "Service"
+	kindConfigMap   = "ConfigMap"
+	kindSecret      = "Secret"
+
+	workloadPollInterval = time.Second
+)
+
+// deployWorkload is a workload declared in the deploy manifests which should be waited for.
+type deployWorkload struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (w *deployWorkload) String() string {
+	return fmt.Sprintf("%s %s/%s", w.kind, w.namespace, w.name)
+}
+
+// deployManifests applies all the manifests in setup.kind.deploy, and waits for all the
+// Deployments and StatefulSets in them to be ready according to the wait policy.
+func deployManifests(c *util.K8sClusterInfo, deploy *config.KindDeploy, timeout time.Duration) error {
+	if len(deploy.Manifests) == 0 {
+		return nil
+	}
+
+	policy := deploy.Wait
+	if policy == "" {
+		policy = constant.DeployWaitAll
+	}
+	if policy != constant.DeployWaitAll && policy != constant.DeployWaitNone {
+		return fmt.Errorf("unsupported deploy wait policy: %s, should use %s or %s instead",
+			policy, constant.DeployWaitAll, constant.DeployWaitNone)
+	}
+
+	files, err := resolveDeployManifests(deploy.Manifests)
+	if err != nil {
+		return err
+	}
+
+	workloads := make([]*deployWorkload, 0)
+	for _, f := range files {
+		objects, err := util.DecodeManifest(f)
+		if err != nil {
+			return err
+		}
+
+		logger.Log.Infof("creating manifest %s", f)
+		if err := util.OperateObjects(c, objects, apiv1.Create); err != nil {
+			logger.Log.Errorf("create manifest %s failed", f)
+			return err
+		}
+
+		for _, obj := range objects {
+			if obj.GetKind() != kindDeployment && obj.GetKind() != kindStatefulSet {
+				continue
+			}
+			namespace := obj.GetNamespace()
+			if namespace == "" {
+				namespace = metav1.NamespaceDefault
+			}
+			workloads = append(workloads, &deployWorkload{kind: obj.GetKind(), namespace: namespace, name: obj.GetName()})
+		}
+	}
+
+	if policy == constant.DeployWaitNone || len(workloads) == 0 {
+		logger.Log.Info("no workload need to wait for in deploy manifests")
+		return nil
+	}
+	return waitWorkloadsReady(c, workloads, timeout)
+}
+
+// resolveDeployManifests expands the glob patterns into the manifest files.
+func resolveDeployManifests(patterns []string) ([]string, error) {
+	files := make([]string, 0)
+	for _, pattern := range patterns {
+		pattern = util.ResolveAbs(os.ExpandEnv(pattern))
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deploy manifest pattern %s: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no deploy manifest matches the pattern %s", pattern)
+		}
+		for _, match := range matches {
+			manifests, err := util.GetManifests(match)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, manifests...)
+		}
+	}
+	return files, nil
+}
+
+// waitWorkloadsReady concurrently waits for the Deployments to be Available and the StatefulSets to be Ready.
+func waitWorkloadsReady(c *util.K8sClusterInfo, workloads []*deployWorkload, timeout time.Duration) error {
+	waitSet := util.NewWaitSet(timeout)
+
+	for _, workload := range workloads {
+		logger.Log.Infof("waiting for %s to be ready", workload)
+
+		switch workload.kind {
+		case kindDeployment:
+			wait := config.Wait{
+				Namespace: workload.namespace,
+				Resource:  fmt.Sprintf("deployment/%s", workload.name),
+				For:       "condition=Available",
+			}
+			options, err := getWaitOptions(c, &wait)
+			if err != nil {
+				return err
+			}
+			waitSet.WaitGroup.Add(1)
+			go concurrentlyWait(&wait, options, waitSet)
+		case kindStatefulSet:
+			waitSet.WaitGroup.Add(1)
+			go func(workload *deployWorkload) {
+				defer waitSet.WaitGroup.Done()
+				if err := waitStatefulSetReady(c, workload, timeout); err != nil {
+					waitSet.ErrChan <- fmt.Errorf("wait for %s ready error: %v", workload, err)
+					return
+				}
+				logger.Log.Infof("%s is ready", workload)
+			}(workload)
+		}
+	}
+
+	go func() {
+		waitSet.WaitGroup.Wait()
+		close(waitSet.FinishChan)
+	}()
+
+	select {
+	case <-waitSet.FinishChan:
+		logger.Log.Infof("deploy manifests and wait for workloads ready success")
+	case err := <-waitSet.ErrChan:
+		logger.Log.Errorf("failed to wait for workloads to be ready")
+		return err
+	case <-time.After(waitSet.Timeout):
+		return &e2eerrors.WaitTimeoutError{Resource: "deploy workloads", Condition: "ready", Timeout: timeout}
+	case <-util.RunContext().Done():
+		return util.RunContext().Err()
+	}
+	return nil
+}
+
+func waitStatefulSetReady(c *util.K8sClusterInfo, workload *deployWorkload, timeout time.Duration) error {
+	return k8swait.PollImmediate(workloadPollInterval, timeout, func() (bool, error) {
+		sts, err := c.Client.AppsV1().StatefulSets(workload.namespace).Get(context.Background(), workload.name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		replicas := int32(1)
+		if sts.Spec.Replicas != nil {
+			replicas = *sts.Spec.Replicas
+		}
+		return sts.Status.ObservedGeneration >= sts.Generation && sts.Status.ReadyReplicas >= replicas, nil
+	})
+}
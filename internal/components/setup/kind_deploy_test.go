@@ -0,0 +1,76 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResolveDeployManifests(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.yaml", "b.yml", "c.txt", "sub/d.yaml"} {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("kind: ConfigMap"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "should expand the glob pattern",
+			patterns: []string{filepath.Join(dir, "*.y*ml")},
+			want:     []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yml")},
+		},
+		{
+			name:     "should walk the directory",
+			patterns: []string{filepath.Join(dir, "sub")},
+			want:     []string{filepath.Join(dir, "sub", "d.yaml")},
+		},
+		{
+			name:     "should fail when nothing matches",
+			patterns: []string{filepath.Join(dir, "*.json")},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDeployManifests(tt.patterns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveDeployManifests() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			sort.Strings(got)
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("resolveDeployManifests() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/kubectl/pkg/polymorphichelpers"
+
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+// forwardCheckInterval is the interval of checking the forwarded pod is still running, and retrying to forward
+// the ports to the current pod after it stopped.
+const forwardCheckInterval = 2 * time.Second
+
+// checkExposeResource checks the ports of the resource could be forwarded, the ports of the pods and the resources with
+// the pod selectors, such as the workloads, jobs and services, are forwarded to one of their pods like `kubectl port-forward`.
+func checkExposeResource(resource string, obj runtime.Object) error {
+	if _, ok := obj.(*v1.Pod); ok {
+		return nil
+	}
+	if _, _, err := polymorphichelpers.SelectorsForObject(obj); err != nil {
+		return fmt.Errorf("unsupported resource %s to expose: %v", resource, err)
+	}
+	return nil
+}
+
+// podForwarder forwards the ports to a pod of the exposed resource, and forwards the same local ports to the current pod
+// of the resource again after the pod stops, such as being replaced by a rolling update or restarted after a crash.
+type podForwarder struct {
+	cluster      *util.K8sClusterInfo
+	client       *rest.RESTClient
+	roundTripper http.RoundTripper
+	upgrader     spdy.Upgrader
+	address      string
+	resource     string
+	object       runtime.Object
+	// waitReady forwards the ports to the ready pods only.
+	waitReady bool
+}
+
+// podForward is the forward of the ports to a pod, it finishes when the stop channel is closed or the connection is lost.
+type podForward struct {
+	pod       *v1.Pod
+	forwarder *portforward.PortForwarder
+	ready     chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	err       error
+	stderr    bytes.Buffer
+}
+
+// start forwards the ports to the pod in background.
+func (f *podForwarder) start(pod *v1.Pod, ports []string) (*podForward, error) {
+	req := f.client.Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(f.upgrader, &http.Client{Transport: f.roundTripper}, http.MethodPost, req.URL())
+
+	forward := &podForward{pod: pod, ready: make(chan struct{}), stop: make(chan struct{}), done: make(chan struct{})}
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{f.address}, ports, forward.stop, forward.ready,
+		io.Discard, &forward.stderr)
+	if err != nil {
+		return nil, err
+	}
+	forward.forwarder = forwarder
+	go func() {
+		defer close(forward.done)
+		forward.err = forwarder.ForwardPorts()
+	}()
+	return forward, nil
+}
+
+// keepForwarding forwards the local ports to the current pod of the resource again whenever the forwarded pod stops,
+// until the stop channel is closed, the ports are in the format of `<local>:<remote>` so that the exported ports are kept.
+func (f *podForwarder) keepForwarding(forward *podForward, ports []string, stop <-chan struct{}) {
+	for {
+		stopped := !f.waitPodStop(forward, stop)
+		close(forward.stop)
+		<-forward.done
+		if stopped {
+			return
+		}
+
+		logger.Log.Warnf("the forwarded pod %s/%s of %s stopped, forwarding the ports to the current pod",
+			forward.pod.Namespace, forward.pod.Name, f.resource)
+		var ok bool
+		if forward, ok = f.restart(ports, stop); !ok {
+			return
+		}
+		logger.Log.Infof("forwarded the ports of %s to the pod %s/%s", f.resource, forward.pod.Namespace, forward.pod.Name)
+	}
+}
+
+// waitPodStop waits until the forwarded pod stops or the connection is lost, returns false if the stop channel is closed.
+func (f *podForwarder) waitPodStop(forward *podForward, stop <-chan struct{}) bool {
+	ticker := time.NewTicker(forwardCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-forward.done:
+			return true
+		case <-ticker.C:
+			gone, err := forwardPodGone(f.cluster.Client, forward.pod)
+			if err != nil {
+				logger.Log.Debugf("failed to check the forwarded pod %s/%s: %v", forward.pod.Namespace, forward.pod.Name, err)
+			} else if gone {
+				return true
+			}
+		}
+	}
+}
+
+// restart forwards the ports to the current running pod of the resource, it retries until succeeded,
+// returns false if the stop channel is closed.
+func (f *podForwarder) restart(ports []string, stop <-chan struct{}) (*podForward, bool) {
+	for {
+		forward, err := f.startCurrent(ports)
+		if err == nil {
+			select {
+			case <-forward.ready:
+				return forward, true
+			case <-forward.done:
+				err = fmt.Errorf("%s: %v", forward.stderr.String(), forward.err)
+			case <-stop:
+				close(forward.stop)
+				<-forward.done
+				return nil, false
+			}
+		}
+		logger.Log.Warnf("failed to forward the ports of %s, retrying: %v", f.resource, err)
+
+		select {
+		case <-stop:
+			return nil, false
+		case <-time.After(forwardCheckInterval):
+		}
+	}
+}
+
+// startCurrent forwards the ports to the current pod of the resource, the pod must be running, or ready if waitReady is set.
+func (f *podForwarder) startCurrent(ports []string) (*podForward, error) {
+	if f.waitReady {
+		pod, err := readyForwardPod(f.cluster.Client, f.object)
+		if err != nil {
+			return nil, err
+		} else if pod == nil {
+			return nil, fmt.Errorf("no pod of %s is ready", f.resource)
+		}
+		return f.start(pod, ports)
+	}
+
+	pod, err := currentForwardPod(f.cluster, f.object, forwardCheckInterval)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+		return nil, fmt.Errorf("the pod %s/%s is not running", pod.Namespace, pod.Name)
+	}
+	return f.start(pod, ports)
+}
+
+// currentForwardPod returns the pod to forward the ports of the resource to, the pod is fetched again if the resource is a pod,
+// otherwise the active pod selected by the workload or the service is returned like `kubectl port-forward`.
+func currentForwardPod(cluster *util.K8sClusterInfo, obj runtime.Object, timeout time.Duration) (*v1.Pod, error) {
+	if pod, ok := obj.(*v1.Pod); ok {
+		return cluster.Client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	}
+	return polymorphichelpers.AttachablePodForObjectFn(cluster, obj, timeout)
+}
+
+// waitReadyForwardPod waits for a ready pod of the resource to forward the ports to, the pods of the workloads and the services
+// are selected by their selectors, fails if there is no ready pod within the timeout.
+func waitReadyForwardPod(client kubernetes.Interface, resource string, obj runtime.Object, timeout time.Duration) (*v1.Pod, error) {
+	var pod *v1.Pod
+	err := k8swait.PollImmediate(workloadPollInterval, timeout, func() (bool, error) {
+		var err error
+		if pod, err = readyForwardPod(client, obj); err != nil {
+			return false, err
+		}
+		if pod == nil {
+			logger.Log.Debugf("waiting for a ready pod of %s to expose", resource)
+		}
+		return pod != nil, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return nil, &e2eerrors.WaitTimeoutError{Resource: resource, Condition: "ready pod to expose", Timeout: timeout}
+	}
+	return pod, err
+}
+
+// readyForwardPod returns a ready pod of the resource, nil if there is none yet.
+func readyForwardPod(client kubernetes.Interface, obj runtime.Object) (*v1.Pod, error) {
+	if pod, ok := obj.(*v1.Pod); ok {
+		current, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if !isPodReady(current) {
+			return nil, nil
+		}
+		return current, nil
+	}
+
+	namespace, selector, err := polymorphichelpers.SelectorsForObject(obj)
+	if err != nil {
+		return nil, fmt.Errorf("cannot expose %T: %v", obj, err)
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if isPodReady(&pods.Items[i]) {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// isPodReady checks the pod is running and ready, and not being deleted.
+func isPodReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// forwardPodGone checks whether the forwarded pod is deleted, terminating, not running or replaced by a new pod of the same name,
+// such as the pods of a StatefulSet.
+func forwardPodGone(client kubernetes.Interface, pod *v1.Pod) (bool, error) {
+	current, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return current.UID != pod.UID || current.DeletionTimestamp != nil || current.Status.Phase != v1.PodRunning, nil
+}
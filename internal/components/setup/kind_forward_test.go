@@ -0,0 +1,165 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckExposeResource(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     runtime.Object
+		wantErr bool
+	}{
+		{name: "pod", obj: &v1.Pod{}},
+		{name: "service", obj: &v1.Service{Spec: v1.ServiceSpec{Selector: map[string]string{"app": "oap"}}}},
+		{name: "deployment", obj: &appsv1.Deployment{}},
+		{name: "statefulset", obj: &appsv1.StatefulSet{}},
+		{name: "daemonset", obj: &appsv1.DaemonSet{}},
+		{name: "replicaset", obj: &appsv1.ReplicaSet{}},
+		{name: "replicationcontroller", obj: &v1.ReplicationController{}},
+		{name: "job", obj: &batchv1.Job{}},
+		{name: "service without selector", obj: &v1.Service{}, wantErr: true},
+		{name: "configmap", obj: &v1.ConfigMap{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkExposeResource(tt.name+"/foo", tt.obj); (err != nil) != tt.wantErr {
+				t.Errorf("checkExposeResource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForwardPodGone(t *testing.T) {
+	forwarded := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "oap-0", Namespace: "default", UID: "1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	now := metav1.Now()
+	tests := []struct {
+		name    string
+		current *v1.Pod
+		want    bool
+	}{
+		{name: "running", current: forwarded, want: false},
+		{name: "deleted", current: nil, want: true},
+		{
+			name: "terminating",
+			current: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "oap-0", Namespace: "default", UID: "1", DeletionTimestamp: &now},
+				Status:     v1.PodStatus{Phase: v1.PodRunning},
+			},
+			want: true,
+		},
+		{
+			name: "recreated with the same name",
+			current: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "oap-0", Namespace: "default", UID: "2"},
+				Status:     v1.PodStatus{Phase: v1.PodRunning},
+			},
+			want: true,
+		},
+		{
+			name: "failed",
+			current: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "oap-0", Namespace: "default", UID: "1"},
+				Status:     v1.PodStatus{Phase: v1.PodFailed},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			if tt.current != nil {
+				client = fake.NewSimpleClientset(tt.current)
+			}
+			got, err := forwardPodGone(client, forwarded)
+			if err != nil {
+				t.Fatalf("forwardPodGone() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("forwardPodGone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadyForwardPod(t *testing.T) {
+	pod := func(name string, phase v1.PodPhase, ready v1.ConditionStatus) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "oap"}},
+			Status: v1.PodStatus{
+				Phase:      phase,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: ready}},
+			},
+		}
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oap", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "oap"}}},
+	}
+	tests := []struct {
+		name    string
+		obj     runtime.Object
+		pods    []runtime.Object
+		wantPod string
+	}{
+		{name: "no pod", obj: deployment},
+		{name: "pod not ready", obj: deployment, pods: []runtime.Object{pod("oap-1", v1.PodRunning, v1.ConditionFalse)}},
+		{name: "pod pending", obj: deployment, pods: []runtime.Object{pod("oap-1", v1.PodPending, v1.ConditionFalse)}},
+		{
+			name:    "one of the pods ready",
+			obj:     deployment,
+			pods:    []runtime.Object{pod("oap-1", v1.PodRunning, v1.ConditionFalse), pod("oap-2", v1.PodRunning, v1.ConditionTrue)},
+			wantPod: "oap-2",
+		},
+		{name: "pod resource not created", obj: pod("oap-1", v1.PodRunning, v1.ConditionTrue)},
+		{
+			name:    "pod resource ready",
+			obj:     pod("oap-1", v1.PodPending, v1.ConditionFalse),
+			pods:    []runtime.Object{pod("oap-1", v1.PodRunning, v1.ConditionTrue)},
+			wantPod: "oap-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readyForwardPod(fake.NewSimpleClientset(tt.pods...), tt.obj)
+			if err != nil {
+				t.Fatalf("readyForwardPod() error = %v", err)
+			}
+			gotPod := ""
+			if got != nil {
+				gotPod = got.Name
+			}
+			if gotPod != tt.wantPod {
+				t.Errorf("readyForwardPod() = %q, want %q", gotPod, tt.wantPod)
+			}
+		})
+	}
+}
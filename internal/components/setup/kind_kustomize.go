@@ -0,0 +1,63 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	apiv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// createKustomizationAndWait renders the kustomization directory, creates the rendered resources and waits for the conditions.
+func createKustomizationAndWait(c *util.K8sClusterInfo, dir string, waits []config.Wait, timeout time.Duration) error {
+	start := time.Now()
+	objects, err := buildKustomization(util.ResolveAbs(os.ExpandEnv(dir)))
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Infof("creating the %d resources of kustomization %s", len(objects), dir)
+	if err := util.OperateObjects(c, objects, apiv1.Create); err != nil {
+		logger.Log.Errorf("create kustomization %s failed", dir)
+		return err
+	}
+	return concurrentlyWaitAll(c, waits, "kustomization", NewTimeout(start, timeout))
+}
+
+// buildKustomization renders the kustomization directory like `kustomize build`, and decodes the rendered resources.
+func buildKustomization(dir string) ([]*unstructured.Unstructured, error) {
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the kustomization %s: %v", dir, err)
+	}
+	rendered, err := resources.AsYaml()
+	if err != nil {
+		return nil, err
+	}
+	return util.DecodeObjects(rendered)
+}
@@ -0,0 +1,49 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildKustomization(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"kustomization.yaml": "namePrefix: e2e-\nresources:\n  - configmap.yaml\n",
+		"configmap.yaml":     "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: oap\ndata:\n  endpoint: oap:12800\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objects, err := buildKustomization(dir)
+	if err != nil {
+		t.Fatalf("buildKustomization() error = %v", err)
+	}
+	if len(objects) != 1 || objects[0].GetKind() != "ConfigMap" || objects[0].GetName() != "e2e-oap" {
+		t.Errorf("buildKustomization() = %v, want the ConfigMap e2e-oap", objects)
+	}
+
+	if _, err := buildKustomization(t.TempDir()); err == nil {
+		t.Errorf("buildKustomization() should fail without the kustomization file")
+	}
+}
@@ -0,0 +1,154 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+// exposeNodePortService exports the host ports mapped to the NodePorts of the service by the `extraPortMappings`
+// of the kind config, instead of forwarding the ports, the service without the NodePorts assigned yet is waited for.
+func exposeNodePortService(port config.KindExposePort, timeout time.Duration, cluster *util.K8sClusterInfo) error {
+	if kindConfigPath == "" {
+		return fmt.Errorf("node-port mode of %s needs the kind config to find the host ports", port.Resource)
+	}
+	mappings, err := util.GetKindPortMappings(kindConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the port mappings of the kind config %s: %v", kindConfigPath, err)
+	}
+	name, err := nodePortServiceName(port.Resource)
+	if err != nil {
+		return err
+	}
+	namespace := port.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	var service *v1.Service
+	err = k8swait.PollImmediate(workloadPollInterval, timeout, func() (bool, error) {
+		service, err = cluster.Client.CoreV1().Services(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			logger.Log.Debugf("waiting for service %s/%s to be created", namespace, name)
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if service.Spec.Type != v1.ServiceTypeNodePort && service.Spec.Type != v1.ServiceTypeLoadBalancer {
+			return false, fmt.Errorf("service %s/%s is %s rather than NodePort", namespace, name, service.Spec.Type)
+		}
+		return hasNodePorts(service), nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{Resource: fmt.Sprintf("service %s/%s", namespace, name), Condition: "node-port", Timeout: timeout}
+	} else if err != nil {
+		return err
+	}
+
+	// format: <resource>_host and <resource>_<port>
+	resourceName := envResourceName(port.Resource)
+	for i, p := range strings.Split(port.Port, ",") {
+		host, hostPort, err := nodePortHostPort(service, p, mappings)
+		if err != nil {
+			return err
+		}
+		if port.Ready != nil {
+			if err := probeForwardedPort(port.Ready, host, uint16(hostPort), timeout); err != nil {
+				return err
+			}
+		}
+		if i == 0 {
+			if err := exportKindEnv(fmt.Sprintf("%s_host", resourceName), hostForURL(host), port.Resource); err != nil {
+				return err
+			}
+		}
+		if err := exportKindEnv(fmt.Sprintf("%s_%s", resourceName, p), strconv.Itoa(int(hostPort)), port.Resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodePortServiceName returns the name of the service resource, such as `foo` of `service/foo`.
+func nodePortServiceName(resource string) (string, error) {
+	kind, name, found := strings.Cut(resource, "/")
+	switch strings.ToLower(kind) {
+	case "service", "services", "svc":
+		if found && name != "" {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("node-port mode only supports a named service, such as service/foo, but got %s", resource)
+}
+
+// hasNodePorts checks the NodePorts of all the ports of the service are assigned.
+func hasNodePorts(service *v1.Service) bool {
+	for _, p := range service.Spec.Ports {
+		if p.NodePort == 0 {
+			return false
+		}
+	}
+	return len(service.Spec.Ports) > 0
+}
+
+// nodePortHostPort finds the host address and port mapped to the NodePort of the service port, which is the number or the name
+// of the service port. The host is the listen address of the mapping, the wildcard addresses are reachable by localhost.
+func nodePortHostPort(service *v1.Service, port string, mappings []v1alpha4.PortMapping) (host string, hostPort int32, err error) {
+	var nodePort int32
+	for _, p := range service.Spec.Ports {
+		if p.Name == port || strconv.Itoa(int(p.Port)) == port {
+			nodePort = p.NodePort
+			break
+		}
+	}
+	if nodePort == 0 {
+		return "", 0, fmt.Errorf("the port %s of service %s/%s is not found or has no NodePort", port, service.Namespace, service.Name)
+	}
+
+	for _, m := range mappings {
+		if m.ContainerPort != nodePort {
+			continue
+		}
+		if m.HostPort <= 0 {
+			return "", 0, fmt.Errorf("the hostPort mapped to the NodePort %d should be fixed in the kind config", nodePort)
+		}
+		host = m.ListenAddress
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = defaultForwardAddress
+		}
+		return host, m.HostPort, nil
+	}
+	return "", 0, fmt.Errorf("the NodePort %d of service %s/%s is not mapped to the host by extraPortMappings of the kind config",
+		nodePort, service.Namespace, service.Name)
+}
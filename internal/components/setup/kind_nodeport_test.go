@@ -0,0 +1,112 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+func TestNodePortHostPort(t *testing.T) {
+	kindConfig := filepath.Join(t.TempDir(), "kind.yaml")
+	if err := os.WriteFile(kindConfig, []byte(`
+kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+nodes:
+  - role: control-plane
+    extraPortMappings:
+      - containerPort: 30080
+        hostPort: 8080
+      - containerPort: 30443
+        hostPort: 8443
+        listenAddress: 127.0.0.2
+      - containerPort: 30090
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := util.GetKindPortMappings(kindConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default"},
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeNodePort,
+			Ports: []v1.ServicePort{
+				{Name: "http", Port: 80, NodePort: 30080},
+				{Name: "https", Port: 443, NodePort: 30443},
+				{Name: "metrics", Port: 9090, NodePort: 30090},
+				{Name: "admin", Port: 9000, NodePort: 30000},
+			},
+		},
+	}
+	tests := []struct {
+		port     string
+		wantHost string
+		wantPort int32
+		wantErr  bool
+	}{
+		{port: "80", wantHost: "localhost", wantPort: 8080},
+		{port: "http", wantHost: "localhost", wantPort: 8080},
+		{port: "https", wantHost: "127.0.0.2", wantPort: 8443},
+		{port: "metrics", wantErr: true},
+		{port: "admin", wantErr: true},
+		{port: "8080", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.port, func(t *testing.T) {
+			host, port, err := nodePortHostPort(service, tt.port, mappings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nodePortHostPort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("nodePortHostPort() = %s:%d, want %s:%d", host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestHasNodePorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		service v1.ServiceSpec
+		want    bool
+	}{
+		{name: "should be true when all the NodePorts are assigned", service: v1.ServiceSpec{
+			Type: v1.ServiceTypeNodePort, Ports: []v1.ServicePort{{Port: 80, NodePort: 30080}},
+		}, want: true},
+		{name: "should be false when any NodePort is not assigned", service: v1.ServiceSpec{
+			Type: v1.ServiceTypeNodePort, Ports: []v1.ServicePort{{Port: 80, NodePort: 30080}, {Port: 443}},
+		}},
+		{name: "should be false when there is no port", service: v1.ServiceSpec{Type: v1.ServiceTypeNodePort}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasNodePorts(&v1.Service{Spec: tt.service}); got != tt.want {
+				t.Errorf("hasNodePorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// manifestPatchTransform builds the transform setting the fields of the patches to the matching objects in order.
+func manifestPatchTransform(patches []config.ManifestPatch) util.ObjectTransform {
+	return func(obj *unstructured.Unstructured) error {
+		for i := range patches {
+			patch := &patches[i]
+			if (patch.Kind != "" && patch.Kind != obj.GetKind()) || (patch.Name != "" && patch.Name != obj.GetName()) {
+				continue
+			}
+			value := patch.Value
+			if s, ok := value.(string); ok {
+				value = os.ExpandEnv(s)
+			}
+			if err := util.SetField(obj.Object, patch.Path, value); err != nil {
+				return fmt.Errorf("failed to patch %s: %v", patch.Path, err)
+			}
+		}
+		return nil
+	}
+}
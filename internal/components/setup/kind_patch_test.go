@@ -0,0 +1,74 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+func TestManifestPatchTransform(t *testing.T) {
+	t.Setenv("IMAGE", "oap:abc123")
+	transform := manifestPatchTransform([]config.ManifestPatch{
+		{Kind: "Deployment", Name: "oap", Path: "spec.template.spec.containers[0].image", Value: "${IMAGE}"},
+		{Kind: "Deployment", Path: "spec.replicas", Value: 2},
+		{Kind: "Service", Path: "spec.type", Value: "NodePort"},
+	})
+	newDeployment := func(name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"template": map[string]any{
+					"spec": map[string]any{"containers": []any{map[string]any{"name": name, "image": name + ":latest"}}},
+				},
+			},
+		}}
+		obj.SetKind("Deployment")
+		obj.SetName(name)
+		return obj
+	}
+
+	tests := []struct {
+		name         string
+		obj          *unstructured.Unstructured
+		wantImage    string
+		wantReplicas int64
+	}{
+		{name: "oap", obj: newDeployment("oap"), wantImage: "oap:abc123", wantReplicas: 2},
+		{name: "ui", obj: newDeployment("ui"), wantImage: "ui:latest", wantReplicas: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := transform(tt.obj); err != nil {
+				t.Fatal(err)
+			}
+			containers, _, _ := unstructured.NestedSlice(tt.obj.Object, "spec", "template", "spec", "containers")
+			if image := containers[0].(map[string]any)["image"]; image != tt.wantImage {
+				t.Errorf("image = %v, want %v", image, tt.wantImage)
+			}
+			if replicas, _, _ := unstructured.NestedInt64(tt.obj.Object, "spec", "replicas"); replicas != tt.wantReplicas {
+				t.Errorf("replicas = %v, want %v", replicas, tt.wantReplicas)
+			}
+			if _, found, _ := unstructured.NestedString(tt.obj.Object, "spec", "type"); found {
+				t.Errorf("the patch of the Service should not be applied to the Deployment")
+			}
+		})
+	}
+}
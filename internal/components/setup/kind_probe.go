@@ -0,0 +1,107 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+const (
+	probeInterval    = 500 * time.Millisecond
+	probeDialTimeout = time.Second
+	// probeReadTimeout is how long the TCP probe waits for the forwarder to close the connection,
+	// the forwarder closes it immediately when the backend refuses the connection.
+	probeReadTimeout = 500 * time.Millisecond
+)
+
+// probeForwardedPort probes the forwarded local port until the backend actually serves,
+// the port-forward accepts the local connections even if the backend is not listening yet.
+func probeForwardedPort(ready *config.KindExposeReady, host string, localPort uint16, timeout time.Duration) error {
+	if ready.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(ready.Timeout); err != nil {
+			return fmt.Errorf("failed to parse the ready timeout: %v", err)
+		}
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(int(localPort)))
+	probe := probeTCP
+	if ready.Type == constant.ExposeReadyHTTP {
+		probe = func(address string) error {
+			return probeHTTP(address, ready.Path)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := probe(address)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the forwarded port %s is not ready after %v: %v", address, timeout, err)
+		}
+		logger.Log.Debugf("the forwarded port %s is not ready: %v", address, err)
+		if err := util.Sleep(probeInterval); err != nil {
+			return err
+		}
+	}
+}
+
+func probeTCP(address string) error {
+	conn, err := net.DialTimeout("tcp", address, probeDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetReadDeadline(time.Now().Add(probeReadTimeout)); err != nil {
+		return err
+	}
+	// the connection kept open or the data sent by the backend means it's serving
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("the connection is closed: %v", err)
+	}
+	return nil
+}
+
+func probeHTTP(address, path string) error {
+	client := http.Client{Timeout: probeDialTimeout}
+	resp, err := client.Get(fmt.Sprintf("http://%s%s", address, path))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProbeTCP(t *testing.T) {
+	tests := []struct {
+		name    string
+		handle  func(conn net.Conn)
+		wantErr bool
+	}{
+		{
+			name:   "should be ready when the connection is kept open",
+			handle: func(conn net.Conn) {},
+		},
+		{
+			name: "should be ready when the backend sends data",
+			handle: func(conn net.Conn) {
+				_, _ = conn.Write([]byte("hello"))
+			},
+		},
+		{
+			name: "should not be ready when the connection is closed",
+			handle: func(conn net.Conn) {
+				_ = conn.Close()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					tt.handle(conn)
+				}
+			}()
+
+			if err := probeTCP(listener.Addr().String()); (err != nil) != tt.wantErr {
+				t.Errorf("probeTCP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "should be ready when the status is ok", path: "/healthz"},
+		{name: "should not be ready when the status is unavailable", path: "/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := probeHTTP(address, tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("probeHTTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+const (
+	// the phases of the KinD setup reported on failure
+	phaseSetup       = "setup"
+	phaseExposeLogs  = "expose-logs"
+	phaseExposePorts = "expose-ports"
+
+	// maxReportEvents is the max number of the most recent events in the failure report
+	maxReportEvents = 50
+	reportTimeout   = 30 * time.Second
+)
+
+// failureReport is the snapshot of the cluster when the setup fails, so that the flaky failures could be classified automatically.
+type failureReport struct {
+	Environment string        `yaml:"environment,omitempty"`
+	Phase       string        `yaml:"phase"`
+	Step        string        `yaml:"step,omitempty"`
+	Resource    string        `yaml:"resource,omitempty"`
+	Condition   string        `yaml:"condition,omitempty"`
+	Timeout     bool          `yaml:"timeout"`
+	Error       string        `yaml:"error"`
+	Events      []reportEvent `yaml:"events"`
+	Pods        []reportPod   `yaml:"pods"`
+}
+
+type reportEvent struct {
+	Time      string `yaml:"time"`
+	Namespace string `yaml:"namespace"`
+	Object    string `yaml:"object"`
+	Type      string `yaml:"type"`
+	Reason    string `yaml:"reason"`
+	Message   string `yaml:"message"`
+	Count     int32  `yaml:"count"`
+}
+
+type reportPod struct {
+	Namespace  string            `yaml:"namespace"`
+	Name       string            `yaml:"name"`
+	Phase      string            `yaml:"phase"`
+	Containers []reportContainer `yaml:"containers"`
+}
+
+type reportContainer struct {
+	Name     string `yaml:"name"`
+	Ready    bool   `yaml:"ready"`
+	Restarts int32  `yaml:"restarts"`
+	State    string `yaml:"state"`
+}
+
+// writeFailureReport writes the failure report of the setup to the work dir, the failure of writing the report is only logged.
+func writeFailureReport(client kubernetes.Interface, phase string, setupErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+
+	report := buildFailureReport(ctx, client, phase, setupErr)
+	data, err := yaml.Marshal(report)
+	if err != nil {
+		logger.Log.Warnf("failed to marshal the setup failure report: %v", err)
+		return
+	}
+
+	name := constant.SetupFailureReportFile
+	if currentEnvironment != "" {
+		name = fmt.Sprintf("%s-%s", currentEnvironment, name)
+	}
+	path := filepath.Join(util.WorkDir, name)
+	if err := os.MkdirAll(util.WorkDir, os.ModePerm); err != nil {
+		logger.Log.Warnf("failed to create the work dir %s: %v", util.WorkDir, err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		logger.Log.Warnf("failed to write the setup failure report %s: %v", path, err)
+		return
+	}
+	logger.Log.Infof("the setup failure report is written to %s", path)
+}
+
+// buildFailureReport collects the failing step or wait from the error, and the recent events and the pod statuses of the cluster.
+func buildFailureReport(ctx context.Context, client kubernetes.Interface, phase string, setupErr error) *failureReport {
+	report := &failureReport{
+		Environment: currentEnvironment,
+		Phase:       phase,
+		Error:       setupErr.Error(),
+		Events:      make([]reportEvent, 0),
+		Pods:        make([]reportPod, 0),
+	}
+	var stepErr *e2eerrors.StepError
+	if errors.As(setupErr, &stepErr) {
+		report.Step = stepErr.Step
+	}
+	var timeoutErr *e2eerrors.WaitTimeoutError
+	if errors.As(setupErr, &timeoutErr) {
+		report.Timeout = true
+		report.Resource = timeoutErr.Resource
+		report.Condition = timeoutErr.Condition
+	}
+
+	if events, err := client.CoreV1().Events(metav1.NamespaceAll).List(ctx, metav1.ListOptions{}); err != nil {
+		logger.Log.Warnf("failed to list the events for the setup failure report: %v", err)
+	} else {
+		report.Events = recentEvents(events.Items, maxReportEvents)
+	}
+	if pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{}); err != nil {
+		logger.Log.Warnf("failed to list the pods for the setup failure report: %v", err)
+	} else {
+		for i := range pods.Items {
+			report.Pods = append(report.Pods, podStatus(&pods.Items[i]))
+		}
+	}
+	return report
+}
+
+// recentEvents returns the most recent events in the order of time, like `kubectl get events --sort-by=.lastTimestamp`.
+func recentEvents(events []corev1.Event, limit int) []reportEvent {
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(&events[i]).Before(eventTime(&events[j]))
+	})
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+
+	result := make([]reportEvent, 0, len(events))
+	for i := range events {
+		event := &events[i]
+		result = append(result, reportEvent{
+			Time:      eventTime(event).Format(time.RFC3339),
+			Namespace: event.Namespace,
+			Object:    fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name),
+			Type:      event.Type,
+			Reason:    event.Reason,
+			Message:   event.Message,
+			Count:     event.Count,
+		})
+	}
+	return result
+}
+
+// eventTime returns the last time the event occurred, the events of the new API only have the event time.
+func eventTime(event *corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	} else if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+func podStatus(pod *corev1.Pod) reportPod {
+	result := reportPod{Namespace: pod.Namespace, Name: pod.Name, Phase: string(pod.Status.Phase), Containers: make([]reportContainer, 0)}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for i := range statuses {
+		status := &statuses[i]
+		result.Containers = append(result.Containers, reportContainer{
+			Name:     status.Name,
+			Ready:    status.Ready,
+			Restarts: status.RestartCount,
+			State:    containerState(&status.State),
+		})
+	}
+	return result
+}
+
+// containerState formats the state of the container like the status column of `kubectl get pods`.
+func containerState(state *corev1.ContainerState) string {
+	switch {
+	case state.Waiting != nil:
+		return fmt.Sprintf("Waiting(%s)", state.Waiting.Reason)
+	case state.Terminated != nil:
+		return fmt.Sprintf("Terminated(%s, exit code: %d)", state.Terminated.Reason, state.Terminated.ExitCode)
+	case state.Running != nil:
+		return "Running"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,90 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+func newEvent(name, reason string, last time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "oap"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		LastTimestamp:  metav1.NewTime(last),
+	}
+}
+
+func TestBuildFailureReport(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	client := fake.NewSimpleClientset(
+		newEvent("pulled", "Pulled", now.Add(-time.Minute)),
+		newEvent("backoff", "BackOff", now),
+		newPodWithContainer("oap", corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: crashLoopBackOff}}, 3),
+	)
+	setupErr := &e2eerrors.StepError{
+		Step: "deploy oap",
+		Err:  &e2eerrors.WaitTimeoutError{Resource: "pod/oap", Condition: "ready", Timeout: time.Minute},
+	}
+
+	report := buildFailureReport(context.Background(), client, phaseSetup, fmt.Errorf("setup failed: %w", setupErr))
+	want := &failureReport{
+		Phase:     phaseSetup,
+		Step:      "deploy oap",
+		Resource:  "pod/oap",
+		Condition: "ready",
+		Timeout:   true,
+		Error:     "setup failed: step [deploy oap]: wait for pod/oap ready timeout after 60 seconds",
+		Events: []reportEvent{
+			{Time: now.Add(-time.Minute).Format(time.RFC3339), Namespace: "default", Object: "Pod/oap", Type: "Warning", Reason: "Pulled"},
+			{Time: now.Format(time.RFC3339), Namespace: "default", Object: "Pod/oap", Type: "Warning", Reason: "BackOff"},
+		},
+		Pods: []reportPod{{
+			Namespace:  "default",
+			Name:       "oap",
+			Containers: []reportContainer{{Name: "app", Restarts: 3, State: "Waiting(CrashLoopBackOff)"}},
+		}},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("buildFailureReport() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecentEvents(t *testing.T) {
+	now := time.Now()
+	events := []corev1.Event{
+		*newEvent("second", "Second", now.Add(-time.Minute)),
+		*newEvent("third", "Third", now),
+		*newEvent("first", "First", now.Add(-time.Hour)),
+	}
+	got := recentEvents(events, 2)
+	if len(got) != 2 || got[0].Reason != "Second" || got[1].Reason != "Third" {
+		t.Errorf("recentEvents() = %+v, want the events Second and Third", got)
+	}
+}
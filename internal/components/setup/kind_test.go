@@ -0,0 +1,152 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+func TestManifestFileWaits(t *testing.T) {
+	dir := t.TempDir()
+	operator, crs := filepath.Join(dir, "operator.yaml"), filepath.Join(dir, "crs.yaml")
+	files := []string{operator, crs}
+	ready := config.Wait{Namespace: "operator-system", Resource: "deployment/operator", For: "condition=Available"}
+
+	tests := []struct {
+		name      string
+		fileWaits []config.FileWait
+		want      map[string][]config.Wait
+		wantErr   bool
+	}{
+		{name: "should have no waits by default", want: map[string][]config.Wait{}},
+		{
+			name:      "should group the waits by the file",
+			fileWaits: []config.FileWait{{File: operator, Waits: []config.Wait{ready}}},
+			want:      map[string][]config.Wait{operator: {ready}},
+		},
+		{
+			name:      "should fail when the file is not one of the manifests",
+			fileWaits: []config.FileWait{{File: filepath.Join(dir, "other.yaml"), Waits: []config.Wait{ready}}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := manifestFileWaits(files, tt.fileWaits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("manifestFileWaits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("manifestFileWaits() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestStepExposePorts(t *testing.T) {
+	steps := []config.Step{{Name: "install"}, {Name: "seed data"}}
+	admin := config.KindExposePort{Resource: "service/admin", Port: "8080", Before: "seed data"}
+	oap := config.KindExposePort{Resource: "service/oap", Port: "12800"}
+
+	tests := []struct {
+		name       string
+		exposes    []config.KindExposePort
+		wantBefore map[string][]config.KindExposePort
+		wantAfter  []config.KindExposePort
+		wantErr    bool
+	}{
+		{
+			name:       "should expose the ports after the steps by default",
+			exposes:    []config.KindExposePort{oap},
+			wantBefore: map[string][]config.KindExposePort{},
+			wantAfter:  []config.KindExposePort{oap},
+		},
+		{
+			name:       "should group the ports by the step",
+			exposes:    []config.KindExposePort{admin, oap},
+			wantBefore: map[string][]config.KindExposePort{"seed data": {admin}},
+			wantAfter:  []config.KindExposePort{oap},
+		},
+		{
+			name:    "should fail when the step is not found",
+			exposes: []config.KindExposePort{{Resource: "service/admin", Port: "8080", Before: "other"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after, err := stepExposePorts(steps, tt.exposes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("stepExposePorts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !cmp.Equal(before, tt.wantBefore) {
+				t.Errorf("stepExposePorts() before mismatch (-want +got):\n%s", cmp.Diff(tt.wantBefore, before))
+			}
+			if !cmp.Equal(after, tt.wantAfter) {
+				t.Errorf("stepExposePorts() after mismatch (-want +got):\n%s", cmp.Diff(tt.wantAfter, after))
+			}
+		})
+	}
+}
+
+func TestRestoreKubeConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		set    bool
+	}{
+		{name: "the existing KUBECONFIG is restored", before: "/home/user/.kube/config", set: true},
+		{name: "the KUBECONFIG not set is unset again"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", "")
+			if tt.set {
+				os.Setenv("KUBECONFIG", tt.before)
+			} else {
+				os.Unsetenv("KUBECONFIG")
+			}
+
+			if err := exportKubeConfig("/tmp/e2e-k8s.config"); err != nil {
+				t.Fatal(err)
+			}
+			if got := os.Getenv("KUBECONFIG"); got != "/tmp/e2e-k8s.config" {
+				t.Errorf("KUBECONFIG = %s during the run, want /tmp/e2e-k8s.config", got)
+			}
+			// the kubeconfig of the second environment doesn't replace the saved one
+			if err := exportKubeConfig("/tmp/e2e-k8s-2.config"); err != nil {
+				t.Fatal(err)
+			}
+
+			RestoreKubeConfig()
+			got, exists := os.LookupEnv("KUBECONFIG")
+			if exists != tt.set || got != tt.before {
+				t.Errorf("KUBECONFIG = %q, %v after the cleanup, want %q, %v", got, exists, tt.before, tt.set)
+			}
+		})
+	}
+}
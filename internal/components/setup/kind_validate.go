@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// setupManifest is a manifest file or a kustomization directory applied by the setup.
+type setupManifest struct {
+	path      string
+	kustomize bool
+}
+
+// objects decodes the manifest file or renders the kustomization directory.
+func (m *setupManifest) objects() ([]*unstructured.Unstructured, error) {
+	if m.kustomize {
+		return buildKustomization(m.path)
+	}
+	return util.DecodeManifest(m.path)
+}
+
+// validateManifests validates the manifests of the deploy and the steps by the server-side dry-run before applying any of them,
+// so that an invalid manifest fails the setup without the half-applied resources. All the invalid objects are reported together.
+func validateManifests(c *util.K8sClusterInfo, s *config.Setup) error {
+	manifests, err := setupManifests(&s.Kind.Deploy, s.Steps)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range manifests {
+		objects, err := manifests[i].objects()
+		if err != nil {
+			return err
+		}
+		logger.Log.Infof("validating manifest %s", manifests[i].path)
+		for _, obj := range objects {
+			err := util.ValidateObjects(c, []*unstructured.Unstructured{obj})
+			if err == nil {
+				continue
+			}
+			// the CRDs and the namespaces may be created by the manifests applied before, which are not created in the dry-run
+			if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+				logger.Log.Debugf("skip validating %s %s of manifest %s, which depends on the resources not created yet: %v",
+					obj.GetKind(), obj.GetName(), manifests[i].path, err)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s %s of manifest %s is invalid: %v", obj.GetKind(), obj.GetName(), manifests[i].path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setupManifests returns the manifest files of the deploy and the manifest files or the kustomization directories
+// of the steps in the applying order, the step paths not existing yet, such as the ones generated by the previous steps, are skipped.
+func setupManifests(deploy *config.KindDeploy, steps []config.Step) ([]setupManifest, error) {
+	manifests := make([]setupManifest, 0)
+	if len(deploy.Manifests) > 0 {
+		deployFiles, err := resolveDeployManifests(deploy.Manifests)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range deployFiles {
+			manifests = append(manifests, setupManifest{path: f})
+		}
+	}
+	for i := range steps {
+		if steps[i].Kustomize != "" {
+			dir := util.ResolveAbs(os.ExpandEnv(steps[i].Kustomize))
+			if _, err := os.Stat(dir); err != nil {
+				logger.Log.Warnf("skip the kustomization of step [%s]: %v", steps[i].Name, err)
+				continue
+			}
+			manifests = append(manifests, setupManifest{path: dir, kustomize: true})
+			continue
+		}
+		if steps[i].Path == "" {
+			continue
+		}
+		stepFiles, err := util.GetManifests(steps[i].Path)
+		if err != nil {
+			logger.Log.Warnf("skip the manifests of step [%s]: %v", steps[i].Name, err)
+			continue
+		}
+		for _, f := range stepFiles {
+			manifests = append(manifests, setupManifest{path: f})
+		}
+	}
+	return manifests, nil
+}
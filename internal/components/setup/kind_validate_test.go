@@ -0,0 +1,77 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+func TestSetupManifests(t *testing.T) {
+	dir := t.TempDir()
+	deploy, step, overlay := filepath.Join(dir, "deploy.yaml"), filepath.Join(dir, "step.yaml"), filepath.Join(dir, "overlay")
+	if err := os.Mkdir(overlay, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{deploy, step} {
+		if err := os.WriteFile(f, []byte("kind: ConfigMap"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		deploy  config.KindDeploy
+		steps   []config.Step
+		want    []setupManifest
+		wantErr bool
+	}{
+		{
+			name:   "should list the deploy manifests before the step manifests",
+			deploy: config.KindDeploy{Manifests: []string{deploy}},
+			steps:  []config.Step{{Name: "command", Command: "echo"}, {Name: "apply", Path: step}, {Name: "overlay", Kustomize: overlay}},
+			want:   []setupManifest{{path: deploy}, {path: step}, {path: overlay, kustomize: true}},
+		},
+		{
+			name:  "should skip the step manifests not existing yet",
+			steps: []config.Step{{Name: "generated", Path: filepath.Join(dir, "generated.yaml")}, {Name: "apply", Path: step}},
+			want:  []setupManifest{{path: step}},
+		},
+		{
+			name:    "should fail when the deploy manifests are not found",
+			deploy:  config.KindDeploy{Manifests: []string{filepath.Join(dir, "*.json")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setupManifests(&tt.deploy, tt.steps)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setupManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want, cmp.AllowUnexported(setupManifest{})) {
+				t.Errorf("setupManifests() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got, cmp.AllowUnexported(setupManifest{})))
+			}
+		})
+	}
+}
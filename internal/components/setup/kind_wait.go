@@ -0,0 +1,1256 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+	"github.com/apache/skywalking-infra-e2e/pkg/e2eerrors"
+)
+
+// waiter waits until the condition of a wait block is met,
+// the kubectl wait options is used when the condition is supported by `kubectl wait`.
+type waiter interface {
+	RunWait() error
+}
+
+// getWaiter builds the waiter according to the wait condition.
+func getWaiter(cluster *util.K8sClusterInfo, wait *config.Wait) (waiter, error) {
+	pollInterval, err := parsePollInterval(wait)
+	if err != nil {
+		return nil, err
+	}
+	switch wait.For {
+	case constant.WaitForRollout:
+		return newRolloutWaiter(cluster, wait, pollInterval)
+	case constant.WaitForBound:
+		return newPVCBoundWaiter(cluster, wait, pollInterval)
+	case constant.WaitForExec:
+		return newExecWaiter(cluster, wait, pollInterval)
+	case constant.WaitForLoadBalancer:
+		return newLoadBalancerWaiter(cluster, wait, pollInterval)
+	case constant.WaitForAbsent:
+		return newAbsentWaiter(cluster, wait, pollInterval)
+	}
+	if strings.HasPrefix(wait.For, constant.WaitForJSONPath) {
+		return newJSONPathWaiter(cluster, wait, pollInterval)
+	}
+	if strings.HasPrefix(wait.For, constant.WaitForKey) {
+		return newKeyWaiter(cluster, wait, pollInterval)
+	}
+	if strings.HasPrefix(wait.For, constant.WaitForEvent) {
+		return newEventWaiter(cluster, wait, pollInterval)
+	}
+	if strings.HasPrefix(wait.For, constant.WaitForLog) {
+		return newLogWaiter(cluster, wait, pollInterval)
+	}
+	if wait.PollInterval != "" {
+		logger.Log.Warnf("poll-interval is ignored by the condition %s which is waited by kubectl", wait.For)
+	}
+	return getWaitOptions(cluster, wait)
+}
+
+// parsePollInterval parses the poll interval of the wait block, defaults to workloadPollInterval.
+func parsePollInterval(wait *config.Wait) (time.Duration, error) {
+	if wait.PollInterval == "" {
+		return workloadPollInterval, nil
+	}
+	interval, err := time.ParseDuration(wait.PollInterval)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse poll-interval %s: %v", wait.PollInterval, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("poll-interval should be > 0, but was %s", wait.PollInterval)
+	}
+	return interval, nil
+}
+
+// rolloutWaiter waits for the rollout of the workloads to be complete, mirrors `kubectl rollout status`.
+type rolloutWaiter struct {
+	client        kubernetes.Interface
+	namespace     string
+	kind          string
+	name          string
+	labelSelector string
+	pollInterval  time.Duration
+}
+
+func newRolloutWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*rolloutWaiter, error) {
+	kind, name, err := parseWaitResource(wait)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindDeployment && kind != kindStatefulSet {
+		return nil, fmt.Errorf("rollout wait only supports deployment and statefulset, but got %s", wait.Resource)
+	}
+
+	return &rolloutWaiter{
+		client:        cluster.Client,
+		namespace:     waitNamespace(wait),
+		kind:          kind,
+		name:          name,
+		labelSelector: wait.LabelSelector,
+		pollInterval:  pollInterval,
+	}, nil
+}
+
+func (w *rolloutWaiter) RunWait() error {
+	var msg string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (done bool, err error) {
+		msg, done, err = w.rolloutStatus()
+		if err != nil {
+			return false, err
+		}
+		if !done {
+			logger.Log.Debugf("%s", msg)
+		}
+		return done, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		logger.Log.Errorf("%s", msg)
+		resource := fmt.Sprintf("%s %s/%s", w.kind, w.namespace, w.name)
+		if w.name == "" {
+			resource = fmt.Sprintf("%s in %s with label selector %q", w.kind, namespaceScope(w.namespace), w.labelSelector)
+		}
+		return &e2eerrors.WaitTimeoutError{Resource: resource, Condition: "rollout", Timeout: constant.SingleDefaultWaitTimeout}
+	}
+	return err
+}
+
+// rolloutStatus checks the rollout of all the matching workloads, the workloads not created yet are treated as not done.
+func (w *rolloutWaiter) rolloutStatus() (msg string, done bool, err error) {
+	var statuses []func() (string, bool, error)
+	switch w.kind {
+	case kindDeployment:
+		deployments, err := w.listDeployments()
+		if err != nil {
+			return "", false, err
+		}
+		for i := range deployments {
+			deployment := &deployments[i]
+			statuses = append(statuses, func() (string, bool, error) { return deploymentRolloutStatus(deployment) })
+		}
+	case kindStatefulSet:
+		statefulSets, err := w.listStatefulSets()
+		if err != nil {
+			return "", false, err
+		}
+		for i := range statefulSets {
+			sts := &statefulSets[i]
+			statuses = append(statuses, func() (string, bool, error) { return statefulSetRolloutStatus(sts) })
+		}
+	}
+
+	if len(statuses) == 0 {
+		return fmt.Sprintf("waiting for matching %s to be created in %s", w.kind, namespaceScope(w.namespace)), false, nil
+	}
+	for _, status := range statuses {
+		if msg, done, err = status(); err != nil || !done {
+			return msg, done, err
+		}
+	}
+	return msg, true, nil
+}
+
+func (w *rolloutWaiter) listDeployments() ([]appsv1.Deployment, error) {
+	deployments := w.client.AppsV1().Deployments(w.namespace)
+	if w.name != "" {
+		deployment, err := deployments.Get(context.Background(), w.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return []appsv1.Deployment{*deployment}, nil
+	}
+
+	list, err := deployments.List(context.Background(), metav1.ListOptions{LabelSelector: w.labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (w *rolloutWaiter) listStatefulSets() ([]appsv1.StatefulSet, error) {
+	statefulSets := w.client.AppsV1().StatefulSets(w.namespace)
+	if w.name != "" {
+		sts, err := statefulSets.Get(context.Background(), w.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return []appsv1.StatefulSet{*sts}, nil
+	}
+
+	list, err := statefulSets.List(context.Background(), metav1.ListOptions{LabelSelector: w.labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// deploymentRolloutStatus checks the generation has been observed by the controller, and all the replicas
+// are updated and available, returns the waiting message if the rollout is not complete.
+func deploymentRolloutStatus(deployment *appsv1.Deployment) (msg string, done bool, err error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return fmt.Sprintf("waiting for deployment %q spec update to be observed", deployment.Name), false, nil
+	}
+
+	for _, c := range deployment.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return "", false, fmt.Errorf("deployment %q exceeded its progress deadline", deployment.Name)
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	if status.UpdatedReplicas < replicas {
+		return fmt.Sprintf("waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated",
+			deployment.Name, status.UpdatedReplicas, replicas), false, nil
+	}
+	if status.Replicas > status.UpdatedReplicas {
+		return fmt.Sprintf("waiting for deployment %q rollout to finish: %d old replicas are pending termination",
+			deployment.Name, status.Replicas-status.UpdatedReplicas), false, nil
+	}
+	if status.AvailableReplicas < status.UpdatedReplicas {
+		return fmt.Sprintf("waiting for deployment %q rollout to finish: %d of %d updated replicas are available",
+			deployment.Name, status.AvailableReplicas, status.UpdatedReplicas), false, nil
+	}
+	return fmt.Sprintf("deployment %q successfully rolled out", deployment.Name), true, nil
+}
+
+// statefulSetRolloutStatus checks the generation has been observed by the controller, and all the replicas
+// are ready and at the update revision, returns the waiting message if the rollout is not complete.
+func statefulSetRolloutStatus(sts *appsv1.StatefulSet) (msg string, done bool, err error) {
+	if sts.Spec.UpdateStrategy.Type != "" && sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return "", false, fmt.Errorf("rollout wait is only available for RollingUpdate strategy, but statefulset %q uses %s",
+			sts.Name, sts.Spec.UpdateStrategy.Type)
+	}
+	if sts.Status.ObservedGeneration == 0 || sts.Generation > sts.Status.ObservedGeneration {
+		return fmt.Sprintf("waiting for statefulset %q spec update to be observed", sts.Name), false, nil
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if sts.Status.ReadyReplicas < replicas {
+		return fmt.Sprintf("waiting for statefulset %q rollout to finish: %d of %d pods are ready",
+			sts.Name, sts.Status.ReadyReplicas, replicas), false, nil
+	}
+
+	if rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil && *rollingUpdate.Partition > 0 {
+		if sts.Status.UpdatedReplicas < replicas-*rollingUpdate.Partition {
+			return fmt.Sprintf("waiting for statefulset %q partitioned rollout to finish: %d out of %d new pods have been updated",
+				sts.Name, sts.Status.UpdatedReplicas, replicas-*rollingUpdate.Partition), false, nil
+		}
+		return fmt.Sprintf("statefulset %q partitioned rollout complete", sts.Name), true, nil
+	}
+	if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+		return fmt.Sprintf("waiting for statefulset %q rolling update to complete: %d pods at revision %s",
+			sts.Name, sts.Status.UpdatedReplicas, sts.Status.UpdateRevision), false, nil
+	}
+	return fmt.Sprintf("statefulset %q successfully rolled out", sts.Name), true, nil
+}
+
+// pvcBoundWaiter waits for the PersistentVolumeClaims to be Bound, so that the storage provisioning
+// problems surface as PVC timeout rather than the pods not ready.
+type pvcBoundWaiter struct {
+	client        kubernetes.Interface
+	namespace     string
+	name          string
+	labelSelector string
+	pollInterval  time.Duration
+}
+
+func newPVCBoundWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*pvcBoundWaiter, error) {
+	kind, name, err := parseWaitResource(wait)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindPVC {
+		return nil, fmt.Errorf("bound wait only supports persistentvolumeclaim, but got %s", wait.Resource)
+	}
+
+	return &pvcBoundWaiter{
+		client:        cluster.Client,
+		namespace:     waitNamespace(wait),
+		name:          name,
+		labelSelector: wait.LabelSelector,
+		pollInterval:  pollInterval,
+	}, nil
+}
+
+func (w *pvcBoundWaiter) RunWait() error {
+	var pending []string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var err error
+		if pending, err = w.pendingPVCs(); err != nil {
+			return false, err
+		}
+		if len(pending) > 0 {
+			logger.Log.Debugf("waiting for persistentvolumeclaims to be Bound: %v", pending)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("persistentvolumeclaims %v in %s", pending, namespaceScope(w.namespace)),
+			Condition: "Bound",
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	return err
+}
+
+// pendingPVCs returns the PVCs which are not Bound yet, the PVCs not created yet (such as the ones of
+// the StatefulSet volumeClaimTemplates) are treated as pending.
+func (w *pvcBoundWaiter) pendingPVCs() ([]string, error) {
+	pvcs, err := w.listPVCs()
+	if err != nil {
+		return nil, err
+	}
+	if len(pvcs) == 0 {
+		name := w.name
+		if name == "" {
+			name = w.labelSelector
+		}
+		return []string{fmt.Sprintf("%s(NotCreated)", name)}, nil
+	}
+
+	pending := make([]string, 0)
+	for i := range pvcs {
+		switch pvcs[i].Status.Phase {
+		case corev1.ClaimBound:
+		case corev1.ClaimLost:
+			return nil, fmt.Errorf("persistentvolumeclaim %s/%s lost its volume", pvcs[i].Namespace, pvcs[i].Name)
+		default:
+			pending = append(pending, fmt.Sprintf("%s(%s)", pvcs[i].Name, pvcs[i].Status.Phase))
+		}
+	}
+	return pending, nil
+}
+
+func (w *pvcBoundWaiter) listPVCs() ([]corev1.PersistentVolumeClaim, error) {
+	pvcs := w.client.CoreV1().PersistentVolumeClaims(w.namespace)
+	if w.name != "" {
+		pvc, err := pvcs.Get(context.Background(), w.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return []corev1.PersistentVolumeClaim{*pvc}, nil
+	}
+
+	list, err := pvcs.List(context.Background(), metav1.ListOptions{LabelSelector: w.labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// loadBalancerWaiter waits for the LoadBalancer Service to get the ingress address, and exports the address
+// as `<resource>_lb_host`, so that the traffic could go through the load balancer instead of the port-forward.
+type loadBalancerWaiter struct {
+	client       kubernetes.Interface
+	namespace    string
+	name         string
+	resource     string
+	pollInterval time.Duration
+}
+
+func newLoadBalancerWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*loadBalancerWaiter, error) {
+	kind, name, err := parseWaitResource(wait)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindService || name == "" {
+		return nil, fmt.Errorf("load-balancer wait only supports a named service, such as service/foo, but got %s", wait.Resource)
+	}
+	if wait.AllNamespaces {
+		return nil, fmt.Errorf("load-balancer wait does not support all-namespaces")
+	}
+
+	return &loadBalancerWaiter{
+		client:       cluster.Client,
+		namespace:    waitNamespace(wait),
+		name:         name,
+		resource:     wait.Resource,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+func (w *loadBalancerWaiter) RunWait() error {
+	var address string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var err error
+		if address, err = w.ingressAddress(); err != nil {
+			return false, err
+		}
+		if address == "" {
+			logger.Log.Debugf("waiting for service %s/%s to get the load balancer ingress", w.namespace, w.name)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("service %s/%s", w.namespace, w.name),
+			Condition: "load balancer ingress",
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// format: <resource>_lb_host
+	return exportKindEnv(fmt.Sprintf("%s_lb_host", envResourceName(w.resource)), hostForURL(address), w.resource)
+}
+
+// ingressAddress returns the IP or the hostname of the load balancer ingress, returns empty if it's not assigned yet.
+// The service not created yet is treated as not assigned.
+func (w *loadBalancerWaiter) ingressAddress() (string, error) {
+	service, err := w.client.CoreV1().Services(w.namespace).Get(context.Background(), w.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return "", fmt.Errorf("service %s/%s is %s rather than LoadBalancer", w.namespace, w.name, service.Spec.Type)
+	}
+
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP, nil
+		}
+		if ingress.Hostname != "" {
+			return ingress.Hostname, nil
+		}
+	}
+	return "", nil
+}
+
+// keyWaiter waits for the key of the ConfigMap or the Secret to be set, such as the connection info written by an operator,
+// and exports the value as the env var if the export is set.
+type keyWaiter struct {
+	client       kubernetes.Interface
+	namespace    string
+	kind         string
+	name         string
+	key          string
+	value        string
+	export       string
+	pollInterval time.Duration
+}
+
+func newKeyWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*keyWaiter, error) {
+	kind, name, err := parseWaitResource(wait)
+	if err != nil {
+		return nil, err
+	}
+	if (kind != kindConfigMap && kind != kindSecret) || name == "" {
+		return nil, fmt.Errorf("key wait only supports a named configmap or secret, such as configmap/foo, but got %s", wait.Resource)
+	}
+	if wait.AllNamespaces {
+		return nil, fmt.Errorf("key wait does not support all-namespaces")
+	}
+	key, value, _ := strings.Cut(strings.TrimPrefix(wait.For, constant.WaitForKey), "=")
+	if key == "" {
+		return nil, fmt.Errorf("the key of %s should be provided, such as key=endpoint or key=endpoint=value", wait.For)
+	}
+
+	return &keyWaiter{
+		client:       cluster.Client,
+		namespace:    waitNamespace(wait),
+		kind:         kind,
+		name:         name,
+		key:          key,
+		value:        value,
+		export:       wait.Export,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+func (w *keyWaiter) RunWait() error {
+	var actual string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var found bool
+		var err error
+		if actual, found, err = w.keyValue(); err != nil {
+			return false, err
+		}
+		if !found || actual == "" || (w.value != "" && actual != w.value) {
+			logger.Log.Debugf("waiting for key %s of %s %s/%s to be set", w.key, w.kind, w.namespace, w.name)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("%s %s/%s", w.kind, w.namespace, w.name),
+			Condition: fmt.Sprintf("key %s", w.key),
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	if err != nil || w.export == "" {
+		return err
+	}
+	if w.kind == kindSecret {
+		return exportSensitiveEnv(w.export, actual, fmt.Sprintf("%s/%s", w.kind, w.name))
+	}
+	return exportKindEnv(w.export, actual, fmt.Sprintf("%s/%s", w.kind, w.name))
+}
+
+// keyValue returns the value of the key, the resource not created yet is treated as the key not found.
+func (w *keyWaiter) keyValue() (value string, found bool, err error) {
+	var data map[string]string
+	switch w.kind {
+	case kindConfigMap:
+		configMap, err := w.client.CoreV1().ConfigMaps(w.namespace).Get(context.Background(), w.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+		data = configMap.Data
+		if v, ok := configMap.BinaryData[w.key]; ok {
+			return string(v), true, nil
+		}
+	case kindSecret:
+		secret, err := w.client.CoreV1().Secrets(w.namespace).Get(context.Background(), w.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+		// the data of the secret is decoded by the client
+		data = make(map[string]string, len(secret.Data))
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+	}
+	value, found = data[w.key]
+	return value, found, nil
+}
+
+// eventWaiter waits for the events of the reason on the involved objects to appear at least count times, such as the `Created` events
+// emitted by an operator for the custom resource, which reflect the reconciliation progress not reflected in the status conditions.
+type eventWaiter struct {
+	client       kubernetes.Interface
+	namespace    string
+	kind         string
+	name         string
+	reason       string
+	count        int
+	pollInterval time.Duration
+}
+
+func newEventWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*eventWaiter, error) {
+	if err := validateWaitResource(wait); err != nil {
+		return nil, err
+	}
+	if wait.LabelSelector != "" {
+		return nil, fmt.Errorf("event wait does not support label-selector, the events are matched by the involved object %s", wait.Resource)
+	}
+	reason, count, err := parseEventCondition(wait.For)
+	if err != nil {
+		return nil, err
+	}
+
+	// the events refer to the kind of the involved object rather than the resource type, such as `OAPServer` of `oapservers`
+	resourceType, name, _ := strings.Cut(wait.Resource, "/")
+	mapper, err := cluster.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	gvk, err := mapper.KindFor(schema.ParseGroupResource(resourceType).WithVersion(""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the kind of resource %s: %v", wait.Resource, err)
+	}
+
+	return &eventWaiter{
+		client:       cluster.Client,
+		namespace:    waitNamespace(wait),
+		kind:         gvk.Kind,
+		name:         name,
+		reason:       reason,
+		count:        count,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+// parseEventCondition parses the condition `event=<reason>[=<count>]`, the count defaults to 1.
+func parseEventCondition(condition string) (reason string, count int, err error) {
+	reason, countValue, found := strings.Cut(strings.TrimPrefix(condition, constant.WaitForEvent), "=")
+	if reason == "" {
+		return "", 0, fmt.Errorf("the reason of %s should be provided, such as event=Created or event=Created=2", condition)
+	}
+	if !found {
+		return reason, 1, nil
+	}
+	if count, err = strconv.Atoi(countValue); err != nil || count <= 0 {
+		return "", 0, fmt.Errorf("the count of %s should be a positive integer", condition)
+	}
+	return reason, count, nil
+}
+
+func (w *eventWaiter) RunWait() error {
+	var actual int
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var err error
+		if actual, err = w.countEvents(); err != nil {
+			return false, err
+		}
+		if actual < w.count {
+			logger.Log.Debugf("waiting for %d %s events of %s, got %d", w.count, w.reason, w.involvedObject(), actual)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("%s in %s", w.involvedObject(), namespaceScope(w.namespace)),
+			Condition: fmt.Sprintf("%d %s events, got %d", w.count, w.reason, actual),
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	return err
+}
+
+// countEvents counts the events of the reason on the involved objects, the repeated events aggregated into one are counted by the times.
+func (w *eventWaiter) countEvents() (int, error) {
+	events, err := w.client.CoreV1().Events(w.namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range events.Items {
+		event := &events.Items[i]
+		if event.Reason != w.reason || event.InvolvedObject.Kind != w.kind || (w.name != "" && event.InvolvedObject.Name != w.name) {
+			continue
+		}
+		if event.Count > 1 {
+			count += int(event.Count)
+		} else {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (w *eventWaiter) involvedObject() string {
+	if w.name == "" {
+		return w.kind
+	}
+	return fmt.Sprintf("%s/%s", w.kind, w.name)
+}
+
+// absentWaiter waits for all the matching resources to be absent, unlike `kubectl wait --for=delete`,
+// the resources which never existed are absent too.
+type absentWaiter struct {
+	cluster      *util.K8sClusterInfo
+	wait         *config.Wait
+	pollInterval time.Duration
+}
+
+func newAbsentWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*absentWaiter, error) {
+	if err := validateWaitResource(wait); err != nil {
+		return nil, err
+	}
+	return &absentWaiter{cluster: cluster, wait: wait, pollInterval: pollInterval}, nil
+}
+
+func (w *absentWaiter) RunWait() error {
+	var remaining []string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var err error
+		if remaining, err = w.remainingResources(); err != nil {
+			return false, err
+		}
+		if len(remaining) > 0 {
+			logger.Log.Debugf("waiting for resources to be absent: %v", remaining)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("%v in %s", remaining, namespaceScope(waitNamespace(w.wait))),
+			Condition: constant.WaitForAbsent,
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	return err
+}
+
+// remainingResources returns the matching resources which still exist.
+func (w *absentWaiter) remainingResources() ([]string, error) {
+	builder := resource.NewBuilder(w.cluster.CopyClusterToNamespace(w.wait.Namespace)).
+		Unstructured().
+		NamespaceParam(w.wait.Namespace).DefaultNamespace().
+		AllNamespaces(w.wait.AllNamespaces)
+	if w.wait.LabelSelector != "" {
+		builder.LabelSelectorParam(w.wait.LabelSelector)
+	} else if !strings.Contains(w.wait.Resource, "/") {
+		builder.SelectAllParam(true)
+	}
+	return existingResources(builder.ResourceTypeOrNameArgs(true, w.wait.Resource).Latest().Flatten().Do().Infos())
+}
+
+// existingResources returns the names of the resources, the ones being deleted are marked as terminating,
+// the resources not found are absent.
+func existingResources(infos []*resource.Info, err error) ([]string, error) {
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	existing := make([]string, 0, len(infos))
+	for _, info := range infos {
+		name := info.ObjectName()
+		if obj, err := meta.Accessor(info.Object); err == nil && obj.GetDeletionTimestamp() != nil {
+			name += "(Terminating)"
+		}
+		existing = append(existing, name)
+	}
+	return existing, nil
+}
+
+// execWaiter waits for the command to exit with 0 in all the matching pods, mirrors the readiness command of compose.
+type execWaiter struct {
+	client        kubernetes.Interface
+	namespace     string
+	name          string
+	labelSelector string
+	command       string
+	pollInterval  time.Duration
+	// exec executes the command in the pod, returns error if the command exits with non-zero code
+	exec func(pod *corev1.Pod) error
+}
+
+func newExecWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*execWaiter, error) {
+	kind, name, err := parseWaitResource(wait)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindPod {
+		return nil, fmt.Errorf("exec wait only supports pod, but got %s", wait.Resource)
+	}
+	if wait.Command == "" {
+		return nil, fmt.Errorf("command must be provided in exec wait block")
+	}
+	restConfig, err := cluster.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &execWaiter{
+		client:        cluster.Client,
+		namespace:     waitNamespace(wait),
+		name:          name,
+		labelSelector: wait.LabelSelector,
+		command:       wait.Command,
+		pollInterval:  pollInterval,
+		exec: func(pod *corev1.Pod) error {
+			return execInPod(cluster.Client, restConfig, pod, wait.Container, wait.Command)
+		},
+	}, nil
+}
+
+func (w *execWaiter) RunWait() error {
+	var pending []string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var err error
+		if pending, err = w.pendingPods(); err != nil {
+			return false, err
+		}
+		if len(pending) > 0 {
+			logger.Log.Debugf("waiting for command %q to succeed in pods: %v", w.command, pending)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("pods %v in %s", pending, namespaceScope(w.namespace)),
+			Condition: fmt.Sprintf("exec %q", w.command),
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	return err
+}
+
+// pendingPods returns the pods which the command doesn't succeed in, the pods not created or not running are treated as pending.
+func (w *execWaiter) pendingPods() ([]string, error) {
+	pods, err := w.listPods()
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		name := w.name
+		if name == "" {
+			name = w.labelSelector
+		}
+		return []string{fmt.Sprintf("%s(NotCreated)", name)}, nil
+	}
+
+	pending := make([]string, 0)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			pending = append(pending, fmt.Sprintf("%s(%s)", pod.Name, pod.Status.Phase))
+			continue
+		}
+		if err := w.exec(pod); err != nil {
+			logger.Log.Debugf("command %q in pod %s/%s failed: %v", w.command, pod.Namespace, pod.Name, err)
+			pending = append(pending, fmt.Sprintf("%s(CommandFailed)", pod.Name))
+		}
+	}
+	return pending, nil
+}
+
+func (w *execWaiter) listPods() ([]corev1.Pod, error) {
+	return listWaitPods(w.client, w.namespace, w.name, w.labelSelector)
+}
+
+// listWaitPods lists the pod of the name, or the pods matching the label selector, the pod not found is not listed.
+func listWaitPods(client kubernetes.Interface, namespace, name, labelSelector string) ([]corev1.Pod, error) {
+	pods := client.CoreV1().Pods(namespace)
+	if name != "" {
+		pod, err := pods.Get(context.Background(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	}
+
+	list, err := pods.List(context.Background(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// logWaiter waits for the log lines matching the pattern to reach the count in all the matching pods,
+// such as the records processed by a data pipeline without any status API.
+type logWaiter struct {
+	client        kubernetes.Interface
+	namespace     string
+	name          string
+	labelSelector string
+	container     string
+	pattern       *regexp.Regexp
+	count         int
+	pollInterval  time.Duration
+}
+
+func newLogWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*logWaiter, error) {
+	kind, name, err := parseWaitResource(wait)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindPod {
+		return nil, fmt.Errorf("log wait only supports pod, but got %s", wait.Resource)
+	}
+	pattern, count, err := parseLogCondition(wait.For)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logWaiter{
+		client:        cluster.Client,
+		namespace:     waitNamespace(wait),
+		name:          name,
+		labelSelector: wait.LabelSelector,
+		container:     wait.Container,
+		pattern:       pattern,
+		count:         count,
+		pollInterval:  pollInterval,
+	}, nil
+}
+
+// parseLogCondition parses the condition `log=<pattern>[=<count>]`, the count defaults to 1,
+// the suffix after the last `=` is the count if it's an integer, such as `log=records=1000`.
+func parseLogCondition(condition string) (pattern *regexp.Regexp, count int, err error) {
+	expression, count := strings.TrimPrefix(condition, constant.WaitForLog), 1
+	if i := strings.LastIndex(expression, "="); i != -1 {
+		if c, err := strconv.Atoi(expression[i+1:]); err == nil {
+			if c <= 0 {
+				return nil, 0, fmt.Errorf("the count of %s should be a positive integer", condition)
+			}
+			expression, count = expression[:i], c
+		}
+	}
+	if expression == "" {
+		return nil, 0, fmt.Errorf("the pattern of %s should be provided, such as log=processed or log=processed=1000", condition)
+	}
+	if pattern, err = regexp.Compile(expression); err != nil {
+		return nil, 0, fmt.Errorf("invalid log pattern %s: %v", expression, err)
+	}
+	return pattern, count, nil
+}
+
+func (w *logWaiter) RunWait() error {
+	var pending []string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var err error
+		if pending, err = w.pendingPods(); err != nil {
+			return false, err
+		}
+		if len(pending) > 0 {
+			logger.Log.Debugf("waiting for %d log lines matching %s in pods: %v", w.count, w.pattern, pending)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("pods %v in %s", pending, namespaceScope(w.namespace)),
+			Condition: fmt.Sprintf("%d log lines matching %s", w.count, w.pattern),
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	return err
+}
+
+// pendingPods returns the pods whose matching log lines don't reach the count yet, with the current counts,
+// the pods not created or whose logs are not available yet are treated as pending.
+func (w *logWaiter) pendingPods() ([]string, error) {
+	pods, err := listWaitPods(w.client, w.namespace, w.name, w.labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		name := w.name
+		if name == "" {
+			name = w.labelSelector
+		}
+		return []string{fmt.Sprintf("%s(NotCreated)", name)}, nil
+	}
+
+	pending := make([]string, 0)
+	for i := range pods {
+		pod := &pods[i]
+		count, err := w.countPodLogLines(pod)
+		if err != nil {
+			logger.Log.Debugf("failed to read the logs of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			pending = append(pending, fmt.Sprintf("%s(LogsUnavailable)", pod.Name))
+			continue
+		}
+		if count < w.count {
+			pending = append(pending, fmt.Sprintf("%s(%d)", pod.Name, count))
+		}
+	}
+	return pending, nil
+}
+
+func (w *logWaiter) countPodLogLines(pod *corev1.Pod) (int, error) {
+	stream, err := w.client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: w.container}).
+		Stream(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Close()
+	return countLogLines(stream, w.pattern)
+}
+
+// execInPod executes the command by `/bin/sh -c` in the container of the pod through the exec subresource,
+// the default container is used if the container is empty.
+func execInPod(client kubernetes.Interface, restConfig *rest.Config, pod *corev1.Pod, container, command string) error {
+	req := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   []string{"/bin/sh", "-c", command},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, http.MethodPost, req.URL())
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	if err := executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return fmt.Errorf("%v, output: %s, error: %s", err, stdout.String(), stderr.String())
+	}
+	return nil
+}
+
+// jsonPathWaiter waits for the value of the JSONPath expression in all the matching resources to be the expected value,
+// mirrors `kubectl wait --for=jsonpath='{.status.phase}'=Running` which is not supported by the kubectl version in use.
+type jsonPathWaiter struct {
+	cluster      *util.K8sClusterInfo
+	wait         *config.Wait
+	parser       *jsonpath.JSONPath
+	condition    string
+	value        string
+	pollInterval time.Duration
+}
+
+func newJSONPathWaiter(cluster *util.K8sClusterInfo, wait *config.Wait, pollInterval time.Duration) (*jsonPathWaiter, error) {
+	if err := validateWaitResource(wait); err != nil {
+		return nil, err
+	}
+	expression, value, err := parseJSONPathCondition(wait.For)
+	if err != nil {
+		return nil, err
+	}
+	parser := jsonpath.New("wait").AllowMissingKeys(true)
+	if err := parser.Parse(expression); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression %s: %v", expression, err)
+	}
+	return &jsonPathWaiter{
+		cluster:      cluster,
+		wait:         wait,
+		parser:       parser,
+		condition:    fmt.Sprintf("%s=%s", expression, value),
+		value:        value,
+		pollInterval: pollInterval,
+	}, nil
+}
+
+func (w *jsonPathWaiter) RunWait() error {
+	var pending []string
+	err := k8swait.PollImmediate(w.pollInterval, constant.SingleDefaultWaitTimeout, func() (bool, error) {
+		var err error
+		if pending, err = w.pendingResources(); err != nil {
+			return false, err
+		}
+		if len(pending) > 0 {
+			logger.Log.Debugf("waiting for %s of resources: %v", w.condition, pending)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == k8swait.ErrWaitTimeout {
+		return &e2eerrors.WaitTimeoutError{
+			Resource:  fmt.Sprintf("%v in %s", pending, namespaceScope(waitNamespace(w.wait))),
+			Condition: w.condition,
+			Timeout:   constant.SingleDefaultWaitTimeout,
+		}
+	}
+	return err
+}
+
+// pendingResources returns the resources whose value is not the expected one, the resources not created yet are treated as pending.
+func (w *jsonPathWaiter) pendingResources() ([]string, error) {
+	builder := resource.NewBuilder(w.cluster.CopyClusterToNamespace(w.wait.Namespace)).
+		Unstructured().
+		NamespaceParam(w.wait.Namespace).DefaultNamespace().
+		AllNamespaces(w.wait.AllNamespaces)
+	if w.wait.LabelSelector != "" {
+		builder.LabelSelectorParam(w.wait.LabelSelector)
+	} else if !strings.Contains(w.wait.Resource, "/") {
+		// check all the resources of the type like `kubectl wait --all`
+		builder.SelectAllParam(true)
+	}
+	infos, err := builder.ResourceTypeOrNameArgs(true, w.wait.Resource).Latest().Flatten().Do().Infos()
+	if apierrors.IsNotFound(err) || (err == nil && len(infos) == 0) {
+		return []string{fmt.Sprintf("%s(NotCreated)", w.wait.Resource)}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	pending := make([]string, 0)
+	for _, info := range infos {
+		obj, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object type %T of %s", info.Object, info.Name)
+		}
+		actual, matched, err := matchJSONPath(w.parser, obj.UnstructuredContent(), w.value)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			pending = append(pending, fmt.Sprintf("%s(%s)", info.ObjectName(), actual))
+		}
+	}
+	return pending, nil
+}
+
+// parseJSONPathCondition parses the condition such as `jsonpath={.status.phase}=Running` into the expression and the value,
+// the expression could be quoted like the kubectl command line, such as `jsonpath='{.status.phase}'=Running`.
+func parseJSONPathCondition(condition string) (expression, value string, err error) {
+	cond := strings.TrimPrefix(condition, constant.WaitForJSONPath)
+	cond = strings.TrimLeft(cond, `'"`)
+	end := strings.LastIndex(cond, "}")
+	if !strings.HasPrefix(cond, "{") || end == -1 {
+		return "", "", fmt.Errorf("the jsonpath expression of %s should be wrapped by {}", condition)
+	}
+	expression, value = cond[:end+1], strings.TrimLeft(cond[end+1:], `'"`)
+	if !strings.HasPrefix(value, "=") || len(value) == 1 {
+		return "", "", fmt.Errorf("the expected value of %s should be provided, such as jsonpath={.status.phase}=Running", condition)
+	}
+	return expression, value[1:], nil
+}
+
+// matchJSONPath checks all the values found by the JSONPath expression equal to the expected value,
+// returns the found values, the missing value is treated as not matched.
+func matchJSONPath(parser *jsonpath.JSONPath, data any, value string) (actual string, matched bool, err error) {
+	results, err := parser.FindResults(data)
+	if err != nil {
+		return "", false, err
+	}
+	values := make([]string, 0)
+	for _, result := range results {
+		for _, v := range result {
+			values = append(values, fmt.Sprint(v.Interface()))
+		}
+	}
+	if len(values) == 0 {
+		return "<missing>", false, nil
+	}
+	for _, v := range values {
+		if v != value {
+			return strings.Join(values, ","), false, nil
+		}
+	}
+	return strings.Join(values, ","), true, nil
+}
+
+// validateWaitResource checks the resource and label selector of the wait block are not conflicted.
+func validateWaitResource(wait *config.Wait) error {
+	if wait.Resource == "" {
+		return fmt.Errorf("resource must be provided in wait block")
+	}
+	if strings.Contains(wait.Resource, "/") && wait.LabelSelector != "" {
+		return fmt.Errorf("when passing resource.group/resource.name in Resource, the labelSelector can not be set at the same time")
+	}
+	if wait.AllNamespaces {
+		if strings.Contains(wait.Resource, "/") {
+			return fmt.Errorf("all-namespaces only supports the resource type with label selector, but got %s", wait.Resource)
+		}
+		if wait.Namespace != "" {
+			return fmt.Errorf("namespace %s can not be set when all-namespaces is enabled", wait.Namespace)
+		}
+	}
+	return nil
+}
+
+// waitNamespace returns the namespace to list the resources of the wait block, defaults to the default namespace,
+// returns metav1.NamespaceAll if all-namespaces is enabled.
+func waitNamespace(wait *config.Wait) string {
+	if wait.AllNamespaces {
+		return metav1.NamespaceAll
+	}
+	if wait.Namespace == "" {
+		return metav1.NamespaceDefault
+	}
+	return wait.Namespace
+}
+
+// namespaceScope describes the namespace in the messages, such as `namespace default` or `all namespaces`.
+func namespaceScope(namespace string) string {
+	if namespace == metav1.NamespaceAll {
+		return "all namespaces"
+	}
+	return "namespace " + namespace
+}
+
+// parseWaitResource parses the resource of the wait block into the workload kind and name,
+// such as `deployment/foo`, `deployments.apps/foo` or `deploy` with label selector.
+func parseWaitResource(wait *config.Wait) (kind, name string, err error) {
+	if err := validateWaitResource(wait); err != nil {
+		return "", "", err
+	}
+
+	resourceType := wait.Resource
+	if i := strings.Index(resourceType, "/"); i != -1 {
+		resourceType, name = resourceType[:i], resourceType[i+1:]
+	}
+	// trim the group, such as deployments.apps
+	if i := strings.Index(resourceType, "."); i != -1 {
+		resourceType = resourceType[:i]
+	}
+
+	switch strings.ToLower(resourceType) {
+	case "deployment", "deployments", "deploy":
+		return kindDeployment, name, nil
+	case "statefulset", "statefulsets", "sts":
+		return kindStatefulSet, name, nil
+	case "persistentvolumeclaim", "persistentvolumeclaims", "pvc":
+		return kindPVC, name, nil
+	case "pod", "pods", "po":
+		return kindPod, name, nil
+	case "service", "services", "svc":
+		return kindService, name, nil
+	case "configmap", "configmaps", "cm":
+		return kindConfigMap, name, nil
+	case "secret", "secrets":
+		return kindSecret, name, nil
+	}
+	return resourceType, name, nil
+}
+
+// waitForCoreDNS waits for the rollout of the CoreDNS of the created cluster, the pods started before it's serving
+// fail the DNS lookups and crash. The deployment is named `coredns` while the service is still named `kube-dns`.
+func waitForCoreDNS(client kubernetes.Interface) error {
+	logger.Log.Info("waiting for CoreDNS of the kind cluster to be available...")
+	w := &rolloutWaiter{
+		client:       client,
+		namespace:    metav1.NamespaceSystem,
+		kind:         kindDeployment,
+		name:         "coredns",
+		pollInterval: workloadPollInterval,
+	}
+	if err := w.RunWait(); err != nil {
+		return fmt.Errorf("wait for CoreDNS of the kind cluster error: %w", err)
+	}
+	return nil
+}
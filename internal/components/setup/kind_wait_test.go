@@ -0,0 +1,696 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestDeploymentRolloutStatus(t *testing.T) {
+	newDeployment := func(generation int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+			Status:     status,
+		}
+	}
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		wantDone   bool
+		wantErr    bool
+	}{
+		{
+			name:       "should wait when the generation is not observed",
+			deployment: newDeployment(2, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}),
+		},
+		{
+			name:       "should wait when the replicas are not updated",
+			deployment: newDeployment(1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1}),
+		},
+		{
+			name:       "should wait when the old replicas are pending termination",
+			deployment: newDeployment(1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2}),
+		},
+		{
+			name:       "should wait when the updated replicas are unavailable",
+			deployment: newDeployment(1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1}),
+		},
+		{
+			name: "should fail when the progress deadline is exceeded",
+			deployment: newDeployment(1, appsv1.DeploymentStatus{
+				ObservedGeneration: 1,
+				Conditions:         []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"}},
+			}),
+			wantErr: true,
+		},
+		{
+			name:       "should be done when all the replicas are updated and available",
+			deployment: newDeployment(1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}),
+			wantDone:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, done, err := deploymentRolloutStatus(tt.deployment)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("deploymentRolloutStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if done != tt.wantDone {
+				t.Errorf("deploymentRolloutStatus() done = %v, want %v", done, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestStatefulSetRolloutStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		partition *int32
+		status    appsv1.StatefulSetStatus
+		wantDone  bool
+	}{
+		{
+			name:   "should wait when the pods are not ready",
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 1, CurrentRevision: "v1", UpdateRevision: "v1"},
+		},
+		{
+			name:   "should wait when the revision is not updated",
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, CurrentRevision: "v1", UpdateRevision: "v2"},
+		},
+		{
+			name:     "should be done when all the pods are ready and updated",
+			status:   appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, CurrentRevision: "v2", UpdateRevision: "v2"},
+			wantDone: true,
+		},
+		{
+			name:      "should wait when the pods above the partition are not updated",
+			partition: int32Ptr(1),
+			status:    appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, CurrentRevision: "v1", UpdateRevision: "v2"},
+		},
+		{
+			name:      "should be done when the pods above the partition are updated",
+			partition: int32Ptr(1),
+			status: appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, UpdatedReplicas: 1,
+				CurrentRevision: "v1", UpdateRevision: "v2"},
+			wantDone: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Generation: 1},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas: int32Ptr(2),
+					UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+						RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: tt.partition},
+					},
+				},
+				Status: tt.status,
+			}
+			_, done, err := statefulSetRolloutStatus(sts)
+			if err != nil {
+				t.Errorf("statefulSetRolloutStatus() error = %v", err)
+				return
+			}
+			if done != tt.wantDone {
+				t.Errorf("statefulSetRolloutStatus() done = %v, want %v", done, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestRolloutWaiterNotCreated(t *testing.T) {
+	w := &rolloutWaiter{client: fake.NewSimpleClientset(), namespace: "default", kind: kindDeployment, name: "foo"}
+	_, done, err := w.rolloutStatus()
+	if err != nil || done {
+		t.Errorf("rolloutStatus() done = %v, error = %v, should keep waiting for the deployment to be created", done, err)
+	}
+}
+
+func TestWaitForCoreDNS(t *testing.T) {
+	client := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: metav1.NamespaceSystem, Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+	})
+	if err := waitForCoreDNS(client); err != nil {
+		t.Errorf("waitForCoreDNS() error = %v", err)
+	}
+}
+
+func TestParseWaitResource(t *testing.T) {
+	tests := []struct {
+		wait     config.Wait
+		wantKind string
+		wantName string
+		wantErr  bool
+	}{
+		{wait: config.Wait{Resource: "deployment/foo"}, wantKind: kindDeployment, wantName: "foo"},
+		{wait: config.Wait{Resource: "deployments.apps", LabelSelector: "app=foo"}, wantKind: kindDeployment},
+		{wait: config.Wait{Resource: "sts/foo"}, wantKind: kindStatefulSet, wantName: "foo"},
+		{wait: config.Wait{Resource: "pods", LabelSelector: "app=foo"}, wantKind: kindPod},
+		{wait: config.Wait{Resource: "svc/foo"}, wantKind: kindService, wantName: "foo"},
+		{wait: config.Wait{Resource: "configmap/foo"}, wantKind: kindConfigMap, wantName: "foo"},
+		{wait: config.Wait{Resource: "secrets/foo"}, wantKind: kindSecret, wantName: "foo"},
+		{wait: config.Wait{Resource: "deployment/foo", LabelSelector: "app=foo"}, wantErr: true},
+		{wait: config.Wait{Resource: "pods", LabelSelector: "app=foo", AllNamespaces: true}, wantKind: kindPod},
+		{wait: config.Wait{Resource: "pod/foo", AllNamespaces: true}, wantErr: true},
+		{wait: config.Wait{Resource: "pods", Namespace: "foo", AllNamespaces: true}, wantErr: true},
+		{wait: config.Wait{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wait.Resource, func(t *testing.T) {
+			kind, name, err := parseWaitResource(&tt.wait)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseWaitResource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if kind != tt.wantKind || name != tt.wantName {
+				t.Errorf("parseWaitResource() = %s, %s, want %s, %s", kind, name, tt.wantKind, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestPVCBoundWaiter(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-foo-0", Namespace: "default", Labels: map[string]string{"app": "foo"}},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	client := fake.NewSimpleClientset()
+	pvcs := client.CoreV1().PersistentVolumeClaims("default")
+	waiters := []*pvcBoundWaiter{
+		{client: client, namespace: "default", name: "data-foo-0"},
+		{client: client, namespace: "default", labelSelector: "app=foo"},
+		{client: client, namespace: metav1.NamespaceAll, labelSelector: "app=foo"},
+	}
+
+	steps := []struct {
+		name        string
+		phase       corev1.PersistentVolumeClaimPhase
+		wantPending bool
+		wantErr     bool
+	}{
+		{name: "should keep waiting when the pvc is not created yet", wantPending: true},
+		{name: "should keep waiting when the pvc is pending", phase: corev1.ClaimPending, wantPending: true},
+		{name: "should be done when the pvc is bound", phase: corev1.ClaimBound},
+		{name: "should fail when the pvc is lost", phase: corev1.ClaimLost, wantErr: true},
+	}
+	for _, step := range steps {
+		if step.phase != "" {
+			pvc.Status.Phase = step.phase
+			var err error
+			if _, getErr := pvcs.Get(context.Background(), pvc.Name, metav1.GetOptions{}); getErr != nil {
+				_, err = pvcs.Create(context.Background(), pvc, metav1.CreateOptions{})
+			} else {
+				_, err = pvcs.Update(context.Background(), pvc, metav1.UpdateOptions{})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, w := range waiters {
+			t.Run(step.name, func(t *testing.T) {
+				pending, err := w.pendingPVCs()
+				if (err != nil) != step.wantErr {
+					t.Errorf("pendingPVCs() error = %v, wantErr %v", err, step.wantErr)
+					return
+				}
+				if (len(pending) > 0) != step.wantPending {
+					t.Errorf("pendingPVCs() = %v, wantPending %v", pending, step.wantPending)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadBalancerWaiterIngressAddress(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	client := fake.NewSimpleClientset()
+	services := client.CoreV1().Services("default")
+	w := &loadBalancerWaiter{client: client, namespace: "default", name: "gateway", resource: "service/gateway"}
+
+	steps := []struct {
+		name        string
+		ingress     []corev1.LoadBalancerIngress
+		serviceType corev1.ServiceType
+		want        string
+		wantErr     bool
+	}{
+		{name: "should keep waiting when the service is not created yet"},
+		{name: "should keep waiting when the ingress is not assigned", serviceType: corev1.ServiceTypeLoadBalancer},
+		{
+			name:        "should return the ingress ip",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			ingress:     []corev1.LoadBalancerIngress{{IP: "172.18.255.200"}},
+			want:        "172.18.255.200",
+		},
+		{
+			name:        "should return the ingress hostname",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			ingress:     []corev1.LoadBalancerIngress{{Hostname: "gateway.example.com"}},
+			want:        "gateway.example.com",
+		},
+		{name: "should fail when the service is not a load balancer", serviceType: corev1.ServiceTypeClusterIP, wantErr: true},
+	}
+	for _, step := range steps {
+		if step.serviceType != "" {
+			service.Spec.Type = step.serviceType
+			service.Status.LoadBalancer.Ingress = step.ingress
+			var err error
+			if _, getErr := services.Get(context.Background(), service.Name, metav1.GetOptions{}); getErr != nil {
+				_, err = services.Create(context.Background(), service, metav1.CreateOptions{})
+			} else {
+				_, err = services.Update(context.Background(), service, metav1.UpdateOptions{})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		t.Run(step.name, func(t *testing.T) {
+			got, err := w.ingressAddress()
+			if (err != nil) != step.wantErr {
+				t.Errorf("ingressAddress() error = %v, wantErr %v", err, step.wantErr)
+				return
+			}
+			if got != step.want {
+				t.Errorf("ingressAddress() = %q, want %q", got, step.want)
+			}
+		})
+	}
+}
+
+func TestExecWaiter(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo-0", Namespace: "default", Labels: map[string]string{"app": "foo"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	client := fake.NewSimpleClientset()
+	pods := client.CoreV1().Pods("default")
+	var execErr error
+	exec := func(pod *corev1.Pod) error { return execErr }
+	waiters := []*execWaiter{
+		{client: client, namespace: "default", name: "foo-0", exec: exec},
+		{client: client, namespace: "default", labelSelector: "app=foo", exec: exec},
+	}
+
+	steps := []struct {
+		name        string
+		phase       corev1.PodPhase
+		execErr     error
+		wantPending bool
+	}{
+		{name: "should keep waiting when the pod is not created yet", wantPending: true},
+		{name: "should keep waiting when the pod is not running", phase: corev1.PodPending, wantPending: true},
+		{name: "should keep waiting when the command fails", phase: corev1.PodRunning, execErr: fmt.Errorf("exit code 1"), wantPending: true},
+		{name: "should be done when the command succeeds", phase: corev1.PodRunning},
+	}
+	for _, step := range steps {
+		execErr = step.execErr
+		if step.phase != "" {
+			pod.Status.Phase = step.phase
+			var err error
+			if _, getErr := pods.Get(context.Background(), pod.Name, metav1.GetOptions{}); getErr != nil {
+				_, err = pods.Create(context.Background(), pod, metav1.CreateOptions{})
+			} else {
+				_, err = pods.Update(context.Background(), pod, metav1.UpdateOptions{})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, w := range waiters {
+			t.Run(step.name, func(t *testing.T) {
+				pending, err := w.pendingPods()
+				if err != nil {
+					t.Errorf("pendingPods() error = %v", err)
+					return
+				}
+				if (len(pending) > 0) != step.wantPending {
+					t.Errorf("pendingPods() = %v, wantPending %v", pending, step.wantPending)
+				}
+			})
+		}
+	}
+}
+
+func TestParsePollInterval(t *testing.T) {
+	tests := []struct {
+		pollInterval string
+		want         time.Duration
+		wantErr      bool
+	}{
+		{pollInterval: "", want: workloadPollInterval},
+		{pollInterval: "200ms", want: 200 * time.Millisecond},
+		{pollInterval: "0s", wantErr: true},
+		{pollInterval: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pollInterval, func(t *testing.T) {
+			got, err := parsePollInterval(&config.Wait{PollInterval: tt.pollInterval})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePollInterval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parsePollInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONPathCondition(t *testing.T) {
+	tests := []struct {
+		condition      string
+		wantExpression string
+		wantValue      string
+		wantErr        bool
+	}{
+		{condition: "jsonpath={.status.phase}=Running", wantExpression: "{.status.phase}", wantValue: "Running"},
+		{condition: "jsonpath='{.status.phase}'=Running", wantExpression: "{.status.phase}", wantValue: "Running"},
+		{condition: `jsonpath={.status.conditions[?(@.type=="Ready")].status}=True`,
+			wantExpression: `{.status.conditions[?(@.type=="Ready")].status}`, wantValue: "True"},
+		{condition: "jsonpath={.status.phase}", wantErr: true},
+		{condition: "jsonpath={.status.phase}=", wantErr: true},
+		{condition: "jsonpath=.status.phase=Running", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expression, value, err := parseJSONPathCondition(tt.condition)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseJSONPathCondition() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if expression != tt.wantExpression || value != tt.wantValue {
+				t.Errorf("parseJSONPathCondition() = %s, %s, want %s, %s", expression, value, tt.wantExpression, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestMatchJSONPath(t *testing.T) {
+	data := map[string]any{
+		"status": map[string]any{
+			"phase":    "Running",
+			"replicas": int64(2),
+			"conditions": []any{
+				map[string]any{"type": "Ready", "status": "True"},
+				map[string]any{"type": "Synced", "status": "False"},
+			},
+		},
+	}
+	tests := []struct {
+		name        string
+		expression  string
+		value       string
+		wantMatched bool
+	}{
+		{name: "should match the string value", expression: "{.status.phase}", value: "Running", wantMatched: true},
+		{name: "should match the number value", expression: "{.status.replicas}", value: "2", wantMatched: true},
+		{name: "should match the filtered value", expression: `{.status.conditions[?(@.type=="Ready")].status}`, value: "True", wantMatched: true},
+		{name: "should not match when any value differs", expression: "{.status.conditions[*].status}", value: "True"},
+		{name: "should not match the missing value", expression: "{.status.ready}", value: "True"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := jsonpath.New("test").AllowMissingKeys(true)
+			if err := parser.Parse(tt.expression); err != nil {
+				t.Fatal(err)
+			}
+			actual, matched, err := matchJSONPath(parser, data, tt.value)
+			if err != nil {
+				t.Fatalf("matchJSONPath() error = %v", err)
+			}
+			if matched != tt.wantMatched {
+				t.Errorf("matchJSONPath() = %s, %v, want matched %v", actual, matched, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func TestKeyWaiterKeyValue(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	configMapWaiter := &keyWaiter{client: client, namespace: "default", kind: kindConfigMap, name: "foo", key: "endpoint"}
+	secretWaiter := &keyWaiter{client: client, namespace: "default", kind: kindSecret, name: "foo", key: "password"}
+
+	for _, w := range []*keyWaiter{configMapWaiter, secretWaiter} {
+		if _, found, err := w.keyValue(); err != nil || found {
+			t.Errorf("keyValue() found = %v, error = %v, should keep waiting for the %s to be created", found, err, w.kind)
+		}
+	}
+
+	if _, err := client.CoreV1().ConfigMaps("default").Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Data:       map[string]string{"endpoint": "http://oap:12800"},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Secrets("default").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		w    *keyWaiter
+		want string
+	}{
+		{w: configMapWaiter, want: "http://oap:12800"},
+		{w: secretWaiter, want: "s3cr3t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.w.kind, func(t *testing.T) {
+			got, found, err := tt.w.keyValue()
+			if err != nil || !found || got != tt.want {
+				t.Errorf("keyValue() = %q, %v, %v, want %q", got, found, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyWaiterExportSecret(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	})
+	t.Setenv("E2E_TEST_SECRET_PASSWORD", "")
+	w := &keyWaiter{client: client, namespace: "default", kind: kindSecret, name: "foo", key: "password",
+		export: "E2E_TEST_SECRET_PASSWORD", pollInterval: time.Millisecond}
+	if err := w.RunWait(); err != nil {
+		t.Fatalf("RunWait() error = %v", err)
+	}
+	if got := os.Getenv("E2E_TEST_SECRET_PASSWORD"); got != "s3cr3t" {
+		t.Errorf("exported E2E_TEST_SECRET_PASSWORD = %q, want s3cr3t", got)
+	}
+	if _, exists := ExportedEnv()["E2E_TEST_SECRET_PASSWORD"]; exists {
+		t.Errorf("the secret value should be kept out of the access info")
+	}
+}
+
+func TestParseEventCondition(t *testing.T) {
+	tests := []struct {
+		condition  string
+		wantReason string
+		wantCount  int
+		wantErr    bool
+	}{
+		{condition: "event=Created", wantReason: "Created", wantCount: 1},
+		{condition: "event=Created=3", wantReason: "Created", wantCount: 3},
+		{condition: "event=", wantErr: true},
+		{condition: "event=Created=0", wantErr: true},
+		{condition: "event=Created=many", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			reason, count, err := parseEventCondition(tt.condition)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEventCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if reason != tt.wantReason || count != tt.wantCount {
+				t.Errorf("parseEventCondition() = %s, %d, want %s, %d", reason, count, tt.wantReason, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestEventWaiterCountEvents(t *testing.T) {
+	newEvent := func(name, kind, objectName, reason string, count int32) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: objectName, Namespace: "default"},
+			Reason:         reason,
+			Count:          count,
+		}
+	}
+	client := fake.NewSimpleClientset(
+		newEvent("e1", "OAPServer", "foo", "Created", 1),
+		newEvent("e2", "OAPServer", "foo", "Created", 2),
+		newEvent("e3", "OAPServer", "bar", "Created", 0),
+		newEvent("e4", "OAPServer", "foo", "Updated", 1),
+		newEvent("e5", "Deployment", "foo", "Created", 1),
+	)
+
+	tests := []struct {
+		name string
+		w    *eventWaiter
+		want int
+	}{
+		{name: "named object", w: &eventWaiter{client: client, namespace: "default", kind: "OAPServer", name: "foo", reason: "Created"}, want: 3},
+		{name: "all objects of kind", w: &eventWaiter{client: client, namespace: "default", kind: "OAPServer", reason: "Created"}, want: 4},
+		{name: "other namespace", w: &eventWaiter{client: client, namespace: "skywalking", kind: "OAPServer", reason: "Created"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.w.countEvents()
+			if err != nil || got != tt.want {
+				t.Errorf("countEvents() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExistingResources(t *testing.T) {
+	deleting := metav1.Now()
+	tests := []struct {
+		name    string
+		infos   []*resource.Info
+		err     error
+		want    []string
+		wantErr bool
+	}{
+		{name: "should be absent when not found", err: apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "foo")},
+		{name: "should be absent without resources", infos: []*resource.Info{}, want: []string{}},
+		{name: "should fail on the other errors", err: fmt.Errorf("the server doesn't have a resource type \"foos\""), wantErr: true},
+		{
+			name: "should list the existing resources",
+			infos: []*resource.Info{
+				{Name: "foo", Object: &corev1.Pod{TypeMeta: metav1.TypeMeta{Kind: "Pod"}, ObjectMeta: metav1.ObjectMeta{Name: "foo"}}},
+				{Name: "bar", Object: &corev1.Pod{TypeMeta: metav1.TypeMeta{Kind: "Pod"},
+					ObjectMeta: metav1.ObjectMeta{Name: "bar", DeletionTimestamp: &deleting}}},
+			},
+			want: []string{"pod/foo", "pod/bar(Terminating)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := existingResources(tt.infos, tt.err)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("existingResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("existingResources() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseLogCondition(t *testing.T) {
+	tests := []struct {
+		condition   string
+		wantPattern string
+		wantCount   int
+		wantErr     bool
+	}{
+		{condition: "log=processed", wantPattern: "processed", wantCount: 1},
+		{condition: "log=processed=1000", wantPattern: "processed", wantCount: 1000},
+		{condition: "log=status=ok", wantPattern: "status=ok", wantCount: 1},
+		{condition: "log=code=200=3", wantPattern: "code=200", wantCount: 3},
+		{condition: "log=", wantErr: true},
+		{condition: "log==3", wantErr: true},
+		{condition: "log=processed=0", wantErr: true},
+		{condition: "log=processed(", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			pattern, count, err := parseLogCondition(tt.condition)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLogCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if pattern.String() != tt.wantPattern || count != tt.wantCount {
+				t.Errorf("parseLogCondition() = %s, %d, want %s, %d", pattern, count, tt.wantPattern, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestLogWaiterPendingPods(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pipeline-0", Namespace: "default", Labels: map[string]string{"app": "pipeline"}}}
+	// the logs of the fake client are always `fake logs`
+	tests := []struct {
+		name        string
+		pods        []runtime.Object
+		count       int
+		wantPending []string
+	}{
+		{name: "should keep waiting when the pod is not created yet", count: 1, wantPending: []string{"app=pipeline(NotCreated)"}},
+		{name: "should keep waiting when the lines don't reach the count", pods: []runtime.Object{pod}, count: 2,
+			wantPending: []string{"pipeline-0(1)"}},
+		{name: "should be done when the lines reach the count", pods: []runtime.Object{pod}, count: 1, wantPending: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &logWaiter{
+				client:        fake.NewSimpleClientset(tt.pods...),
+				namespace:     "default",
+				labelSelector: "app=pipeline",
+				pattern:       regexp.MustCompile("^fake"),
+				count:         tt.count,
+			}
+			pending, err := w.pendingPods()
+			if err != nil {
+				t.Fatalf("pendingPods() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantPending, pending); diff != "" {
+				t.Errorf("pendingPods() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
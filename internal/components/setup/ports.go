@@ -0,0 +1,198 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+// fixedPort is the fixed local port which the setup binds on the host, the auto-assigned ports are not checked.
+type fixedPort struct {
+	address string
+	port    int
+	// owner is the resource or the service which the port is bound for.
+	owner string
+}
+
+// checkPortsAvailable checks the fixed local ports are not in use before forwarding or publishing them, so that the collision
+// fails the setup early with the user of the port, instead of the cryptic binding error after the environment is started.
+func checkPortsAvailable(ports []fixedPort, lister containerLister) error {
+	inUse := make([]string, 0)
+	for _, p := range ports {
+		listener, err := net.Listen("tcp", net.JoinHostPort(p.address, strconv.Itoa(p.port)))
+		if err == nil {
+			listener.Close()
+			continue
+		}
+		inUse = append(inUse, fmt.Sprintf("port %d of %s is already in use by %s", p.port, p.owner, portUser(lister, p.port)))
+	}
+	if len(inUse) > 0 {
+		return fmt.Errorf("%s", strings.Join(inUse, "; "))
+	}
+	return nil
+}
+
+// portUser finds the container publishing the port, such as the one of the previous run, or reports another process.
+func portUser(lister containerLister, port int) string {
+	if lister == nil {
+		return "another process"
+	}
+	containers, err := lister.ContainerList(context.Background(), types.ContainerListOptions{})
+	if err != nil {
+		return "another process"
+	}
+	for i := range containers {
+		for _, p := range containers[i].Ports {
+			if int(p.PublicPort) == port && len(containers[i].Names) > 0 {
+				return fmt.Sprintf("container %s", strings.TrimPrefix(containers[i].Names[0], "/"))
+			}
+		}
+	}
+	return "another process"
+}
+
+// kindFixedPorts returns the fixed local ports of the port-forwards, such as `8080:12800`,
+// and the host ports of the `extraPortMappings` of the kind config if the cluster is going to be created.
+func kindFixedPorts(kindSetup *config.KindSetup, kindConfigFile string, createCluster bool) ([]fixedPort, error) {
+	ports := make([]fixedPort, 0)
+	for _, expose := range kindSetup.ExposePorts {
+		if expose.Mode == constant.ExposeModeNodePort {
+			continue
+		}
+		address := expose.Address
+		if address == "" {
+			address = "localhost"
+		}
+		for _, p := range strings.Split(expose.Port, ",") {
+			local, _, found := strings.Cut(p, ":")
+			if !found || local == "" {
+				continue
+			}
+			port, err := strconv.Atoi(local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid local port %s of %s: %v", local, expose.Resource, err)
+			}
+			if port != 0 {
+				ports = append(ports, fixedPort{address: address, port: port, owner: expose.Resource})
+			}
+		}
+	}
+
+	if !createCluster || kindConfigFile == "" {
+		return ports, nil
+	}
+	mappings, err := util.GetKindPortMappings(kindConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the port mappings of the kind config %s: %v", kindConfigFile, err)
+	}
+	for _, mapping := range mappings {
+		if mapping.HostPort <= 0 || (mapping.Protocol != "" && mapping.Protocol != v1alpha4.PortMappingProtocolTCP) {
+			continue
+		}
+		ports = append(ports, fixedPort{
+			address: mapping.ListenAddress,
+			port:    int(mapping.HostPort),
+			owner:   fmt.Sprintf("the extraPortMappings of container port %d", mapping.ContainerPort),
+		})
+	}
+	return ports, nil
+}
+
+// composeFixedPorts returns the fixed host ports published by the services, such as `8080:80`, `127.0.0.1:8080:80`,
+// `8080-8081:80-81` or the long syntax with `published`, the env vars in the ports are expanded like compose.
+func composeFixedPorts(services map[string]any) []fixedPort {
+	ports := make([]fixedPort, 0)
+	for service, content := range services {
+		serviceConfig, ok := content.(map[any]any)
+		if !ok {
+			continue
+		}
+		portList, ok := serviceConfig["ports"].([]any)
+		if !ok {
+			continue
+		}
+		for _, portConfig := range portList {
+			address, published := composePublishedPorts(portConfig)
+			for _, port := range published {
+				ports = append(ports, fixedPort{address: address, port: port, owner: fmt.Sprintf("service %s", service)})
+			}
+		}
+	}
+	return ports
+}
+
+// composePublishedPorts parses the host address and the fixed host ports of the port config, the UDP ports are ignored.
+func composePublishedPorts(portConfig any) (address string, ports []int) {
+	switch conf := portConfig.(type) {
+	case string:
+		spec, protocol, _ := strings.Cut(os.ExpandEnv(conf), "/")
+		if protocol != "" && protocol != "tcp" {
+			return "", nil
+		}
+		// the container port is the last part, the host address may be IPv6 such as `[::1]:8080:80`
+		i := strings.LastIndex(spec, ":")
+		if i == -1 {
+			return "", nil
+		}
+		host := spec[:i]
+		if j := strings.LastIndex(host, ":"); j != -1 {
+			address, host = strings.Trim(host[:j], "[]"), host[j+1:]
+		}
+		return address, portRange(host)
+	case map[any]any:
+		if protocol, ok := conf["protocol"].(string); ok && protocol != "tcp" {
+			return "", nil
+		}
+		if hostIP, ok := conf["host_ip"].(string); ok {
+			address = os.ExpandEnv(hostIP)
+		}
+		return address, portRange(os.ExpandEnv(fmt.Sprint(conf["published"])))
+	}
+	return "", nil
+}
+
+// portRange parses the port or the port range such as `8080-8081`, the empty or invalid ports are ignored.
+func portRange(s string) []int {
+	start, end, isRange := strings.Cut(s, "-")
+	if !isRange {
+		end = start
+	}
+	from, err1 := strconv.Atoi(start)
+	to, err2 := strconv.Atoi(end)
+	if err1 != nil || err2 != nil || from <= 0 || to < from {
+		return nil
+	}
+	ports := make([]int, 0, to-from+1)
+	for port := from; port <= to; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
@@ -0,0 +1,114 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+)
+
+func TestCheckPortsAvailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if err := checkPortsAvailable([]fixedPort{{address: "127.0.0.1", port: 0, owner: "pod/foo"}}, nil); err != nil {
+		t.Errorf("checkPortsAvailable() error = %v, the available port should pass", err)
+	}
+
+	tests := []struct {
+		name   string
+		lister containerLister
+		want   string
+	}{
+		{name: "another process", want: "already in use by another process"},
+		{
+			name:   "container",
+			lister: staticContainerLister{{Names: []string{"/e2e-oap-1"}, Ports: []types.Port{{PrivatePort: 12800, PublicPort: uint16(port)}}}},
+			want:   "already in use by container e2e-oap-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPortsAvailable([]fixedPort{{address: "127.0.0.1", port: port, owner: "service oap"}}, tt.lister)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("checkPortsAvailable() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeFixedPorts(t *testing.T) {
+	t.Setenv("OAP_PORT", "12800")
+	services := map[string]any{
+		"oap": map[any]any{"ports": []any{
+			12800,
+			"${OAP_PORT}:12800",
+			"127.0.0.1:11800:11800",
+			"[::1]:9090:9090",
+			"8080-8081:80-81",
+			"::1234",
+			"5353:53/udp",
+			map[any]any{"target": 80, "published": 8090, "host_ip": "0.0.0.0"},
+			map[any]any{"target": 80},
+		}},
+		"ui": map[any]any{"image": "ui"},
+	}
+	got := composeFixedPorts(services)
+	sort.Slice(got, func(i, j int) bool { return got[i].port < got[j].port })
+	want := []fixedPort{
+		{address: "", port: 8080, owner: "service oap"},
+		{address: "", port: 8081, owner: "service oap"},
+		{address: "0.0.0.0", port: 8090, owner: "service oap"},
+		{address: "::1", port: 9090, owner: "service oap"},
+		{address: "127.0.0.1", port: 11800, owner: "service oap"},
+		{address: "", port: 12800, owner: "service oap"},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(fixedPort{})); diff != "" {
+		t.Errorf("composeFixedPorts() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestKindFixedPorts(t *testing.T) {
+	kindSetup := &config.KindSetup{ExposePorts: []config.KindExposePort{
+		{Resource: "service/oap", Port: "12800,8080:12801", Address: "0.0.0.0"},
+		{Resource: "pod/ui", Port: ":8080,9090:80"},
+		{Resource: "service/gateway", Port: "30080", Mode: "node-port"},
+	}}
+	got, err := kindFixedPorts(kindSetup, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []fixedPort{
+		{address: "0.0.0.0", port: 8080, owner: "service/oap"},
+		{address: "localhost", port: 9090, owner: "pod/ui"},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(fixedPort{})); diff != "" {
+		t.Errorf("kindFixedPorts() mismatch (-want +got):\n%s", diff)
+	}
+}
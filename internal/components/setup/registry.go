@@ -0,0 +1,151 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package setup
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/apache/skywalking-infra-e2e/internal/config"
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+	"github.com/apache/skywalking-infra-e2e/internal/util"
+)
+
+const dockerHubRegistry = "docker.io"
+
+// registryAuths resolves the credentials of the image registries, the registries configured in
+// setup.registries take precedence over the ones in the docker config file.
+type registryAuths map[string]types.AuthConfig
+
+func newRegistryAuths(registries []config.Registry) registryAuths {
+	auths := loadDockerConfigAuths()
+	for _, r := range registries {
+		server := normalizeRegistry(os.ExpandEnv(r.Server))
+		auths[server] = types.AuthConfig{
+			Username:      os.ExpandEnv(r.Username),
+			Password:      os.ExpandEnv(r.Password),
+			ServerAddress: server,
+		}
+	}
+	return auths
+}
+
+// encodedAuthFor returns the encoded credential which could be used in the image pull options,
+// returns empty string when there is no credential for the image registry.
+func (a registryAuths) encodedAuthFor(image string) (string, error) {
+	auth, ok := a[imageRegistry(image)]
+	if !ok {
+		return "", nil
+	}
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// authenticatedImages filters the images which have credentials for their registries.
+func (a registryAuths) authenticatedImages(images []string) []string {
+	result := make([]string, 0)
+	for _, image := range images {
+		if _, ok := a[imageRegistry(image)]; ok {
+			result = append(result, image)
+		}
+	}
+	return result
+}
+
+// loadDockerConfigAuths reads the credentials from $DOCKER_CONFIG/config.json or ~/.docker/config.json,
+// the credential helpers are not supported.
+func loadDockerConfigAuths() registryAuths {
+	auths := make(registryAuths)
+
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		dir = filepath.Join(util.UserHomeDir(), ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return auths
+	}
+
+	dockerConfig := struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(data, &dockerConfig); err != nil {
+		logger.Log.Warnf("failed to parse docker config file in %s: %v", dir, err)
+		return auths
+	}
+
+	for server, entry := range dockerConfig.Auths {
+		if entry.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			logger.Log.Warnf("failed to decode docker credential of %s: %v", server, err)
+			continue
+		}
+		userAndPassword := strings.SplitN(string(decoded), ":", 2)
+		if len(userAndPassword) != 2 {
+			continue
+		}
+		server = normalizeRegistry(server)
+		auths[server] = types.AuthConfig{
+			Username:      userAndPassword[0],
+			Password:      userAndPassword[1],
+			ServerAddress: server,
+		}
+	}
+	return auths
+}
+
+// imageRegistry finds the registry domain of the image reference, such as `ghcr.io/apache/skywalking` is `ghcr.io`.
+func imageRegistry(image string) string {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return dockerHubRegistry
+	}
+	domain := image[:i]
+	if !strings.ContainsAny(domain, ".:") && domain != "localhost" {
+		return dockerHubRegistry
+	}
+	return normalizeRegistry(domain)
+}
+
+// normalizeRegistry converts the registry address into the domain, such as `https://index.docker.io/v1/` is `docker.io`.
+func normalizeRegistry(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	if i := strings.Index(server, "/"); i != -1 {
+		server = server[:i]
+	}
+	switch server {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHubRegistry
+	}
+	return server
+}
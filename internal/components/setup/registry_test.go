@@ -0,0 +1,97 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package setup
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestImageRegistry(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "nginx", want: "docker.io"},
+		{image: "library/nginx", want: "docker.io"},
+		{image: "ghcr.io/x/y", want: "ghcr.io"},
+		{image: "localhost:5000/x", want: "localhost:5000"},
+		{image: "localhost/x", want: "localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := imageRegistry(tt.image); got != tt.want {
+				t.Errorf("imageRegistry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRegistry(t *testing.T) {
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{server: "https://index.docker.io/v1/", want: "docker.io"},
+		{server: "registry-1.docker.io", want: "docker.io"},
+		{server: "http://localhost:5000", want: "localhost:5000"},
+		{server: "ghcr.io", want: "ghcr.io"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			if got := normalizeRegistry(tt.server); got != tt.want {
+				t.Errorf("normalizeRegistry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDockerConfigAuths(t *testing.T) {
+	dir := t.TempDir()
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	content := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + encode("foo:bar") + `"},
+		"ghcr.io": {"auth": "` + encode("user:pass:word") + `"},
+		"invalid.io": {"auth": "` + encode("no-password") + `"},
+		"helper.io": {}
+	}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	want := registryAuths{
+		"docker.io": {Username: "foo", Password: "bar", ServerAddress: "docker.io"},
+		"ghcr.io":   {Username: "user", Password: "pass:word", ServerAddress: "ghcr.io"},
+	}
+	got := loadDockerConfigAuths()
+	if !cmp.Equal(got, want) {
+		t.Errorf("loadDockerConfigAuths() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+
+	auths := newRegistryAuths(nil)
+	if images := auths.authenticatedImages([]string{"nginx", "ghcr.io/x/y", "quay.io/x/y"}); !cmp.Equal(images, []string{"nginx", "ghcr.io/x/y"}) {
+		t.Errorf("authenticatedImages() = %v", images)
+	}
+}
@@ -18,6 +18,17 @@
 
 package trigger
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+)
+
 type Action interface {
 	// Do performs the trigger action according to the settings,
 	// and returns an error channel, the controller waits for the
@@ -37,3 +48,187 @@ type Action interface {
 	// Stop stops the scheduled actions.
 	Stop()
 }
+
+// normalizeTimes converts the non-positive times into a large number to simulate infinite runs.
+func normalizeTimes(times int) int {
+	if times <= 0 {
+		logger.Log.Warnf("trigger times (%d) is invalid (<=0). It has been set to a large number (%d) to simulate infinite runs. "+
+			"consider using a positive value.", times, math.MaxInt32)
+		return math.MaxInt32
+	}
+	return times
+}
+
+// schedule runs the execute function with the interval until it has been executed the given times or stopped,
+// the first success or the last error is sent to the returned channel.
+func schedule(description string, interval time.Duration, times int, stopCh chan struct{}, execute func() error) chan error {
+	t := time.NewTicker(interval)
+
+	var timesInfo string
+	if times == math.MaxInt32 {
+		timesInfo = "a very large number of times (practically until stopped)"
+	} else {
+		timesInfo = fmt.Sprintf("%d times", times)
+	}
+	logger.Log.Infof("trigger will %s %s with interval %s.", description, timesInfo, interval)
+
+	// buffered so that the result is never blocked if the controller stops waiting for it, such as on the deadline of the run
+	result := make(chan error, 1)
+	sent := false
+	executedCount := 0
+	go func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				logger.Log.Debugf("trigger executes the %d time.", executedCount)
+				err := execute()
+				executedCount++
+
+				// `err == nil`: if no error occurs, everything is OK and send `nil` to the channel to continue.
+				// `times == executedCount`: reach to the maximum retry count and send the `err`, no matter it's `nil` or not.
+				if !sent && (err == nil || times == executedCount) {
+					result <- err
+					sent = true
+					logger.Log.Infof("trigger has sent result after executed %d times with err: %v", executedCount, err)
+				}
+				if times != math.MaxInt32 && executedCount >= times {
+					logger.Log.Infof("trigger has completed %d executions and will stop.", executedCount)
+					return
+				}
+			case <-stopCh:
+				logger.Log.Infof("trigger was stopped manually after %d executions.", executedCount)
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// scheduleConcurrently runs the execute function by the concurrent workers, each of them executes it with the interval,
+// until the total successful executions or the total failed executions reach the given times, or stopped.
+// The first success, or the aggregated errors if the failures reach the times before any success, is sent to the returned channel.
+func scheduleConcurrently(description string, interval time.Duration, times, concurrency int, stopCh chan struct{},
+	execute func() error) chan error {
+	logger.Log.Infof("trigger will %s by %d workers until %d successes with interval %s.", description, concurrency, times, interval)
+
+	result := make(chan error, 1)
+	stats := &workerStats{times: times, start: time.Now(), result: result, done: make(chan struct{}), errs: make(map[string]int)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t := time.NewTicker(interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-t.C:
+					if !stats.record(execute()) {
+						return
+					}
+				case <-stats.done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		select {
+		case <-stopCh:
+			logger.Log.Infof("trigger was stopped manually.")
+			stats.finish()
+		case <-stats.done:
+		}
+	}()
+	go func() {
+		wg.Wait()
+		stats.report()
+	}()
+
+	return result
+}
+
+// workerStats aggregates the results of the concurrent workers.
+type workerStats struct {
+	sync.Mutex
+	times     int
+	start     time.Time
+	succeeded int
+	failed    int
+	// errs are the counts of the distinct error messages.
+	errs     map[string]int
+	result   chan error
+	sent     bool
+	done     chan struct{}
+	finished bool
+}
+
+// record records the result of an execution, returns false if the workers should stop.
+func (s *workerStats) record(err error) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return false
+	}
+	if err == nil {
+		s.succeeded++
+	} else {
+		s.failed++
+		s.errs[err.Error()]++
+	}
+
+	if !s.sent && err == nil {
+		s.result <- nil
+		s.sent = true
+	} else if !s.sent && s.failed >= s.times {
+		s.result <- s.aggregatedError()
+		s.sent = true
+	}
+	if s.succeeded >= s.times || s.failed >= s.times {
+		s.finishLocked()
+		return false
+	}
+	return true
+}
+
+func (s *workerStats) finish() {
+	s.Lock()
+	defer s.Unlock()
+	s.finishLocked()
+}
+
+func (s *workerStats) finishLocked() {
+	if !s.finished {
+		s.finished = true
+		close(s.done)
+	}
+}
+
+// aggregatedError combines the distinct errors with their counts, such as `3 time(s): connection refused`.
+func (s *workerStats) aggregatedError() error {
+	messages := make([]string, 0, len(s.errs))
+	for message := range s.errs {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	errs := make([]error, 0, len(messages))
+	for _, message := range messages {
+		errs = append(errs, fmt.Errorf("%d time(s): %s", s.errs[message], message))
+	}
+	return errors.Join(errs...)
+}
+
+// report logs the achieved throughput of the successful executions and the aggregated errors.
+func (s *workerStats) report() {
+	s.Lock()
+	defer s.Unlock()
+	elapsed := time.Since(s.start)
+	logger.Log.Infof("trigger has completed %d successful and %d failed executions in %s, throughput %.2f/s.",
+		s.succeeded, s.failed, elapsed.Round(time.Millisecond), float64(s.succeeded)/elapsed.Seconds())
+	if s.failed > 0 {
+		logger.Log.Warnf("trigger failed executions:\n%v", s.aggregatedError())
+	}
+}
@@ -0,0 +1,91 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package trigger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/logger"
+)
+
+type commandAction struct {
+	interval time.Duration
+	times    int
+	command  string
+	stopCh   chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func NewCommandAction(intervalStr string, times int, command string) (Action, error) {
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("trigger interval should be > 0, but was %s", interval)
+	}
+
+	if command == "" {
+		return nil, fmt.Errorf("trigger command should not be empty")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &commandAction{
+		interval: interval,
+		times:    normalizeTimes(times),
+		command:  command,
+		stopCh:   make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (c *commandAction) Do() chan error {
+	return schedule("run command", c.interval, c.times, c.stopCh, c.execute)
+}
+
+// Stop stops the scheduling and kills the running command.
+func (c *commandAction) Stop() {
+	c.cancel()
+	c.stopCh <- struct{}{}
+}
+
+// execute runs the command with the current environment variables, unlike the setup steps and verify queries,
+// the environment variables of the command are not propagated back, because it runs in the background of verify.
+func (c *commandAction) execute() error {
+	logger.Log.Debugf("run command %s.", c.command)
+	command := exec.CommandContext(c.ctx, "bash", "-ec", c.command)
+	command.Env = os.Environ()
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	command.Stdout, command.Stderr = &stdout, &stderr
+
+	if err := command.Run(); err != nil {
+		logger.Log.Errorf("run command error %v, stdout: %s, stderr: %s", err, stdout.String(), stderr.String())
+		return fmt.Errorf("run command failed: %v, stderr: %s", err, stderr.String())
+	}
+	logger.Log.Debugf("run command success, stdout: %s", stdout.String())
+	return nil
+}
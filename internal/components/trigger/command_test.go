@@ -0,0 +1,67 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package trigger
+
+import (
+	"testing"
+)
+
+func TestCommandAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		times      int
+		wantErr    bool
+		wantNewErr bool
+	}{
+		{
+			name:    "should succeed when the command exits with 0",
+			command: "exit 0",
+			times:   3,
+		},
+		{
+			name:    "should return the last error after reaching the times",
+			command: "echo failed >&2; exit 1",
+			times:   2,
+			wantErr: true,
+		},
+		{
+			name:       "should fail when the command is empty",
+			command:    "",
+			times:      1,
+			wantNewErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := NewCommandAction("10ms", tt.times, tt.command)
+			if (err != nil) != tt.wantNewErr {
+				t.Fatalf("NewCommandAction() error = %v, wantErr %v", err, tt.wantNewErr)
+			}
+			if tt.wantNewErr {
+				return
+			}
+			defer action.Stop()
+
+			if err := <-action.Do(); (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -19,30 +19,61 @@
 package trigger
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
+	"k8s.io/client-go/util/jsonpath"
+
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 )
 
 type httpAction struct {
-	interval      time.Duration
-	times         int
-	url           string
-	method        string
-	body          string
-	headers       map[string]string
-	executedCount int
-	stopCh        chan struct{}
-	client        *http.Client
+	interval time.Duration
+	times    int
+	url      string
+	method   string
+	body     string
+	headers  map[string]string
+	// capture are the JSONPath expressions of the env vars, which are validated when creating the action.
+	capture map[string]string
+	success *successMatcher
+	stopCh  chan struct{}
+	client  *http.Client
+	// concurrency is the number of the workers sending the requests concurrently, times is the total successful requests of them.
+	concurrency int
+}
+
+// SuccessCondition is the condition of the successful HTTP response, the response should meet all the declared conditions.
+type SuccessCondition struct {
+	// Status is the allowed status codes, defaults to 200.
+	Status []int
+	// Headers are the regular expressions the response headers should match, the headers should be present.
+	Headers map[string]string
+	// Body are the regular expressions the values of the JSONPath expressions on the response should match.
+	Body map[string]string
+}
+
+// successMatcher is the compiled SuccessCondition.
+type successMatcher struct {
+	status  []int
+	headers map[string]*regexp.Regexp
+	body    map[string]*jsonPathMatcher
+}
+
+type jsonPathMatcher struct {
+	expression string
+	value      *regexp.Regexp
 }
 
-func NewHTTPAction(intervalStr string, times int, url, method, body string, headers map[string]string) (Action, error) {
+func NewHTTPAction(intervalStr string, times, concurrency int, url, method, body string, headers, capture map[string]string,
+	success SuccessCondition) (Action, error) {
 	interval, err := time.ParseDuration(intervalStr)
 	if err != nil {
 		return nil, err
@@ -51,68 +82,44 @@ func NewHTTPAction(intervalStr string, times int, url, method, body string, head
 	if interval <= 0 {
 		return nil, fmt.Errorf("trigger interval should be > 0, but was %s", interval)
 	}
-
-	if times <= 0 {
-		logger.Log.Warnf("trigger times (%d) is invalid (<=0). It has been set to a large number (%d) to simulate infinite runs. "+
-			"consider using a positive value.", times, math.MaxInt32)
-		times = math.MaxInt32
+	if concurrency < 0 {
+		return nil, fmt.Errorf("trigger concurrency should be >= 0, but was %d", concurrency)
 	}
 
 	// there can be env variables in url, say, "http://${GATEWAY_HOST}:${GATEWAY_PORT}/test"
 	url = os.ExpandEnv(url)
 
+	for env, expression := range capture {
+		if err := jsonpath.New(env).Parse(expression); err != nil {
+			return nil, fmt.Errorf("invalid capture expression %s of %s: %v", expression, env, err)
+		}
+	}
+
+	matcher, err := newSuccessMatcher(success)
+	if err != nil {
+		return nil, err
+	}
+
 	return &httpAction{
-		interval:      interval,
-		times:         times,
-		url:           url,
-		method:        strings.ToUpper(method),
-		body:          body,
-		headers:       headers,
-		executedCount: 0,
-		stopCh:        make(chan struct{}, 1),
-		client:        &http.Client{},
+		interval:    interval,
+		times:       normalizeTimes(times),
+		concurrency: concurrency,
+		url:         url,
+		method:      strings.ToUpper(method),
+		body:        body,
+		headers:     headers,
+		capture:     capture,
+		success:     matcher,
+		stopCh:      make(chan struct{}, 1),
+		client:      &http.Client{},
 	}, nil
 }
 
 func (h *httpAction) Do() chan error {
-	t := time.NewTicker(h.interval)
-
-	var timesInfo string
-	if h.times == math.MaxInt32 {
-		timesInfo = "a very large number of times (practically until stopped)"
-	} else {
-		timesInfo = fmt.Sprintf("%d times", h.times)
-	}
-	logger.Log.Infof("trigger will request URL %s %s with interval %s.", h.url, timesInfo, h.interval)
-
-	result := make(chan error)
-	sent := false
-	go func() {
-		defer t.Stop()
-		for {
-			select {
-			case <-t.C:
-				err := h.execute()
-
-				// `err == nil`: if no error occurs, everything is OK and send `nil` to the channel to continue.
-				// `h.times == h.executedCount`: reach to the maximum retry count and send the `err`, no matter it's `nil` or not.
-				if !sent && (err == nil || h.times == h.executedCount) {
-					result <- err
-					sent = true
-					logger.Log.Infof("trigger has sent result after executed %d requests with err: %v", h.executedCount, err)
-				}
-				if h.times != math.MaxInt32 && h.executedCount >= h.times {
-					logger.Log.Infof("trigger has completed %d requests and will stop.", h.executedCount)
-					return
-				}
-			case <-h.stopCh:
-				logger.Log.Infof("trigger was stopped manually after %d executions.", h.executedCount)
-				return
-			}
-		}
-	}()
-
-	return result
+	if h.concurrency > 1 {
+		return scheduleConcurrently(fmt.Sprintf("request URL %s", h.url), h.interval, h.times, h.concurrency, h.stopCh, h.execute)
+	}
+	return schedule(fmt.Sprintf("request URL %s", h.url), h.interval, h.times, h.stopCh, h.execute)
 }
 
 func (h *httpAction) Stop() {
@@ -138,20 +145,138 @@ func (h *httpAction) execute() error {
 		logger.Log.Errorf("failed to create new request %v", err)
 		return err
 	}
-	logger.Log.Debugf("request URL %s the %d time.", h.url, h.executedCount)
+	logger.Log.Debugf("request URL %s.", h.url)
 	response, err := h.client.Do(req)
-	h.executedCount++
 	if err != nil {
 		logger.Log.Errorf("do request error %v", err)
 		return err
 	}
-	_, _ = io.ReadAll(response.Body)
+	body, _ := io.ReadAll(response.Body)
 	_ = response.Body.Close()
 
 	logger.Log.Debugf("do request %v response http code %v", h.url, response.StatusCode)
-	if response.StatusCode == http.StatusOK {
-		logger.Log.Debugf("do http action %+v success.", *h)
-		return nil
+	doc := responseDocument(response, body)
+	if err := h.success.match(response, doc); err != nil {
+		return fmt.Errorf("do request failed, %v", err)
+	}
+	logger.Log.Debugf("do http action %+v success.", *h)
+	h.captureResponse(doc)
+	return nil
+}
+
+// responseDocument builds the document of the response to evaluate the JSONPath expressions on, in the format of
+// `{"status": <code>, "headers": {<name>: <first value>}, "body": <JSON body or text>}`.
+func responseDocument(response *http.Response, body []byte) map[string]any {
+	headers := make(map[string]any, len(response.Header))
+	for name := range response.Header {
+		headers[name] = response.Header.Get(name)
+	}
+	var data any = string(body)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// keep the numbers as they are, such as the long ids
+	decoder.UseNumber()
+	var jsonBody any
+	if err := decoder.Decode(&jsonBody); err == nil {
+		data = jsonBody
+	}
+	return map[string]any{"status": response.StatusCode, "headers": headers, "body": data}
+}
+
+// captureResponse exports the captured fields of the response document as env vars.
+func (h *httpAction) captureResponse(doc map[string]any) {
+	for env, expression := range h.capture {
+		value, err := evaluateJSONPath(expression, doc)
+		if err != nil {
+			logger.Log.Warnf("failed to capture %s from the response: %v", env, err)
+			continue
+		}
+		if err := os.Setenv(env, value); err != nil {
+			logger.Log.Warnf("failed to export %s: %v", env, err)
+			continue
+		}
+		logger.Log.Debugf("captured %s=%s from the response", env, value)
+	}
+}
+
+// evaluateJSONPath evaluates the expression on the document, the expression is parsed for every evaluation because the parser
+// keeps the state of the evaluation, which can't be shared by the concurrent workers.
+func evaluateJSONPath(expression string, doc map[string]any) (string, error) {
+	parser := jsonpath.New(expression)
+	if err := parser.Parse(expression); err != nil {
+		return "", err
+	}
+	var value bytes.Buffer
+	if err := parser.Execute(&value, doc); err != nil {
+		return "", err
+	}
+	return value.String(), nil
+}
+
+func newSuccessMatcher(success SuccessCondition) (*successMatcher, error) {
+	matcher := &successMatcher{
+		status:  success.Status,
+		headers: make(map[string]*regexp.Regexp, len(success.Headers)),
+		body:    make(map[string]*jsonPathMatcher, len(success.Body)),
+	}
+	if len(matcher.status) == 0 {
+		matcher.status = []int{http.StatusOK}
+	}
+	for name, pattern := range success.Headers {
+		value, err := compileFullMatch(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success pattern %s of header %s: %v", pattern, name, err)
+		}
+		matcher.headers[name] = value
+	}
+	for expression, pattern := range success.Body {
+		if err := jsonpath.New(expression).Parse(expression); err != nil {
+			return nil, fmt.Errorf("invalid success expression %s: %v", expression, err)
+		}
+		value, err := compileFullMatch(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success pattern %s of %s: %v", pattern, expression, err)
+		}
+		matcher.body[expression] = &jsonPathMatcher{expression: expression, value: value}
+	}
+	return matcher, nil
+}
+
+// compileFullMatch compiles the regular expression matching the whole value, such as `ok` doesn't match `not ok`.
+func compileFullMatch(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+}
+
+// match checks the response meets all the conditions, returns the first unmet one.
+func (m *successMatcher) match(response *http.Response, doc map[string]any) error {
+	statusMatched := false
+	for _, status := range m.status {
+		if response.StatusCode == status {
+			statusMatched = true
+			break
+		}
+	}
+	if !statusMatched {
+		return fmt.Errorf("response status code: %d, expected: %v", response.StatusCode, m.status)
+	}
+
+	for name, value := range m.headers {
+		values, present := response.Header[http.CanonicalHeaderKey(name)]
+		if !present {
+			return fmt.Errorf("response header %s is absent", name)
+		}
+		if !value.MatchString(strings.Join(values, ",")) {
+			return fmt.Errorf("response header %s: %s doesn't match %s", name, strings.Join(values, ","), value)
+		}
+	}
+
+	for expression, matcher := range m.body {
+		actual, err := evaluateJSONPath(matcher.expression, doc)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate %s on the response: %v", expression, err)
+		}
+		if !matcher.value.MatchString(actual) {
+			return fmt.Errorf("response %s: %s doesn't match %s", expression, actual, matcher.value)
+		}
 	}
-	return fmt.Errorf("do request failed, response status code: %d", response.StatusCode)
+	return nil
 }
@@ -0,0 +1,242 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package trigger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPActionCapture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		_, _ = w.Write([]byte(`{"traceId": "abc", "segments": [{"id": 1234567890123456789}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		env        string
+		expression string
+		want       string
+		wantNewErr bool
+	}{
+		{
+			name:       "should capture the field of the JSON body",
+			env:        "CAPTURE_TRACE_ID",
+			expression: "{.body.traceId}",
+			want:       "abc",
+		},
+		{
+			name:       "should keep the long numbers as they are",
+			env:        "CAPTURE_SEGMENT_ID",
+			expression: "{.body.segments[0].id}",
+			want:       "1234567890123456789",
+		},
+		{
+			name:       "should capture the response header",
+			env:        "CAPTURE_REQUEST_ID",
+			expression: "{.headers['X-Request-Id']}",
+			want:       "req-1",
+		},
+		{
+			name:       "should capture the status code",
+			env:        "CAPTURE_STATUS",
+			expression: "{.status}",
+			want:       "200",
+		},
+		{
+			name:       "should fail when the expression is invalid",
+			env:        "CAPTURE_INVALID",
+			expression: "{.body[",
+			wantNewErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := NewHTTPAction("1ms", 1, 0, server.URL, http.MethodGet, "", nil, map[string]string{tt.env: tt.expression}, SuccessCondition{})
+			if (err != nil) != tt.wantNewErr {
+				t.Fatalf("NewHTTPAction() error = %v, wantNewErr %v", err, tt.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+			defer os.Unsetenv(tt.env)
+			if err := <-action.Do(); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if got := os.Getenv(tt.env); got != tt.want {
+				t.Errorf("captured %s = %q, want %q", tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPActionSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Trace-Id", "3f2a9c")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status": "accepted", "count": 3}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		success    SuccessCondition
+		wantErr    bool
+		wantNewErr bool
+	}{
+		{name: "should fail when the status is not 200 by default", wantErr: true},
+		{name: "should pass when the status is allowed", success: SuccessCondition{Status: []int{200, 202}}},
+		{
+			name: "should pass when all the conditions are met",
+			success: SuccessCondition{
+				Status:  []int{202},
+				Headers: map[string]string{"x-trace-id": "[0-9a-f]+"},
+				Body:    map[string]string{"{.body.status}": "accepted", "{.body.count}": "[1-9]"},
+			},
+		},
+		{
+			name:    "should fail when the header is absent",
+			success: SuccessCondition{Status: []int{202}, Headers: map[string]string{"X-Request-Id": ".*"}},
+			wantErr: true,
+		},
+		{
+			name:    "should fail when the body field doesn't match the whole value",
+			success: SuccessCondition{Status: []int{202}, Body: map[string]string{"{.body.status}": "accept"}},
+			wantErr: true,
+		},
+		{
+			name:       "should fail when the pattern is invalid",
+			success:    SuccessCondition{Headers: map[string]string{"X-Trace-Id": "["}},
+			wantNewErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := NewHTTPAction("1ms", 1, 0, server.URL, http.MethodGet, "", nil, nil, tt.success)
+			if (err != nil) != tt.wantNewErr {
+				t.Fatalf("NewHTTPAction() error = %v, wantNewErr %v", err, tt.wantNewErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := <-action.Do(); (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPActionConcurrency(t *testing.T) {
+	var requests, failures atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		if failures.Load() > 0 {
+			failures.Add(-1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		failures  int32
+		times     int
+		wantErr   string
+		wantTotal int32
+	}{
+		{name: "should stop after the total successful requests", times: 20, wantTotal: 20},
+		{
+			name:      "should aggregate the errors when all the requests failed",
+			failures:  100,
+			times:     6,
+			wantErr:   "6 time(s): do request failed, response status code: 503, expected: [200]",
+			wantTotal: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			failures.Store(tt.failures)
+			action, err := NewHTTPAction("1ms", tt.times, 4, server.URL, http.MethodGet, "", nil, nil, SuccessCondition{})
+			if err != nil {
+				t.Fatalf("NewHTTPAction() error = %v", err)
+			}
+			err = <-action.Do()
+			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Fatalf("Do() error = %v, want %q", err, tt.wantErr)
+			}
+			// the workers stop after the in-flight requests finish
+			time.Sleep(50 * time.Millisecond)
+			// the in-flight requests of the other workers may finish after the times is reached
+			if total := requests.Load(); total < tt.wantTotal || total >= tt.wantTotal+4 {
+				t.Errorf("requests = %d, want %d at least and less than %d", total, tt.wantTotal, tt.wantTotal+4)
+			}
+		})
+	}
+}
+
+func TestHTTPActionConcurrencyWithBodyAndCapture(t *testing.T) {
+	var requests atomic.Int32
+	// the responses of every 4 requests are sent together, so that the workers evaluate them at the same time
+	var lock sync.Mutex
+	barrier := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		lock.Lock()
+		current := barrier
+		if requests.Add(1)%4 == 0 {
+			close(barrier)
+			barrier = make(chan struct{})
+		}
+		lock.Unlock()
+		select {
+		case <-current:
+		case <-time.After(100 * time.Millisecond):
+		}
+		_, _ = w.Write([]byte(`{"items": [{"id": "1", "status": "ok"}, {"id": "2", "status": "ok"}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("E2E_TRIGGER_CAPTURED_ID", "")
+	// the parser keeps the state of the range expressions during the evaluation
+	action, err := NewHTTPAction("1ms", 400, 4, server.URL, http.MethodGet, "", nil,
+		map[string]string{"E2E_TRIGGER_CAPTURED_ID": "{range .body.items[*]}{.id},{end}"},
+		SuccessCondition{Body: map[string]string{"{range .body.items[*]}{.status}{end}": "okok"}})
+	if err != nil {
+		t.Fatalf("NewHTTPAction() error = %v", err)
+	}
+	if err := <-action.Do(); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	// the body conditions and the captures are evaluated by all the workers concurrently
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() < 400 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	action.Stop()
+	if got := os.Getenv("E2E_TRIGGER_CAPTURED_ID"); got != "1,2," {
+		t.Errorf("captured E2E_TRIGGER_CAPTURED_ID = %s, want 1,2,", got)
+	}
+}
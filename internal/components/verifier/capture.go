@@ -0,0 +1,70 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Capture evaluates the JSONPath expressions on the actual data, and returns the captured values by the env var names,
+// such as the generated ID queried by a case, which is referred by the cases depending on it.
+func Capture(actualData string, capture map[string]string) (map[string]string, error) {
+	var data any
+	if err := yaml.Unmarshal([]byte(actualData), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse the actual data to capture: %v", err)
+	}
+	data = stringKeys(data)
+
+	captured := make(map[string]string, len(capture))
+	for name, expression := range capture {
+		parser := jsonpath.New(name)
+		if err := parser.Parse(expression); err != nil {
+			return nil, fmt.Errorf("invalid capture expression %s of %s: %v", expression, name, err)
+		}
+		var value bytes.Buffer
+		if err := parser.Execute(&value, data); err != nil {
+			return nil, fmt.Errorf("failed to capture %s by %s: %v", name, expression, err)
+		}
+		captured[name] = value.String()
+	}
+	return captured, nil
+}
+
+// stringKeys converts the keys of the YAML maps into strings, so that the JSONPath expressions could be evaluated on them.
+func stringKeys(v any) any {
+	switch value := v.(type) {
+	case map[any]any:
+		result := make(map[string]any, len(value))
+		for key, item := range value {
+			result[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return result
+	case []any:
+		result := make([]any, 0, len(value))
+		for _, item := range value {
+			result = append(result, stringKeys(item))
+		}
+		return result
+	}
+	return v
+}
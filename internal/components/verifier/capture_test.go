@@ -0,0 +1,77 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCapture(t *testing.T) {
+	actualData := `
+traces:
+  - traceId: 1.2.3
+    spans: 3
+  - traceId: 4.5.6
+    spans: 1
+total: 2
+`
+	tests := []struct {
+		name    string
+		capture map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "fields",
+			capture: map[string]string{"TRACE_ID": "{.traces[0].traceId}", "TOTAL": "{.total}"},
+			want:    map[string]string{"TRACE_ID": "1.2.3", "TOTAL": "2"},
+		},
+		{
+			name:    "filter",
+			capture: map[string]string{"TRACE_ID": "{.traces[?(@.spans==1)].traceId}"},
+			want:    map[string]string{"TRACE_ID": "4.5.6"},
+		},
+		{
+			name:    "missing field",
+			capture: map[string]string{"SEGMENT_ID": "{.segmentId}"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid expression",
+			capture: map[string]string{"TRACE_ID": "{.traces[0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Capture(actualData, tt.capture)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Capture() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Capture() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
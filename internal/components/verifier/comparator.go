@@ -0,0 +1,151 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+// regexpLinePrefix marks the line of the expected text as a regular expression, which matches the whole actual line.
+const regexpLinePrefix = "regexp:"
+
+// compare verifies the actual data against the expected template by the comparator, the YAML comparator is used if it's empty.
+func compare(comparator, actualData, expectedTemplate string, ignorePaths [][]string) error {
+	switch comparator {
+	case "", constant.ComparatorYAML:
+		return verify(actualData, expectedTemplate, ignorePaths)
+	case constant.ComparatorJSON:
+		return verifyJSON(actualData, expectedTemplate, ignorePaths)
+	case constant.ComparatorText:
+		return verifyText(actualData, expectedTemplate)
+	case constant.ComparatorCSV:
+		return verifyCSV(actualData, expectedTemplate)
+	}
+	return fmt.Errorf("unsupported comparator %s, should be one of %s, %s, %s or %s", comparator,
+		constant.ComparatorYAML, constant.ComparatorJSON, constant.ComparatorText, constant.ComparatorCSV)
+}
+
+// verifyJSON parses both the actual data and the rendered expected data as JSON strictly.
+func verifyJSON(actualData, expectedTemplate string, ignorePaths [][]string) error {
+	var actual any
+	if err := json.Unmarshal([]byte(actualData), &actual); err != nil {
+		return fmt.Errorf("failed to unmarshal actual data: %v", err)
+	}
+
+	rendered, err := render(expectedTemplate, actual)
+	if err != nil {
+		return err
+	}
+
+	var expected any
+	if err := json.Unmarshal(rendered, &expected); err != nil {
+		return fmt.Errorf("failed to unmarshal expected data: %v", err)
+	}
+	return compareData(expected, actual, ignorePaths)
+}
+
+// verifyText compares the actual data line by line, the template is rendered with the actual lines, and the expected lines
+// with the `regexp:` prefix are regular expressions matching the whole actual lines.
+func verifyText(actualData, expectedTemplate string) error {
+	actual := textLines(actualData)
+	rendered, err := render(expectedTemplate, actual)
+	if err != nil {
+		return err
+	}
+	expected := textLines(string(rendered))
+
+	// the matched lines are replaced by the patterns, so that the diff only contains the mismatched lines
+	got := append([]string{}, actual...)
+	for i := 0; i < len(expected) && i < len(got); i++ {
+		if !strings.HasPrefix(expected[i], regexpLinePrefix) {
+			continue
+		}
+		pattern, err := regexp.Compile("^(?:" + strings.TrimSpace(strings.TrimPrefix(expected[i], regexpLinePrefix)) + ")$")
+		if err != nil {
+			return fmt.Errorf("failed to parse the regular expression of line %d: %v", i+1, err)
+		}
+		if pattern.MatchString(got[i]) {
+			got[i] = expected[i]
+		}
+	}
+	if !cmp.Equal(expected, got) {
+		return &MismatchError{diff: fmt.Sprintf("mismatch (-want +got):\n%s", cmp.Diff(expected, got))}
+	}
+	return nil
+}
+
+// textLines splits the text into lines, the trailing whitespaces of the lines and the trailing empty lines are removed,
+// such as the padding of the table columns.
+func textLines(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// verifyCSV parses both the actual data and the rendered expected data as the rows of CSV, the template is rendered with
+// the actual rows, so that the cells could be referred to by `index`.
+func verifyCSV(actualData, expectedTemplate string) error {
+	actual, err := csvRecords(actualData)
+	if err != nil {
+		return fmt.Errorf("failed to parse actual data: %v", err)
+	}
+
+	rendered, err := render(expectedTemplate, actual)
+	if err != nil {
+		return err
+	}
+
+	expected, err := csvRecords(string(rendered))
+	if err != nil {
+		return fmt.Errorf("failed to parse expected data: %v", err)
+	}
+	if !cmp.Equal(expected, actual) {
+		return &MismatchError{diff: fmt.Sprintf("mismatch (-want +got):\n%s", cmp.Diff(expected, actual))}
+	}
+	return nil
+}
+
+// csvRecords parses the CSV rows, the rows could have different numbers of cells and the spaces around the cells are trimmed.
+func csvRecords(data string) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+	}
+	return records, nil
+}
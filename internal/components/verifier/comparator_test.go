@@ -0,0 +1,118 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"testing"
+)
+
+func TestVerifyWith(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator string
+		actual     string
+		expected   string
+		wantErr    bool
+	}{
+		{
+			name:       "json",
+			comparator: "json",
+			actual:     `{"service": "oap", "count": 2}`,
+			expected:   `{"service": "oap", "count": {{ .count }}}`,
+		},
+		{
+			name:       "json rejects yaml",
+			comparator: "json",
+			actual:     "service: oap",
+			expected:   "service: oap",
+			wantErr:    true,
+		},
+		{
+			name:       "text",
+			comparator: "text",
+			actual:     "ID   NAME    \nsvc1 oap     \n\n",
+			expected:   "ID   NAME\nsvc1 oap\n",
+		},
+		{
+			name:       "text regexp line",
+			comparator: "text",
+			actual:     "ID   NAME\nsvc1 oap-7d9f\n",
+			expected:   "ID   NAME\nregexp: svc\\d+ oap-[a-z0-9]+\n",
+		},
+		{
+			name:       "text regexp line mismatch",
+			comparator: "text",
+			actual:     "ID   NAME\nsvc1 mysql\n",
+			expected:   "ID   NAME\nregexp: svc\\d+ oap-[a-z0-9]+\n",
+			wantErr:    true,
+		},
+		{
+			name:       "text missing line",
+			comparator: "text",
+			actual:     "ID   NAME\n",
+			expected:   "ID   NAME\nsvc1 oap\n",
+			wantErr:    true,
+		},
+		{
+			name:       "text template",
+			comparator: "text",
+			actual:     "ID   NAME\nsvc1 oap\n",
+			expected:   "ID   NAME\n{{ index . 1 }}\n",
+		},
+		{
+			name:       "csv",
+			comparator: "csv",
+			actual:     "id, name\nsvc1, oap\n",
+			expected:   "id,name\nsvc1,oap\n",
+		},
+		{
+			name:       "csv template",
+			comparator: "csv",
+			actual:     "id,name\nsvc1,oap-7d9f\n",
+			expected:   "id,name\nsvc1,{{ regexp (index (index . 1) 1) \"oap-.+\" }}\n",
+		},
+		{
+			name:       "csv mismatch",
+			comparator: "csv",
+			actual:     "id,name\nsvc1,oap\n",
+			expected:   "id,name\nsvc2,oap\n",
+			wantErr:    true,
+		},
+		{
+			name:       "unsupported",
+			comparator: "xml",
+			actual:     "<oap/>",
+			expected:   "<oap/>",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyWith(tt.comparator, tt.actual, tt.expected, nil); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyWith() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWithIgnoringJSON(t *testing.T) {
+	actual := `{"service": "oap", "start": 1700000000}`
+	if err := VerifyWith("json", actual, `{"service": "oap", "start": 1}`, []string{"$.start"}); err != nil {
+		t.Errorf("VerifyWith() error = %v", err)
+	}
+}
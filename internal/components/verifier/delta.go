@@ -0,0 +1,172 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Delta subtracts the numbers in the baseline data from the ones in the current data at the same position,
+// so that the increase of the counters between two queries could be verified by the expected template.
+// The elements of the lists are paired by the non-numeric fields, such as the name and labels of the metrics,
+// the numbers without the paired baseline fail the delta, unless fromZero is true, which treats them as increased from 0.
+// The numeric strings, such as the values returned by MQE, are subtracted as numbers too, except the unchanged ones.
+func Delta(baselineData, currentData string, fromZero bool) (string, error) {
+	var baseline, current any
+	if err := yaml.Unmarshal([]byte(baselineData), &baseline); err != nil {
+		return "", fmt.Errorf("failed to unmarshal baseline data: %v", err)
+	}
+	if err := yaml.Unmarshal([]byte(currentData), &current); err != nil {
+		return "", fmt.Errorf("failed to unmarshal current data: %v", err)
+	}
+
+	result, err := delta(baseline, current, "", fromZero)
+	if err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func delta(baseline, current any, path string, fromZero bool) (any, error) {
+	switch c := current.(type) {
+	case map[any]any:
+		b, _ := baseline.(map[any]any)
+		result := make(map[any]any, len(c))
+		for k, v := range c {
+			d, err := delta(b[k], v, fmt.Sprintf("%s.%v", path, k), fromZero)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = d
+		}
+		return result, nil
+	case []any:
+		b, _ := baseline.([]any)
+		result := make([]any, len(c))
+		for i, v := range c {
+			d, err := delta(pairedElement(b, i, v), v, fmt.Sprintf("%s[%d]", path, i), fromZero)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = d
+		}
+		return result, nil
+	}
+
+	c, ok := toNumber(current)
+	if !ok {
+		return current, nil
+	}
+	b, ok := toNumber(baseline)
+	// the numeric strings without the baseline or unchanged are usually the identifiers, such as the labels, rather than the counters
+	if isString(current) && (!ok || current == baseline) {
+		return current, nil
+	}
+	if !ok {
+		// the absolute value of the new series is not the increase, unless it's known to start from 0
+		if !fromZero {
+			return nil, fmt.Errorf("the number %v at %s has no baseline to subtract", current, path)
+		}
+		b = 0
+		baseline = 0
+	}
+	if ci, ok := toInt(current); ok {
+		if bi, ok := toInt(baseline); ok {
+			return int(ci - bi), nil
+		}
+	}
+	return c - b, nil
+}
+
+// pairedElement finds the element in the baseline list paired with the current element,
+// the maps are paired by the non-numeric fields, and the other elements are paired by the index.
+func pairedElement(baseline []any, index int, current any) any {
+	if c, ok := current.(map[any]any); ok {
+		for _, b := range baseline {
+			if m, ok := b.(map[any]any); ok && sameIdentity(m, c) {
+				return m
+			}
+		}
+		return nil
+	}
+	if index < len(baseline) {
+		return baseline[index]
+	}
+	return nil
+}
+
+// sameIdentity checks the non-numeric fields of the maps are the same, the numeric strings are part of the identity.
+func sameIdentity(a, b map[any]any) bool {
+	return identity(a) == identity(b)
+}
+
+func identity(m map[any]any) string {
+	fields := make(map[any]any, len(m))
+	for k, v := range m {
+		// the numeric strings are kept as the identity, as they're more likely the labels than the counters
+		if _, ok := toNumber(v); !ok || isString(v) {
+			fields[k] = v
+		}
+	}
+	// the keys of the maps are sorted by yaml.Marshal, so the result is stable
+	data, _ := yaml.Marshal(fields)
+	return string(data)
+}
+
+func isString(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func toInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case string:
+		i, err := strconv.ParseInt(n, 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,133 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDelta(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline string
+		current  string
+		fromZero bool
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "should subtract the numbers at the same position",
+			baseline: "count: 10\nrate: 0.5\nname: foo\n",
+			current:  "count: 110\nrate: 1.5\nname: foo\n",
+			want:     "count: 100\nrate: 1\nname: foo\n",
+		},
+		{
+			name: "should pair the list elements by the non-numeric fields",
+			baseline: `
+metrics:
+  - name: http_requests_total
+    labels: {code: "500"}
+    value: 3
+  - name: http_requests_total
+    labels: {code: "200"}
+    value: 100
+`,
+			current: `
+metrics:
+  - name: http_requests_total
+    labels: {code: "200"}
+    value: 250
+  - name: http_requests_total
+    labels: {code: "404"}
+    value: 7
+  - name: http_requests_total
+    labels: {code: "500"}
+    value: 3
+`,
+			fromZero: true,
+			want: `
+metrics:
+  - name: http_requests_total
+    labels: {code: "200"}
+    value: 150
+  - name: http_requests_total
+    labels: {code: "404"}
+    value: 7
+  - name: http_requests_total
+    labels: {code: "500"}
+    value: 0
+`,
+		},
+		{
+			name:     "should pair the scalar list elements by the index",
+			baseline: "values: [1, 2]\n",
+			current:  "values: [5, 7, 9]\n",
+			fromZero: true,
+			want:     "values: [4, 5, 9]\n",
+		},
+		{
+			name:     "should fail with the number without baseline",
+			baseline: "metrics: [{name: foo, value: 1}]\n",
+			current:  "metrics: [{name: foo, value: 2}, {name: bar, value: 500}]\n",
+			wantErr:  true,
+		},
+		{
+			name:     "should subtract the numeric strings",
+			baseline: "value: {a: \"100\", b: \"0.5\"}\nvalues: [\"1\", \"2\"]\n",
+			current:  "value: {a: \"350\", b: \"1.5\"}\nvalues: [\"4\", \"2\"]\n",
+			want:     "value: {a: 250, b: 1}\nvalues: [3, \"2\"]\n",
+		},
+		{
+			name:     "should keep the unchanged numeric strings",
+			baseline: "values: [{label: a, value: 1}]\n",
+			current:  "values: [{label: a, value: 3}, {label: \"200\", value: 2}]\n",
+			fromZero: true,
+			want:     "values: [{label: a, value: 2}, {label: \"200\", value: 2}]\n",
+		},
+		{
+			name:     "should fail with invalid baseline",
+			baseline: "{",
+			current:  "count: 1\n",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Delta(tt.baseline, tt.current, tt.fromZero)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Delta() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var gotData, wantData any
+			if err := yaml.Unmarshal([]byte(got), &gotData); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(tt.want), &wantData); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(wantData, gotData); diff != "" {
+				t.Errorf("Delta() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -23,7 +23,9 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/apache/skywalking-infra-e2e/third-party/go/template"
@@ -52,6 +54,10 @@ var customFuncMap = map[string]any{
 	// Regex:
 	"regexp": regexpMatch,
 
+	// Tolerance:
+	"approx":  approx,
+	"between": between,
+
 	// Calculation:
 	"subtractor": subtractor,
 }
@@ -97,6 +103,84 @@ func regexpMatch(s, pattern string) string {
 	return s
 }
 
+// approx verifies the number is within the tolerance of the expected number, the tolerance is either
+// an absolute number such as 5, or a percentage of the expected number such as "10%".
+func approx(actual, expected, tolerance any) string {
+	value, err := toFloat(actual)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	want, err := toFloat(expected)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	delta, err := parseTolerance(tolerance, want)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	if math.Abs(value-want) > delta {
+		return fmt.Sprintf("<wanted approx %v ± %v, but was %v>", expected, tolerance, actual)
+	}
+	return fmt.Sprint(actual)
+}
+
+// between verifies the number is in the closed range of min and max.
+func between(actual, minimum, maximum any) string {
+	value, err := toFloat(actual)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	lower, err := toFloat(minimum)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	upper, err := toFloat(maximum)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	if value < lower || value > upper {
+		return fmt.Sprintf("<wanted between %v and %v, but was %v>", minimum, maximum, actual)
+	}
+	return fmt.Sprint(actual)
+}
+
+// parseTolerance returns the absolute tolerance, the percentage is relative to the expected number.
+func parseTolerance(tolerance any, expected float64) (float64, error) {
+	if s, ok := tolerance.(string); ok && strings.HasSuffix(strings.TrimSpace(s), "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid tolerance %q: %v", s, err)
+		}
+		return math.Abs(expected * percent / 100), nil
+	}
+	delta, err := toFloat(tolerance)
+	if err != nil {
+		return 0, err
+	}
+	return math.Abs(delta), nil
+}
+
+// toFloat converts the numeric leaf of the actual data or the param into float64, the numeric strings are parsed.
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", n)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%v is not a number, but was %T", v, v)
+}
+
 func subtractor(total int, nums ...int) int {
 	for _, num := range nums {
 		total -= num
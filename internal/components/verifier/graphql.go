@@ -0,0 +1,143 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const graphQLFetchTimeout = 30 * time.Second
+
+// graphQLResponse is the envelope of the GraphQL response.
+type graphQLResponse struct {
+	Data   any `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// FetchGraphQL posts the query with the variables to the GraphQL endpoint, and converts the `data` of the response into YAML,
+// so that it can be verified with the same expected template as other cases.
+func FetchGraphQL(url, query string, variables map[string]any, headers map[string]string) (string, error) {
+	url = os.ExpandEnv(url)
+	body, err := json.Marshal(map[string]any{"query": expandEnv(query), "variables": jsonValue(variables)})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the GraphQL request: %v", err)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		request.Header.Set(key, os.ExpandEnv(value))
+	}
+
+	client := &http.Client{Timeout: graphQLFetchTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to query GraphQL from %s: %v", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to query GraphQL from %s, response status code: %d", url, response.StatusCode)
+	}
+
+	var result graphQLResponse
+	decoder := json.NewDecoder(response.Body)
+	// keep the numbers as they are, such as the long ids
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode the GraphQL response from %s: %v", url, err)
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			messages = append(messages, e.Message)
+		}
+		return "", fmt.Errorf("the GraphQL query to %s responded errors: %s", url, strings.Join(messages, "; "))
+	}
+
+	data, err := yaml.Marshal(yamlValue(result.Data))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// jsonValue converts the values decoded from YAML into the ones could be marshaled into JSON,
+// the keys of the YAML maps are not strings, and the env variables in the strings are expanded.
+func jsonValue(v any) any {
+	switch value := v.(type) {
+	case map[any]any:
+		result := make(map[string]any, len(value))
+		for key, item := range value {
+			result[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, item := range value {
+			result[key] = jsonValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, 0, len(value))
+		for _, item := range value {
+			result = append(result, jsonValue(item))
+		}
+		return result
+	case string:
+		return expandEnv(value)
+	}
+	return v
+}
+
+// yamlValue converts the JSON numbers into the integers or the floats, so that they're not quoted as strings in YAML.
+func yamlValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = yamlValue(item)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = yamlValue(item)
+		}
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	}
+	return v
+}
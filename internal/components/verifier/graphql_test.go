@@ -0,0 +1,81 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFetchGraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if request.Query == "query { broken }" {
+			_, _ = w.Write([]byte(`{"errors": [{"message": "Field 'broken' is undefined"}]}`))
+			return
+		}
+		data, _ := json.Marshal(request.Variables)
+		_, _ = w.Write([]byte(`{"data": {"service": {"id": 1234567890123456789, "variables": ` + string(data) + `}}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("GRAPHQL_SERVER", server.URL)
+	t.Setenv("SERVICE_NAME", "oap")
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "should extract the data with the variables",
+			query:     "query ($condition: Condition) { service(condition: $condition) { id } }",
+			variables: map[string]any{"condition": map[any]any{"name": "${SERVICE_NAME}", "layers": []any{"GENERAL"}}},
+			want:      "service:\n  id: 1234567890123456789\n  variables:\n    condition:\n      layers:\n      - GENERAL\n      name: oap\n",
+		},
+		{
+			name:    "should fail when the response has errors",
+			query:   "query { broken }",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchGraphQL("${GRAPHQL_SERVER}/graphql", tt.query, tt.variables, map[string]string{"Authorization": "Bearer token"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchGraphQL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FetchGraphQL() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseIgnorePath parses the JSONPath of the ignored fields into the segments, such as `$.nodes[*].id`, `{.nodes[*].id}`
+// or `nodes[*].id` into `nodes`, `[*]` and `id`. The segments are the keys, the wildcard `*`, and the list indexes like `[0]` or `[*]`.
+func parseIgnorePath(path string) ([]string, error) {
+	p := strings.TrimSpace(path)
+	if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+		p = p[1 : len(p)-1]
+	}
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+	if p == "" {
+		return nil, fmt.Errorf("the ignored path %q is empty", path)
+	}
+
+	segments := make([]string, 0)
+	for _, part := range strings.Split(p, ".") {
+		key := part
+		var indexes string
+		if i := strings.Index(part, "["); i != -1 {
+			key, indexes = part[:i], part[i:]
+		}
+		if key != "" {
+			segments = append(segments, key)
+		} else if indexes == "" {
+			return nil, fmt.Errorf("the ignored path %q has an empty key", path)
+		}
+		for indexes != "" {
+			end := strings.Index(indexes, "]")
+			if !strings.HasPrefix(indexes, "[") || end == -1 {
+				return nil, fmt.Errorf("the ignored path %q has an invalid index %s", path, indexes)
+			}
+			index := indexes[1:end]
+			if _, err := strconv.Atoi(index); err != nil && index != "*" {
+				return nil, fmt.Errorf("the ignored path %q has an invalid index [%s], should be a number or *", path, index)
+			}
+			segments = append(segments, indexes[:end+1])
+			indexes = indexes[end+1:]
+		}
+	}
+	return segments, nil
+}
+
+// stripPaths removes the fields of the paths from the data, the missing fields are ignored.
+func stripPaths(data any, paths [][]string) any {
+	for _, segments := range paths {
+		data = stripPath(data, segments)
+	}
+	return data
+}
+
+// stripPath removes the fields matching the segments from the data, the maps are modified in place and the lists are copied.
+func stripPath(data any, segments []string) any {
+	if len(segments) == 0 {
+		return data
+	}
+	segment, rest := segments[0], segments[1:]
+	switch d := data.(type) {
+	case map[any]any:
+		for k, v := range d {
+			if segment != "*" && fmt.Sprint(k) != segment {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(d, k)
+			} else {
+				d[k] = stripPath(v, rest)
+			}
+		}
+	case map[string]any:
+		// the objects decoded by the JSON comparator
+		for k, v := range d {
+			if segment != "*" && k != segment {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(d, k)
+			} else {
+				d[k] = stripPath(v, rest)
+			}
+		}
+	case []any:
+		result := make([]any, 0, len(d))
+		for i, v := range d {
+			if segment != "*" && segment != "[*]" && segment != fmt.Sprintf("[%d]", i) {
+				result = append(result, v)
+			} else if len(rest) > 0 {
+				result = append(result, stripPath(v, rest))
+			}
+		}
+		return result
+	}
+	return data
+}
@@ -0,0 +1,129 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseIgnorePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "$.nodes[*].id", want: []string{"nodes", "[*]", "id"}},
+		{path: "{.nodes[0].id}", want: []string{"nodes", "[0]", "id"}},
+		{path: "nodes.*.start", want: []string{"nodes", "*", "start"}},
+		{path: "[*].matrix[1][*]", want: []string{"[*]", "matrix", "[1]", "[*]"}},
+		{path: "$", wantErr: true},
+		{path: "nodes..id", wantErr: true},
+		{path: "nodes[a].id", wantErr: true},
+		{path: "nodes[0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseIgnorePath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIgnorePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("parseIgnorePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIgnoring(t *testing.T) {
+	actual := `
+nodes:
+  - id: 7f3a
+    name: oap
+    start: 1700000000
+  - id: 9c1b
+    name: ui
+    start: 1700000001
+total: 2
+`
+	tests := []struct {
+		name        string
+		expected    string
+		ignorePaths []string
+		wantErr     bool
+	}{
+		{
+			name: "should ignore the fields of all the list elements",
+			expected: `
+nodes:
+  - name: oap
+  - name: ui
+total: 2
+`,
+			ignorePaths: []string{"$.nodes[*].id", "$.nodes[*].start"},
+		},
+		{
+			name: "should ignore the fields by the wildcard",
+			expected: `
+nodes:
+  - name: oap
+    start: 0
+  - name: ui
+    start: 0
+total: 2
+`,
+			ignorePaths: []string{"nodes.*.id", "nodes.*.start"},
+		},
+		{
+			name: "should still compare the fields not ignored",
+			expected: `
+nodes:
+  - name: oap
+  - name: ui
+total: 3
+`,
+			ignorePaths: []string{"$.nodes[*].id", "$.nodes[*].start"},
+			wantErr:     true,
+		},
+		{
+			name: "should ignore the list element by the index",
+			expected: `
+nodes:
+  - id: 7f3a
+    name: oap
+    start: 1700000000
+total: 2
+`,
+			ignorePaths: []string{"$.nodes[1]"},
+		},
+		{
+			name:        "should fail when the path is invalid",
+			expected:    "total: 2",
+			ignorePaths: []string{"nodes[a]"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyIgnoring(actual, tt.expected, tt.ignorePaths); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyIgnoring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// InstanceEnv finds the env vars of every instance of the scaled service in the environ, the `<service>_<number>_<key>` vars
+// of each instance are returned as `<service>_<key>`, so that the same query could run against each instance.
+func InstanceEnv(service string, environ []string) []map[string]string {
+	instances := make([]map[string]string, 0)
+	for number := 1; ; number++ {
+		prefix := fmt.Sprintf("%s_%d_", service, number)
+		env := make(map[string]string)
+		for _, kv := range environ {
+			key, value, found := strings.Cut(kv, "=")
+			if found && strings.HasPrefix(key, prefix) {
+				env[fmt.Sprintf("%s_%s", service, strings.TrimPrefix(key, prefix))] = value
+			}
+		}
+		if len(env) == 0 {
+			return instances
+		}
+		instances = append(instances, env)
+	}
+}
+
+// InstanceQuery exports the env vars of the instance before running the query.
+func InstanceQuery(query string, env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(env[key]))
+	}
+	b.WriteString(query)
+	return b.String()
+}
+
+// MergeOutputs merges the YAML outputs of the instances, the lists are concatenated and the maps are merged by the keys,
+// the other values are taken from the first output.
+func MergeOutputs(outputs []string) (string, error) {
+	var merged any
+	for i, output := range outputs {
+		var data any
+		if err := yaml.Unmarshal([]byte(output), &data); err != nil {
+			return "", fmt.Errorf("failed to unmarshal the output of instance %d: %v", i+1, err)
+		}
+		if i == 0 {
+			merged = data
+		} else {
+			merged = mergeValue(merged, data)
+		}
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func mergeValue(a, b any) any {
+	switch av := a.(type) {
+	case []any:
+		if bv, ok := b.([]any); ok {
+			return append(av, bv...)
+		}
+	case map[any]any:
+		if bv, ok := b.(map[any]any); ok {
+			for key, value := range bv {
+				if existing, exists := av[key]; exists {
+					av[key] = mergeValue(existing, value)
+				} else {
+					av[key] = value
+				}
+			}
+			return av
+		}
+	case nil:
+		return b
+	}
+	return a
+}
@@ -0,0 +1,87 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInstanceEnv(t *testing.T) {
+	environ := []string{
+		"oap_host=localhost", "oap_12800=30001",
+		"oap_1_host=localhost", "oap_1_12800=30001",
+		"oap_2_host=localhost", "oap_2_12800=30002",
+		"oap_ui_1_host=localhost",
+	}
+	want := []map[string]string{
+		{"oap_host": "localhost", "oap_12800": "30001"},
+		{"oap_host": "localhost", "oap_12800": "30002"},
+	}
+	if got := InstanceEnv("oap", environ); !cmp.Equal(got, want) {
+		t.Errorf("InstanceEnv() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+	if got := InstanceEnv("ui", environ); len(got) != 0 {
+		t.Errorf("InstanceEnv() = %v, want no instance", got)
+	}
+}
+
+func TestInstanceQuery(t *testing.T) {
+	got := InstanceQuery("swctl service ls", map[string]string{"oap_host": "localhost", "oap_name": "it's"})
+	want := "export oap_host='localhost'\nexport oap_name='it'\\''s'\nswctl service ls"
+	if got != want {
+		t.Errorf("InstanceQuery() mismatch (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestMergeOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "should concatenate the lists",
+			outputs: []string{"- name: a\n", "- name: b\n"},
+			want:    "- name: a\n- name: b\n",
+		},
+		{
+			name:    "should merge the maps by the keys",
+			outputs: []string{"total: 1\nservices:\n- a\n", "total: 2\nservices:\n- b\nnodes:\n- node\n"},
+			want:    "nodes:\n- node\nservices:\n- a\n- b\ntotal: 1\n",
+		},
+		{
+			name:    "should fail when the output is not yaml",
+			outputs: []string{"- a\n", "a: [b"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeOutputs(tt.outputs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MergeOutputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("MergeOutputs() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
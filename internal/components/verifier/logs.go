@@ -0,0 +1,153 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// VerifyLogs checks the logs contain all the contains patterns and none of the absent patterns,
+// the patterns are regular expressions matched against each line.
+func VerifyLogs(logs string, contains, absent []string) error {
+	lines := strings.Split(logs, "\n")
+
+	var problems []string
+	for _, pattern := range contains {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %s: %v", pattern, err)
+		}
+		if matchedLine(re, lines) == "" {
+			problems = append(problems, fmt.Sprintf("expected a line matching %q, but not found", pattern))
+		}
+	}
+	for _, pattern := range absent {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %s: %v", pattern, err)
+		}
+		if line := matchedLine(re, lines); line != "" {
+			problems = append(problems, fmt.Sprintf("expected no line matching %q, but found: %s", pattern, line))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &MismatchError{
+			Err:  fmt.Errorf("the logs mismatch"),
+			diff: strings.Join(problems, "\n"),
+		}
+	}
+	return nil
+}
+
+func matchedLine(re *regexp.Regexp, lines []string) string {
+	for _, line := range lines {
+		if re.MatchString(line) {
+			return line
+		}
+	}
+	return ""
+}
+
+// ReadLogs reads the log files matching the glob patterns relative to the log directory,
+// the content before the offsets of the files are skipped.
+func ReadLogs(logDir string, patterns []string, offsets map[string]int64) (string, error) {
+	var logs bytes.Buffer
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(filepath.Join(logDir, pattern))
+		if err != nil {
+			return "", fmt.Errorf("invalid log files pattern %s: %v", pattern, err)
+		}
+		if len(files) == 0 {
+			return "", fmt.Errorf("no log files match %s in %s", pattern, logDir)
+		}
+		for _, file := range files {
+			if err := readLogFrom(&logs, file, offsets[file]); err != nil {
+				return "", err
+			}
+		}
+	}
+	return logs.String(), nil
+}
+
+func readLogFrom(w io.Writer, file string, offset int64) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open the log file %s: %v", file, err)
+	}
+	defer f.Close()
+
+	// the log file is recreated if the offset is beyond the size, read it from the beginning
+	if info, err := f.Stat(); err == nil && info.Size() >= offset {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to read the log file %s: %v", file, err)
+	}
+	return nil
+}
+
+// SaveLogOffsets records the sizes of all the log files under the log directory into the offsets file,
+// so that the logs written later could be read by ReadLogs with the offsets.
+func SaveLogOffsets(logDir, offsetsFile string) error {
+	offsets := make(map[string]int64)
+	err := filepath.Walk(logDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			offsets[path] = info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(offsets)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(offsetsFile, data, 0o600)
+}
+
+// LoadLogOffsets loads the offsets recorded by SaveLogOffsets, returns empty offsets if it's not recorded.
+func LoadLogOffsets(offsetsFile string) (map[string]int64, error) {
+	offsets := make(map[string]int64)
+	data, err := os.ReadFile(offsetsFile)
+	if os.IsNotExist(err) {
+		return offsets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &offsets); err != nil {
+		return nil, fmt.Errorf("failed to parse the log offsets %s: %v", offsetsFile, err)
+	}
+	return offsets, nil
+}
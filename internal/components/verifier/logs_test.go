@@ -0,0 +1,102 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyLogs(t *testing.T) {
+	logs := "INFO server started on port 8080\nWARN slow query\nINFO request handled\n"
+	tests := []struct {
+		name     string
+		contains []string
+		absent   []string
+		wantErr  bool
+	}{
+		{
+			name:     "should pass when the patterns are matched",
+			contains: []string{`started on port \d+`, "request handled"},
+			absent:   []string{"^ERROR"},
+		},
+		{
+			name:     "should fail when the expected line is not found",
+			contains: []string{"shutdown"},
+			wantErr:  true,
+		},
+		{
+			name:    "should fail when the absent line is found",
+			absent:  []string{"^WARN"},
+			wantErr: true,
+		},
+		{
+			name:     "should fail when the pattern is invalid",
+			contains: []string{"("},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyLogs(logs, tt.contains, tt.absent); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyLogs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadLogsSinceOffsets(t *testing.T) {
+	logDir := t.TempDir()
+	file := filepath.Join(logDir, "default", "oap-0.log")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("before trigger\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	offsetsFile := filepath.Join(t.TempDir(), "offsets.json")
+	if err := SaveLogOffsets(logDir, offsetsFile); err != nil {
+		t.Fatalf("SaveLogOffsets() error = %v", err)
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("after trigger\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	offsets, err := LoadLogOffsets(offsetsFile)
+	if err != nil {
+		t.Fatalf("LoadLogOffsets() error = %v", err)
+	}
+	got, err := ReadLogs(logDir, []string{"default/oap-*.log"}, offsets)
+	if err != nil {
+		t.Fatalf("ReadLogs() error = %v", err)
+	}
+	if got != "after trigger\n" {
+		t.Errorf("ReadLogs() = %q, want the lines after the offsets only", got)
+	}
+
+	if _, err := ReadLogs(logDir, []string{"default/missing-*.log"}, nil); err == nil {
+		t.Errorf("ReadLogs() should fail when no log files match")
+	}
+}
@@ -0,0 +1,180 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const metricsFetchTimeout = 30 * time.Second
+
+// MetricSample is a single sample line of the Prometheus/OpenMetrics text exposition format.
+type MetricSample struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+	Value  float64           `yaml:"value"`
+}
+
+// FetchMetrics scrapes the metrics endpoint and converts the exposition text into YAML,
+// so that it can be verified with the same expected template as other cases.
+func FetchMetrics(url string) (string, error) {
+	// there can be env variables in url, say, "http://${OAP_HOST}:${OAP_1234}/metrics"
+	url = os.ExpandEnv(url)
+
+	client := &http.Client{Timeout: metricsFetchTimeout}
+	response, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to scrape metrics from %s: %v", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to scrape metrics from %s, response status code: %d", url, response.StatusCode)
+	}
+
+	return MetricsToYAML(response.Body)
+}
+
+// MetricsToYAML parses the exposition text and renders the samples in the following YAML structure:
+//
+//	metrics:
+//	  - name: http_requests_total
+//	    labels:
+//	      code: "200"
+//	    value: 1027
+func MetricsToYAML(reader io.Reader) (string, error) {
+	samples, err := ParseMetrics(reader)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := yaml.Marshal(map[string][]MetricSample{"metrics": samples})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ParseMetrics parses the Prometheus/OpenMetrics text exposition format, comments (HELP, TYPE, etc.) are ignored.
+func ParseMetrics(reader io.Reader) ([]MetricSample, error) {
+	samples := make([]MetricSample, 0)
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sample, err := parseMetricLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metrics line %d %q: %v", lineNum, line, err)
+		}
+		samples = append(samples, *sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func parseMetricLine(line string) (*MetricSample, error) {
+	sample := &MetricSample{}
+
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return nil, fmt.Errorf("missing metric value")
+	}
+	sample.Name = line[:nameEnd]
+	rest := line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, remain, err := parseMetricLabels(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+		if len(labels) > 0 {
+			sample.Labels = labels
+		}
+		rest = remain
+	}
+
+	// the value may be followed by an optional timestamp, which is ignored
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing metric value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metric value %q", fields[0])
+	}
+	sample.Value = value
+	return sample, nil
+}
+
+// parseMetricLabels parses the content after `{` and returns the labels and the content after `}`.
+func parseMetricLabels(s string) (labels map[string]string, remain string, err error) {
+	labels = make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("invalid labels")
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("label value of %s should be quoted", key)
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] != '\\' || i+1 >= len(s) {
+				value.WriteByte(s[i])
+				continue
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				value.WriteByte('\n')
+			default:
+				value.WriteByte(s[i])
+			}
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("unterminated label value of %s", key)
+		}
+		labels[key] = value.String()
+		s = s[i+1:]
+	}
+}
@@ -0,0 +1,153 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []MetricSample
+		wantErr bool
+	}{
+		{
+			name: "should parse samples with and without labels",
+			text: `
+# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post", code="400"}    3
+process_cpu_seconds_total 12.5
+`,
+			want: []MetricSample{
+				{Name: "http_requests_total", Labels: map[string]string{"method": "post", "code": "200"}, Value: 1027},
+				{Name: "http_requests_total", Labels: map[string]string{"method": "post", "code": "400"}, Value: 3},
+				{Name: "process_cpu_seconds_total", Value: 12.5},
+			},
+		},
+		{
+			name: "should unescape label values",
+			text: `msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9`,
+			want: []MetricSample{
+				{
+					Name:   "msdos_file_access_time_seconds",
+					Labels: map[string]string{"path": `C:\DIR\FILE.TXT`, "error": "Cannot find file:\n\"FILE.TXT\""},
+					Value:  1.458255915e9,
+				},
+			},
+		},
+		{
+			name:    "should fail when the value is missing",
+			text:    `http_requests_total{method="post"}`,
+			wantErr: true,
+		},
+		{
+			name:    "should fail when the label value is not quoted",
+			text:    `http_requests_total{method=post} 1`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMetrics(strings.NewReader(tt.text))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMetrics() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("ParseMetrics() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestVerifyMetrics(t *testing.T) {
+	actual, err := MetricsToYAML(strings.NewReader(`
+http_requests_total{method="post",code="200"} 1027
+http_requests_total{method="post",code="400"} 3
+`))
+	if err != nil {
+		t.Fatalf("MetricsToYAML() error = %v", err)
+	}
+
+	expected := `
+metrics:
+{{- contains .metrics }}
+  - name: http_requests_total
+    labels:
+      code: "200"
+      method: post
+    value: {{ gt .value 1000 }}
+{{- end }}
+`
+	if err := Verify(actual, expected); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestFetchMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintln(w, "process_cpu_seconds_total 12.5")
+	}))
+	defer server.Close()
+
+	t.Setenv("METRICS_SERVER", server.URL)
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "should expand the env variables in url",
+			url:  "${METRICS_SERVER}/metrics",
+			want: "metrics:\n- name: process_cpu_seconds_total\n  value: 12.5\n",
+		},
+		{
+			name:    "should fail when the status code is not 200",
+			url:     "${METRICS_SERVER}/not-found",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchMetrics(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FetchMetrics() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FetchMetrics() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+// seriesOperators are the operators of the series conditions, the longer ones are matched first.
+var seriesOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// VerifySeries checks the values of the metrics series in the actual data meet the condition such as `> 0`,
+// `all` checks all the values, `any` checks any one of them and `last` checks the last one.
+// The values are the numeric `value` fields of the swctl output in order, the empty values of the time buckets are ignored.
+func VerifySeries(actualData, match, condition string) error {
+	operator, threshold, err := parseSeriesCondition(condition)
+	if err != nil {
+		return err
+	}
+	var data any
+	if err := yaml.Unmarshal([]byte(actualData), &data); err != nil {
+		return fmt.Errorf("failed to parse the metrics series: %v", err)
+	}
+	values := seriesValues(data)
+	if len(values) == 0 {
+		return &MismatchError{Err: fmt.Errorf("the series mismatch"), diff: "expected the values of the series, but got none"}
+	}
+
+	unmet := make([]string, 0)
+	for _, value := range values {
+		if !compareSeriesValue(value, operator, threshold) {
+			unmet = append(unmet, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+	switch match {
+	case constant.SeriesMatchAny:
+		if len(unmet) < len(values) {
+			return nil
+		}
+		return seriesMismatch(fmt.Sprintf("expected any value of the series %s, but none of %v does", condition, unmet))
+	case constant.SeriesMatchLast:
+		last := values[len(values)-1]
+		if compareSeriesValue(last, operator, threshold) {
+			return nil
+		}
+		return seriesMismatch(fmt.Sprintf("expected the last value of the series %s, but got %v", condition, last))
+	}
+	if len(unmet) == 0 {
+		return nil
+	}
+	return seriesMismatch(fmt.Sprintf("expected all the values of the series %s, but %d of %d don't: %v", condition, len(unmet), len(values), unmet))
+}
+
+func seriesMismatch(diff string) error {
+	return &MismatchError{Err: fmt.Errorf("the series mismatch"), diff: diff}
+}
+
+// parseSeriesCondition parses the condition such as `>= 99.5` into the operator and the threshold.
+func parseSeriesCondition(condition string) (operator string, threshold float64, err error) {
+	condition = strings.TrimSpace(condition)
+	for _, op := range seriesOperators {
+		if strings.HasPrefix(condition, op) {
+			threshold, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(condition, op)), 64)
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid threshold of the series condition %s: %v", condition, err)
+			}
+			return op, threshold, nil
+		}
+	}
+	return "", 0, fmt.Errorf("invalid series condition %q, should be an operator of %v and a number, such as `> 0`", condition, seriesOperators)
+}
+
+func compareSeriesValue(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case ">=":
+		return value >= threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	case ">":
+		return value > threshold
+	}
+	return value < threshold
+}
+
+// seriesValues collects the numeric `value` fields in order, the keys of the maps such as the times of the buckets are sorted,
+// and the values marked as `isemptyvalue` or null are ignored.
+func seriesValues(v any) []float64 {
+	values := make([]float64, 0)
+	switch value := v.(type) {
+	case map[any]any:
+		for key, item := range value {
+			if strings.EqualFold(fmt.Sprint(key), "isemptyvalue") && item == true {
+				return values
+			}
+		}
+		keys := make([]string, 0, len(value))
+		items := make(map[string]any, len(value))
+		for key, item := range value {
+			keys = append(keys, fmt.Sprint(key))
+			items[fmt.Sprint(key)] = item
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if key == "value" {
+				if number, ok := seriesNumber(items[key]); ok {
+					values = append(values, number)
+					continue
+				}
+			}
+			values = append(values, seriesValues(items[key])...)
+		}
+	case []any:
+		for _, item := range value {
+			values = append(values, seriesValues(item)...)
+		}
+	}
+	return values
+}
+
+// seriesNumber converts the value into a number, the values of the MQE results are strings.
+func seriesNumber(v any) (float64, bool) {
+	if number, ok := toNumber(v); ok {
+		return number, true
+	}
+	if s, ok := v.(string); ok {
+		if number, err := strconv.ParseFloat(s, 64); err == nil {
+			return number, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,109 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSeriesValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		actualData string
+		want       []float64
+	}{
+		{
+			name: "linear",
+			actualData: `
+- value: 0
+  isemptyvalue: true
+- value: 10
+  isemptyvalue: false
+- value: 20
+  isemptyvalue: false
+`,
+			want: []float64{10, 20},
+		},
+		{
+			name: "expression",
+			actualData: `
+type: TIME_SERIES_VALUES
+results:
+  - metric:
+      labels: []
+    values:
+      - id: "1"
+        value: "9500"
+      - id: "2"
+        value: null
+      - id: "3"
+        value: "10000"
+`,
+			want: []float64{9500, 10000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data any
+			if err := yaml.Unmarshal([]byte(tt.actualData), &data); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, seriesValues(data)); diff != "" {
+				t.Errorf("seriesValues() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVerifySeries(t *testing.T) {
+	actualData := `
+- value: 0
+- value: 10
+- value: 20
+`
+	tests := []struct {
+		name      string
+		match     string
+		condition string
+		wantErr   bool
+	}{
+		{name: "all met", match: "all", condition: ">= 0"},
+		{name: "all unmet", match: "all", condition: "> 0", wantErr: true},
+		{name: "default to all", match: "", condition: "> 0", wantErr: true},
+		{name: "any met", match: "any", condition: "> 15"},
+		{name: "any unmet", match: "any", condition: "> 20", wantErr: true},
+		{name: "last met", match: "last", condition: "== 20"},
+		{name: "last unmet", match: "last", condition: "< 20", wantErr: true},
+		{name: "invalid condition", match: "all", condition: "positive", wantErr: true},
+		{name: "invalid threshold", match: "all", condition: "> zero", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifySeries(actualData, tt.match, tt.condition); (err != nil) != tt.wantErr {
+				t.Errorf("VerifySeries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if err := VerifySeries("[]", "all", "> 0"); err == nil {
+		t.Errorf("VerifySeries() of the empty series should fail")
+	}
+}
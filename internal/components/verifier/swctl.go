@@ -0,0 +1,115 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+// swctlTimeFormats are the formats of the `--start` and `--end` of swctl, which depend on the step.
+var swctlTimeFormats = map[string]string{
+	constant.SwctlStepSecond: "2006-01-02 150405",
+	constant.SwctlStepMinute: "2006-01-02 1504",
+	constant.SwctlStepHour:   "2006-01-02 15",
+	constant.SwctlStepDay:    "2006-01-02",
+}
+
+// SwctlMetrics is the metrics query of SkyWalking executed by swctl.
+type SwctlMetrics struct {
+	BaseURL    string
+	Name       string
+	Expression string
+	Service    string
+	Instance   string
+	Endpoint   string
+	Start      string
+	End        string
+	Step       string
+}
+
+// Command builds the swctl command of the query, the env vars in the fields are expanded, the base URL defaults to the exposed `oap` service,
+// and the durations of the time range, such as `30m`, are converted into the times before now in the format of the step.
+func (m *SwctlMetrics) Command(now time.Time) (string, error) {
+	baseURL := m.BaseURL
+	if baseURL == "" {
+		baseURL = constant.SwctlBaseURL
+	}
+	args := []string{"swctl", "--display=yaml", swctlFlag("base-url", baseURL), "metrics"}
+	if m.Expression != "" {
+		args = append(args, "exec", swctlFlag("expression", m.Expression))
+	} else {
+		args = append(args, "linear", swctlFlag("name", m.Name))
+	}
+	for _, entity := range []struct{ flag, value string }{
+		{"service-name", m.Service}, {"instance-name", m.Instance}, {"endpoint-name", m.Endpoint},
+	} {
+		if entity.value != "" {
+			args = append(args, swctlFlag(entity.flag, entity.value))
+		}
+	}
+
+	if m.Start == "" && m.End == "" && m.Step == "" {
+		return strings.Join(args, " "), nil
+	}
+	step := m.Step
+	if step == "" {
+		step = constant.SwctlStepMinute
+	}
+	for _, t := range []struct{ flag, value string }{{"start", m.Start}, {"end", m.End}} {
+		if t.value == "" {
+			continue
+		}
+		value, err := swctlTime(os.ExpandEnv(t.value), step, now)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s of the swctl case: %v", t.flag, err)
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", t.flag, shellQuote(value)))
+	}
+	args = append(args, "--step="+step)
+	return strings.Join(args, " "), nil
+}
+
+// swctlTime converts the duration before now into the time in the format of the step, the times are returned as they are.
+func swctlTime(value, step string, now time.Time) (string, error) {
+	format, ok := swctlTimeFormats[step]
+	if !ok {
+		return "", fmt.Errorf("unsupported step %s", step)
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d).Format(format), nil
+	}
+	if _, err := time.ParseInLocation(format, value, time.Local); err != nil {
+		return "", fmt.Errorf("%s is neither a duration nor a time in the format %q", value, format)
+	}
+	return value, nil
+}
+
+func swctlFlag(name, value string) string {
+	return fmt.Sprintf("--%s=%s", name, shellQuote(os.ExpandEnv(value)))
+}
+
+// shellQuote quotes the value for the shell, so that the spaces and quotes in the names are kept.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
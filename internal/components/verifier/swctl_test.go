@@ -0,0 +1,73 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSwctlMetricsCommand(t *testing.T) {
+	t.Setenv("oap_host", "localhost")
+	t.Setenv("oap_12800", "12800")
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		metrics SwctlMetrics
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "name",
+			metrics: SwctlMetrics{Name: "service_sla", Service: "e2e-service-provider"},
+			want:    "swctl --display=yaml --base-url='http://localhost:12800/graphql' metrics linear --name='service_sla' --service-name='e2e-service-provider'",
+		},
+		{
+			name:    "expression",
+			metrics: SwctlMetrics{BaseURL: "http://oap:12800/graphql", Expression: "avg(service_cpm)", Service: "svc", Instance: "it's"},
+			want:    "swctl --display=yaml --base-url='http://oap:12800/graphql' metrics exec --expression='avg(service_cpm)' --service-name='svc' --instance-name='it'\\''s'",
+		},
+		{
+			name:    "relative time range",
+			metrics: SwctlMetrics{Name: "endpoint_cpm", Endpoint: "POST:/users", Start: "30m"},
+			want:    "swctl --display=yaml --base-url='http://localhost:12800/graphql' metrics linear --name='endpoint_cpm' --endpoint-name='POST:/users' --start='2024-05-06 0638' --step=MINUTE",
+		},
+		{
+			name:    "absolute time range",
+			metrics: SwctlMetrics{Name: "service_sla", Start: "2024-05-06 05", End: "1h", Step: "HOUR"},
+			want:    "swctl --display=yaml --base-url='http://localhost:12800/graphql' metrics linear --name='service_sla' --start='2024-05-06 05' --end='2024-05-06 06' --step=HOUR",
+		},
+		{
+			name:    "time not matching the step",
+			metrics: SwctlMetrics{Name: "service_sla", Start: "2024-05-06 0500", Step: "DAY"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.metrics.Command(now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Command() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Command() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,7 +20,11 @@ package verifier
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"regexp"
+	"strings"
 
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/third-party/go/template"
 
 	"github.com/google/go-cmp/cmp"
@@ -43,28 +47,81 @@ func (e *MismatchError) Error() string {
 	return e.diff
 }
 
-// Verify checks if the actual data match the expected template.
+// envPattern matches the `${NAME}` references in the expected template, the `$name` form is not supported
+// because it conflicts with the variables of the Go template.
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)}`)
+
+// expandEnv replaces the `${NAME}` references in the expected template with the environment variables,
+// such as the ones exported by the setup, the undefined variables are replaced with empty strings.
+func expandEnv(expectedTemplate string) string {
+	return envPattern.ReplaceAllStringFunc(expectedTemplate, func(s string) string {
+		return os.Getenv(envPattern.FindStringSubmatch(s)[1])
+	})
+}
+
+// Verify checks if the actual data match the expected template,
+// the environment variables in the template are expanded before rendering the template.
 func Verify(actualData, expectedTemplate string) error {
+	return verify(actualData, expectedTemplate, nil)
+}
+
+// VerifyIgnoring checks if the actual data match the expected template like Verify,
+// the fields of the JSONPaths are removed from both the actual and the rendered expected data before comparing.
+func VerifyIgnoring(actualData, expectedTemplate string, ignorePaths []string) error {
+	return VerifyWith(constant.ComparatorYAML, actualData, expectedTemplate, ignorePaths)
+}
+
+// VerifyWith checks if the actual data match the expected template like VerifyIgnoring by the comparator,
+// the YAML comparator is used if it's empty, the ignored JSONPaths are only supported by the YAML and JSON comparators.
+func VerifyWith(comparator, actualData, expectedTemplate string, ignorePaths []string) error {
+	paths := make([][]string, 0, len(ignorePaths))
+	for _, path := range ignorePaths {
+		segments, err := parseIgnorePath(path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, segments)
+	}
+	return compare(comparator, actualData, expectedTemplate, paths)
+}
+
+func verify(actualData, expectedTemplate string, ignorePaths [][]string) error {
 	var actual any
 	if err := yaml.Unmarshal([]byte(actualData), &actual); err != nil {
 		return fmt.Errorf("failed to unmarshal actual data: %v", err)
 	}
 
-	tmpl, err := template.New("test").Funcs(funcMap()).Parse(expectedTemplate)
+	rendered, err := render(expectedTemplate, actual)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %v", err)
-	}
-
-	var b bytes.Buffer
-	if err := tmpl.Execute(&b, actual); err != nil {
-		return fmt.Errorf("failed to execute template: %v", err)
+		return err
 	}
 
 	var expected any
-	if err := yaml.Unmarshal(b.Bytes(), &expected); err != nil {
+	if err := yaml.Unmarshal(rendered, &expected); err != nil {
 		return fmt.Errorf("failed to unmarshal expected data: %v", err)
 	}
+	return compareData(expected, actual, ignorePaths)
+}
 
+// render renders the expected template with the actual data, the environment variables in the template are expanded before rendering.
+func render(expectedTemplate string, actual any) ([]byte, error) {
+	tmpl, err := template.New("test").Funcs(funcMap()).Parse(expandEnv(expectedTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, actual); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+// compareData compares the parsed expected and actual data, the fields of the ignored paths are not compared.
+func compareData(expected, actual any, ignorePaths [][]string) error {
+	// the actual data is stripped after rendering, so that the template could still refer to the ignored fields
+	actual = stripPaths(actual, ignorePaths)
+	expected = stripPaths(expected, ignorePaths)
 	if !cmp.Equal(expected, actual) {
 		// TODO: use a custom Reporter (suggested by the comment of cmp.Diff)
 		diff := cmp.Diff(expected, actual)
@@ -72,3 +129,53 @@ func Verify(actualData, expectedTemplate string) error {
 	}
 	return nil
 }
+
+// Expected is the expected data template with the name to report, such as the file path.
+type Expected struct {
+	Name     string
+	Template string
+}
+
+// VerifyAnyOf verifies that the actual data matches any of the expected templates by the comparator, which are tried in order,
+// the fields of the ignored JSONPaths are not compared.
+// When none of them matches, the MismatchError lists the tried names and the diff of the closest template,
+// which is the one with the fewest changed lines.
+func VerifyAnyOf(actualData string, expected []Expected, ignorePaths []string, comparator string) error {
+	if len(expected) == 0 {
+		return fmt.Errorf("no expected data to verify against")
+	} else if len(expected) == 1 {
+		return VerifyWith(comparator, actualData, expected[0].Template, ignorePaths)
+	}
+
+	names := make([]string, 0, len(expected))
+	var closest *MismatchError
+	var closestName string
+	for _, e := range expected {
+		names = append(names, e.Name)
+		err := VerifyWith(comparator, actualData, e.Template, ignorePaths)
+		if err == nil {
+			return nil
+		}
+		me, ok := err.(*MismatchError)
+		if !ok {
+			return fmt.Errorf("failed to verify against %s: %v", e.Name, err)
+		}
+		if closest == nil || changedLines(me.diff) < changedLines(closest.diff) {
+			closest, closestName = me, e.Name
+		}
+	}
+	return &MismatchError{diff: fmt.Sprintf("none of the expected data %v matches, the closest one is %s, %s",
+		names, closestName, closest.diff)}
+}
+
+// changedLines counts the removed and added lines of the diff.
+func changedLines(diff string) int {
+	count := 0
+	for _, line := range strings.Split(diff, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+") {
+			count++
+		}
+	}
+	return count
+}
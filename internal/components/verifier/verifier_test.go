@@ -16,7 +16,10 @@
 // under the License.
 package verifier
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestVerify(t *testing.T) {
 	type args struct {
@@ -297,6 +300,46 @@ metrics:
 			},
 			wantErr: false,
 		},
+		{
+			name: "numbers within the tolerance",
+			args: args{
+				actualData: `
+latency: 105
+throughput: 98.5
+cpm: "1003"
+`,
+				expectedTemplate: `
+latency: {{ approx .latency 100 "10%" }}
+throughput: {{ between .throughput 90 110 }}
+cpm: "{{ approx .cpm 1000 5 }}"
+`,
+			},
+			wantErr: false,
+		},
+		{
+			name: "number out of the tolerance",
+			args: args{
+				actualData: `
+latency: 120
+`,
+				expectedTemplate: `
+latency: {{ approx .latency 100 "10%" }}
+`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "number out of the range",
+			args: args{
+				actualData: `
+throughput: 89.9
+`,
+				expectedTemplate: `
+throughput: {{ between .throughput 90 110 }}
+`,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -306,3 +349,64 @@ metrics:
 		})
 	}
 }
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("E2E_NAMESPACE", "e2e-1234")
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "should expand the braced references",
+			template: "name: service::${E2E_NAMESPACE}-gateway",
+			want:     "name: service::e2e-1234-gateway",
+		},
+		{
+			name:     "should expand the undefined variables to empty",
+			template: "name: ${E2E_UNDEFINED_VARIABLE}gateway",
+			want:     "name: gateway",
+		},
+		{
+			name:     "should keep the template variables",
+			template: "{{- range $i, $v := .services }}{{ $v.name }}{{ end }}",
+			want:     "{{- range $i, $v := .services }}{{ $v.name }}{{ end }}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandEnv(tt.template); got != tt.want {
+				t.Errorf("expandEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAnyOf(t *testing.T) {
+	actual := "status: pending\nname: oap\n"
+	running := Expected{Name: "running.yml", Template: "status: running\nname: oap\n"}
+	pending := Expected{Name: "pending.yml", Template: "status: pending\nname: oap\n"}
+	other := Expected{Name: "other.yml", Template: "status: running\nname: ui\n"}
+	tests := []struct {
+		name     string
+		expected []Expected
+		wantErr  bool
+		closest  string
+	}{
+		{name: "should pass when the first one matches", expected: []Expected{pending, running}},
+		{name: "should pass when the last one matches", expected: []Expected{running, other, pending}},
+		{name: "should report the closest one when none matches", expected: []Expected{other, running}, wantErr: true, closest: "running.yml"},
+		{name: "should fail when the template is invalid", expected: []Expected{running, {Name: "bad.yml", Template: "{{ .status"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyAnyOf(actual, tt.expected, nil, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyAnyOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.closest != "" && !strings.Contains(err.Error(), "the closest one is "+tt.closest) {
+				t.Errorf("VerifyAnyOf() error = %v, want the closest one %s", err, tt.closest)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package verifier
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// versionOperators are the operators of the version constraints, the longer ones are matched first.
+var versionOperators = []string{">=", "<=", "==", ">", "<", "="}
+
+// versionConstraint is the version constraint in the name of the expected file, such as `>=9.0` of `expected.>=9.0.yaml`.
+type versionConstraint struct {
+	file     string
+	operator string
+	version  string
+}
+
+// VersionedExpected selects the expected file of the version among the version-gated variants, such as `expected.>=9.0.yaml`
+// and `expected.<9.0.yaml` of `expected.yaml`. When several variants match, the most specific one is selected, that is the exact
+// version first, then the greatest lower bound, then the least upper bound. The file itself is returned if none matches.
+func VersionedExpected(file, version string) (string, error) {
+	ext := filepath.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+	candidates, err := filepath.Glob(globEscape(base) + ".*" + globEscape(ext))
+	if err != nil {
+		return "", err
+	}
+
+	matched := make([]versionConstraint, 0)
+	for _, candidate := range candidates {
+		constraint, ok := parseVersionConstraint(strings.TrimSuffix(strings.TrimPrefix(candidate, base+"."), ext))
+		if !ok {
+			continue
+		}
+		constraint.file = candidate
+		if constraint.matches(version) {
+			matched = append(matched, constraint)
+		}
+	}
+	if len(matched) == 0 {
+		return file, nil
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].moreSpecific(&matched[j])
+	})
+	return matched[0].file, nil
+}
+
+func parseVersionConstraint(s string) (versionConstraint, bool) {
+	for _, operator := range versionOperators {
+		if version := strings.TrimPrefix(s, operator); version != s && version != "" {
+			return versionConstraint{operator: operator, version: version}, true
+		}
+	}
+	return versionConstraint{}, false
+}
+
+func (c *versionConstraint) matches(version string) bool {
+	result := compareVersions(version, c.version)
+	switch c.operator {
+	case ">=":
+		return result >= 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case "<":
+		return result < 0
+	}
+	return result == 0
+}
+
+// rank orders the kinds of the constraints, the exact version, the lower bounds, then the upper bounds.
+func (c *versionConstraint) rank() int {
+	switch c.operator {
+	case "=", "==":
+		return 0
+	case ">=", ">":
+		return 1
+	}
+	return 2
+}
+
+func (c *versionConstraint) moreSpecific(other *versionConstraint) bool {
+	if c.rank() != other.rank() {
+		return c.rank() < other.rank()
+	}
+	result := compareVersions(c.version, other.version)
+	if c.rank() == 1 {
+		return result > 0
+	}
+	return result < 0
+}
+
+// compareVersions compares the dotted versions segment by segment numerically, such as `9.10` > `9.7`,
+// the missing segments are 0, and the non-numeric suffixes such as `-SNAPSHOT` are ignored.
+func compareVersions(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+	for len(as) < len(bs) {
+		as = append(as, 0)
+	}
+	for len(bs) < len(as) {
+		bs = append(bs, 0)
+	}
+	for i := range as {
+		if as[i] != bs[i] {
+			if as[i] < bs[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionSegments(version string) []int {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	segments := make([]int, 0, len(parts))
+	for _, part := range parts {
+		digits := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' })
+		if digits != -1 {
+			part = part[:digits]
+		}
+		n, _ := strconv.Atoi(part)
+		segments = append(segments, n)
+	}
+	return segments
+}
+
+// globEscape escapes the meta characters of the glob pattern in the path.
+func globEscape(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
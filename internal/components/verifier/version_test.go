@@ -0,0 +1,78 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package verifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVersionedExpected(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"expected.yaml", "expected.<9.0.yaml", "expected.>=9.0.yaml", "expected.>=9.5.yaml", "expected.=9.2.1.yaml", "expected.bak.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(dir, "expected.yaml")
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "8.9.1", want: "expected.<9.0.yaml"},
+		{version: "9.0.0", want: "expected.>=9.0.yaml"},
+		{version: "9.2.1", want: "expected.=9.2.1.yaml"},
+		{version: "9.4", want: "expected.>=9.0.yaml"},
+		{version: "v9.10.0-SNAPSHOT", want: "expected.>=9.5.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := VersionedExpected(file, tt.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != filepath.Join(dir, tt.want) {
+				t.Errorf("VersionedExpected() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	other := filepath.Join(dir, "other.yaml")
+	if got, err := VersionedExpected(other, "9.0"); err != nil || got != other {
+		t.Errorf("VersionedExpected() = %s, %v, want the file itself without the variants", got, err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "9.10", b: "9.7", want: 1},
+		{a: "9.0", b: "9.0.0", want: 0},
+		{a: "v8.9.1", b: "9", want: -1},
+		{a: "10.0.0-SNAPSHOT", b: "10.0.0", want: 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -21,6 +21,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
@@ -34,57 +35,332 @@ type E2EConfig struct {
 	Cleanup Cleanup `yaml:"cleanup"`
 	Trigger Trigger `yaml:"trigger"`
 	Verify  Verify  `yaml:"verify"`
+	// Matrix is the list of the variable sets, the whole e2e is run once with each variable set exported as env vars.
+	Matrix []map[string]string `yaml:"matrix"`
 }
 
 type Setup struct {
-	Env                   string    `yaml:"env"`
-	File                  string    `yaml:"file"`
-	Kubeconfig            string    `yaml:"kubeconfig"`
-	Steps                 []Step    `yaml:"steps"`
-	Timeout               any       `yaml:"timeout"`
-	InitSystemEnvironment string    `yaml:"init-system-environment"`
-	Kind                  KindSetup `yaml:"kind"`
+	Env                   string       `yaml:"env" enum:"kind,compose"`
+	File                  string       `yaml:"file"`
+	Kubeconfig            string       `yaml:"kubeconfig"`
+	KubeContext           string       `yaml:"kube-context"`
+	Steps                 []Step       `yaml:"steps"`
+	Timeout               any          `yaml:"timeout"`
+	InitSystemEnvironment string       `yaml:"init-system-environment"`
+	Kind                  KindSetup    `yaml:"kind"`
+	Compose               ComposeSetup `yaml:"compose"`
+	Registries            []Registry   `yaml:"registries"`
+	// Environments are the named setups created together in one run, instead of the single setup above.
+	Environments []Environment `yaml:"environments"`
+	// Before is the commands executed once before setting up all the environments.
+	Before string `yaml:"before"`
+	// ExportEnv customizes the keys of the env vars exported by all the environments.
+	ExportEnv ExportEnv `yaml:"export-env"`
+	// Validate validates all the manifests of the kind setup by the server-side dry-run before applying any of them.
+	Validate bool `yaml:"validate"`
+	// CheckPorts checks the fixed local ports of the port-forwards and the compose services are not in use before binding them.
+	CheckPorts bool `yaml:"check-ports"`
+	// EnvVars are the static values exported as env vars before running the steps, such as the namespace or the release name,
+	// the env vars in the values are expanded and the keys are customized by export-env like the other exported env vars.
+	EnvVars map[string]string `yaml:"env-vars"`
 
 	timeout time.Duration
 }
 
+// ExportEnv customizes the keys of the exported env vars, such as the host and ports of the services.
+type ExportEnv struct {
+	// Prefix is prepended to all the exported keys, such as `E2E_`.
+	Prefix string `yaml:"prefix"`
+	// Format is the Go template of the exported keys with `.Environment` and `.Key`, such as `{{ upper .Key }}`,
+	// defaults to `{{.Environment}}_{{.Key}}` in the multi-environment run and `{{.Key}}` otherwise.
+	Format string `yaml:"format"`
+}
+
+// Environment is a named setup in the multi-environment run, the env vars it exports are prefixed with the name.
+type Environment struct {
+	Name  string `yaml:"name"`
+	Setup `yaml:",inline"`
+}
+
 func (s *Setup) Finalize() error {
-	interval, err := parseInterval(s.Timeout, "setup.timeout")
-	if err != nil {
-		return err
+	var interval time.Duration
+	if s.Timeout != nil {
+		var err error
+		if interval, err = parseInterval(s.Timeout, "setup.timeout"); err != nil {
+			return err
+		}
 	}
 	if interval <= 0 {
 		interval = constant.DefaultWaitTimeout
 	}
 	s.timeout = interval
+
+	if len(s.Environments) > 0 && s.Env != "" {
+		return fmt.Errorf("setup.env and setup.environments can not be set at the same time")
+	}
+	if s.File != "" && s.Kind.Content != "" {
+		return fmt.Errorf("setup.file and setup.kind.content can not be set at the same time")
+	}
+	names := make(map[string]bool)
+	for i := range s.Environments {
+		environment := &s.Environments[i]
+		if environment.Name == "" {
+			return fmt.Errorf("the name of setup.environments[%d] must be provided", i)
+		}
+		if names[environment.Name] {
+			return fmt.Errorf("duplicated environment name %s in setup.environments", environment.Name)
+		}
+		names[environment.Name] = true
+		if len(environment.Environments) > 0 {
+			return fmt.Errorf("nested environments in setup.environments[%d] are not supported", i)
+		}
+		if environment.Before != "" {
+			return fmt.Errorf("before in setup.environments[%d] is not supported, please use setup.before instead", i)
+		}
+		if environment.ExportEnv != (ExportEnv{}) {
+			return fmt.Errorf("export-env in setup.environments[%d] is not supported, please use setup.export-env instead", i)
+		}
+
+		// inherit the timeout of the setup if not set
+		if environment.Timeout == nil {
+			environment.Timeout = s.Timeout
+		}
+		// inherit the env vars of the setup, the env vars of the environment take precedence
+		for key, value := range s.EnvVars {
+			if _, ok := environment.EnvVars[key]; ok {
+				continue
+			}
+			if environment.EnvVars == nil {
+				environment.EnvVars = make(map[string]string)
+			}
+			environment.EnvVars[key] = value
+		}
+		if err := environment.Setup.Finalize(); err != nil {
+			return fmt.Errorf("setup.environments[%d]: %v", i, err)
+		}
+	}
 	return nil
 }
 
+// GetEnvironments returns all the environments need to set up, the single setup is treated as an unnamed environment.
+func (s *Setup) GetEnvironments() []*Environment {
+	if len(s.Environments) == 0 {
+		return []*Environment{{Setup: *s}}
+	}
+	environments := make([]*Environment, 0, len(s.Environments))
+	for i := range s.Environments {
+		environments = append(environments, &s.Environments[i])
+	}
+	return environments
+}
+
 func (s *Setup) GetTimeout() time.Duration {
 	return s.timeout
 }
 
 type Cleanup struct {
-	On string `yaml:"on"`
+	On string `yaml:"on" enum:"success,failure,always,never"`
+	// After is the commands executed once after cleaning up all the environments, even if the cleanup failed.
+	After string `yaml:"after"`
+	// DeleteManifests deletes the resources applied by the setup from the existing cluster of the kubeconfig in the reverse order,
+	// the cluster created by kind is deleted as a whole.
+	DeleteManifests bool `yaml:"delete-manifests"`
 }
 
 type Step struct {
 	Name    string `yaml:"name"`
 	Path    string `yaml:"path"`
 	Command string `yaml:"command"`
-	Waits   []Wait `yaml:"wait"`
+	// Kustomize is the kustomization directory, the resources rendered like `kustomize build` are created.
+	Kustomize string `yaml:"kustomize"`
+	// Delete deletes the resources in the cluster, such as deleting a pod to simulate a crash.
+	Delete *DeleteResource `yaml:"delete"`
+	// Sleep waits for a fixed duration such as 20s, for the eventual consistency without any observable condition.
+	Sleep string `yaml:"sleep"`
+	// Exec executes the command inside the container of the compose service, such as seeding the database.
+	Exec  *ComposeExec `yaml:"exec"`
+	Waits []Wait       `yaml:"wait"`
+	// FileWaits are waited for after applying the manifest files of the path, before applying the next file.
+	FileWaits []FileWait `yaml:"file-wait"`
+	// Parallel runs the step concurrently with the consecutive parallel steps, such as deploying the independent components,
+	// the next non-parallel step starts after all of them finish.
+	Parallel bool `yaml:"parallel"`
+	// Apply applies the manifest files of the path by the server-side apply instead of creating them, such as updating the
+	// resources created by the previous steps.
+	Apply *ManifestApply `yaml:"apply"`
+}
+
+// ManifestApply is the server-side apply of the manifests, FieldManager defaults to `skywalking-infra-e2e`,
+// and Force takes over the fields owned by other field managers such as the controllers instead of failing on conflicts.
+type ManifestApply struct {
+	FieldManager string `yaml:"field-manager"`
+	Force        bool   `yaml:"force"`
+}
+
+// FileWait is the conditions of a manifest file, such as the operator should be ready before applying its custom resources.
+type FileWait struct {
+	File  string `yaml:"file"`
+	Waits []Wait `yaml:"wait"`
+}
+
+// ComposeExec is the command executed inside the container of the compose service, the step fails on non-zero exit.
+type ComposeExec struct {
+	Service string `yaml:"service"`
+	Command string `yaml:"command"`
+}
+
+// DeleteResource is the resources deleted by the step, selected by the name or the label selector.
+type DeleteResource struct {
+	Namespace     string `yaml:"namespace"`
+	Resource      string `yaml:"resource"`
+	LabelSelector string `yaml:"label-selector"`
 }
 
 type KindSetup struct {
 	ImportImages []string         `yaml:"import-images"`
 	ExposePorts  []KindExposePort `yaml:"expose-ports"`
 	NoWait       bool             `yaml:"no-wait"`
+	Deploy       KindDeploy       `yaml:"deploy"`
+	// ExportLogs archives the logs of the cluster on failure or before the cluster is deleted, the logs of the pods since
+	// the time of `--since` are exported, or the whole cluster by `kind export logs` if it's `all`.
+	ExportLogs string `yaml:"export-logs" enum:"on-failure,always"`
+	// CrashGate fails the setup fast if any pod crashes while deploying and running steps.
+	CrashGate *KindCrashGate `yaml:"crash-gate"`
+	// Content is the inline kind cluster config instead of the file, the env vars in it are expanded.
+	Content string `yaml:"content"`
+	// Patches set the fields of the manifest objects before they're created, such as the run-specific image tag.
+	Patches []ManifestPatch `yaml:"patches"`
+	// Binary is the path of the external kind binary to run instead of the built-in kind, so that the kind version could be
+	// pinned independently, and Version is the expected version of the kind binary, such as `v0.20.0`.
+	Binary  string `yaml:"binary"`
+	Version string `yaml:"version"`
+	// ImagePullPolicy is `always` to pull the import images before loading them even if they exist locally,
+	// such as the mutable tags like `latest`, defaults to `if-not-present`.
+	ImagePullPolicy string `yaml:"image-pull-policy" enum:"always,if-not-present"`
+}
+
+// GetBinary returns the path of the external kind binary with the env vars expanded, empty if the built-in kind is used,
+// the relative paths are resolved against the config file, while the names without the path are looked up in the PATH.
+func (k *KindSetup) GetBinary() string {
+	binary := os.ExpandEnv(k.Binary)
+	if !strings.Contains(binary, "/") {
+		return binary
+	}
+	return util.ResolveAbs(binary)
+}
+
+// ManifestPatch sets the field of the manifest objects, the objects are matched by the kind and the name if they're set.
+type ManifestPatch struct {
+	Kind string `yaml:"kind"`
+	Name string `yaml:"name"`
+	// Path is the field path such as `spec.template.spec.containers[0].image`,
+	// the keys containing dots are quoted like `metadata.annotations['sidecar.istio.io/inject']`.
+	Path string `yaml:"path"`
+	// Value is set to the field, the env vars in the string values are expanded.
+	Value any `yaml:"value" schema:"any"`
+}
+
+// KindCrashGate checks the pods in the namespaces are not in CrashLoopBackOff or restarted too many times.
+type KindCrashGate struct {
+	Namespaces    []string `yaml:"namespaces"`
+	LabelSelector string   `yaml:"label-selector"`
+	MaxRestarts   int32    `yaml:"max-restarts"`
+}
+
+// ComposeSetup is the compose specific setup.
+type ComposeSetup struct {
+	// IPFamily is the preferred address family of the exported host and ports on the dual-stack networks.
+	IPFamily string `yaml:"ip-family" enum:"ipv4,ipv6"`
+	// Network is the docker network whose gateway is exported as the host when running inside a container, `project` is the
+	// default network of the compose project, defaults to `bridge`, or `reaper_default` which is created if `bridge` is not available.
+	Network string `yaml:"network"`
+	// Scale is the number of the containers of the services, which overrides the `deploy.replicas` in the compose file.
+	Scale map[string]int `yaml:"scale"`
+	// Readiness is the readiness checks of the services inside the containers, which replace waiting for the published ports.
+	Readiness map[string]ComposeReadiness `yaml:"readiness"`
+	// UpFlags are the extra flags of `compose up`, such as `--force-recreate`, defaults to `--remove-orphans` if not set.
+	UpFlags []string `yaml:"up-flags"`
+	// StopTimeout is the timeout of stopping the containers by `compose down` before killing them, such as 1s,
+	// defaults to the one of the compose.
+	StopTimeout string `yaml:"stop-timeout"`
+	// ExportLogs archives the logs of the containers of the compose project on failure, since the time of `--since`.
+	ExportLogs string `yaml:"export-logs" enum:"on-failure"`
+	// DockerHost is the docker daemon the compose project runs against, such as tcp://builder:2375,
+	// which overrides the `DOCKER_HOST` for the compose environment only.
+	DockerHost string `yaml:"docker-host"`
+	// Host is the host of the published ports, which is exported as `<service>_host` and used to check the ports,
+	// such as `localhost`, defaults to the one detected from the docker host.
+	Host string `yaml:"host"`
+	// UpRetry re-runs `compose up` on failure, such as the transient image pull errors, the partially started project
+	// is torn down before the next attempt.
+	UpRetry ComposeUpRetry `yaml:"up-retry"`
+	// LoadImages are the image archives saved by `docker save`, which are loaded into the docker daemon before `compose up`,
+	// such as the images not in any registry on the air-gapped CI, the glob patterns like `images/*.tar` are supported.
+	LoadImages []string `yaml:"load-images"`
+	// NativeWait runs `compose up --wait` which blocks until the healthchecks of the services pass, the readiness and the port
+	// checks are skipped for the services with healthchecks, and still applied to the others.
+	NativeWait bool `yaml:"native-wait"`
+}
+
+// ComposeUpRetry is the retry strategy of `compose up`, Count is the number of the retries after the first attempt.
+type ComposeUpRetry struct {
+	Count    int    `yaml:"count"`
+	Interval string `yaml:"interval"`
+}
+
+// ComposeReadiness checks the service is ready inside the container, for the services publishing the ports before they're ready.
+type ComposeReadiness struct {
+	// Port is the port listened inside the container, which doesn't need to be published.
+	Port int `yaml:"port"`
+	// Command is executed inside the container by `/bin/sh -c`, the service is ready when it exits with 0.
+	Command string `yaml:"command"`
+	// Log is ready when the log lines of the container matching the pattern reach the count, such as the processed records.
+	Log *ComposeReadinessLog `yaml:"log"`
+}
+
+// ComposeReadinessLog counts the log lines of the container matching the regular expression, the count defaults to 1.
+type ComposeReadinessLog struct {
+	Pattern string `yaml:"pattern"`
+	Count   int    `yaml:"count"`
+}
+
+// KindDeploy applies the manifests before steps and waits for the workloads declared in them.
+type KindDeploy struct {
+	Manifests []string `yaml:"manifests"`
+	Wait      string   `yaml:"wait" enum:"all,none"`
+}
+
+// Registry is the credential of a private docker registry, used when pulling images.
+type Registry struct {
+	Server   string `yaml:"server"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
 }
 
 type KindExposePort struct {
 	Namespace string `yaml:"namespace"`
 	Resource  string `yaml:"resource"`
 	Port      string `yaml:"port"`
+	// Address is the local address the forwarded ports bind to, defaults to localhost.
+	Address string `yaml:"address"`
+	// Mode is `port-forward` to forward the ports, or `node-port` to export the host ports mapped to the NodePorts
+	// of the service by the kind config, defaults to `port-forward`.
+	Mode string `yaml:"mode" enum:"port-forward,node-port"`
+	// Ready probes the forwarded local ports before exporting them.
+	Ready *KindExposeReady `yaml:"ready"`
+	// Before is the name of the setup step which the port is exposed before, so that the step could reach the service,
+	// the port is exposed after all the steps if it's not set.
+	Before string `yaml:"before"`
+	// WaitReady waits for a ready pod of the resource before forwarding the ports, instead of forwarding to the pod not ready yet
+	// or failing if there is no pod, the setup fails if no pod is ready within the timeout.
+	WaitReady bool `yaml:"wait-ready"`
+}
+
+// KindExposeReady probes the forwarded local port until the backend serves, by TCP connection or HTTP request.
+type KindExposeReady struct {
+	Type    string `yaml:"type" enum:"tcp,http"`
+	Path    string `yaml:"path"`
+	Timeout string `yaml:"timeout"`
 }
 
 type Verify struct {
@@ -92,6 +368,93 @@ type Verify struct {
 	Cases         []VerifyCase        `yaml:"cases"`
 	FailFast      bool                `yaml:"fail-fast"`
 	Concurrency   bool                `yaml:"concurrency"`
+	// Steps are executed once after the trigger and before verifying the cases, such as deleting a pod to verify the recovery.
+	Steps []Step `yaml:"steps"`
+	// IgnorePaths are the JSONPaths of the volatile fields removed from both the actual and the expected data of all the cases
+	// before comparing, such as `$.traces[*].start`.
+	IgnorePaths []string `yaml:"ignore-paths"`
+	// TriggerRetry re-runs the trigger and the verification together on failure, so that the data is re-generated.
+	TriggerRetry TriggerRetry `yaml:"trigger-retry"`
+	// Comparator is how the actual data is compared with the expected data of all the cases, defaults to `yaml`.
+	Comparator string `yaml:"comparator" enum:"yaml,json,text,csv"`
+	// Version is the version of the system under test, such as `${OAP_VERSION}`, which selects the version-gated expected files.
+	Version string `yaml:"version"`
+	// Delay waits for the duration before verifying the cases, such as the known latency before the data is queryable,
+	// instead of retrying the queries which are known to fail during it.
+	Delay string `yaml:"delay"`
+}
+
+// GetVersion returns the version of the system under test with the env vars expanded, empty if it's not set.
+func (v *Verify) GetVersion() string {
+	return os.ExpandEnv(v.Version)
+}
+
+// GetDelay returns the delay before verifying the cases, 0 if it's not set, the invalid delay is reported when loading the config.
+func (v *Verify) GetDelay() time.Duration {
+	return parseDelay(v.Delay)
+}
+
+// CaseOrder returns the indexes of the cases in the order of verifying them, the cases are in the order of declaration
+// except that the cases are moved after the ones they depend on, the dependencies must be the unique names of the cases without cycles.
+func (v *Verify) CaseOrder() ([]int, error) {
+	indexes := make(map[string][]int, len(v.Cases))
+	for i := range v.Cases {
+		if name := v.Cases[i].Name; name != "" {
+			indexes[name] = append(indexes[name], i)
+		}
+	}
+	dependencies := make([][]int, len(v.Cases))
+	for i := range v.Cases {
+		for _, name := range v.Cases[i].DependsOn {
+			switch found := indexes[name]; len(found) {
+			case 0:
+				return nil, fmt.Errorf("the dependency %s of case %s is not found", name, v.Cases[i].Name)
+			case 1:
+				dependencies[i] = append(dependencies[i], found[0])
+			default:
+				return nil, fmt.Errorf("the dependency %s of case %s is ambiguous, there are %d cases of the name", name, v.Cases[i].Name, len(found))
+			}
+		}
+	}
+
+	order := make([]int, 0, len(v.Cases))
+	ordered := make([]bool, len(v.Cases))
+	for len(order) < len(v.Cases) {
+		next := -1
+		for i := range v.Cases {
+			if !ordered[i] && allOrdered(dependencies[i], ordered) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			cyclic := make([]string, 0)
+			for i := range v.Cases {
+				if !ordered[i] {
+					cyclic = append(cyclic, v.Cases[i].Name)
+				}
+			}
+			return nil, fmt.Errorf("the dependencies of cases %v are cyclic", cyclic)
+		}
+		ordered[next] = true
+		order = append(order, next)
+	}
+	return order, nil
+}
+
+func allOrdered(indexes []int, ordered []bool) bool {
+	for _, i := range indexes {
+		if !ordered[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TriggerRetry is the retry strategy of the whole trigger and verify block, unlike the retry of the cases.
+type TriggerRetry struct {
+	Count    int    `yaml:"count"`
+	Interval string `yaml:"interval"`
 }
 
 func (s *Setup) GetFile() string {
@@ -101,6 +464,16 @@ func (s *Setup) GetFile() string {
 	return file
 }
 
+// GetHost returns the host of the published ports with the env vars expanded, empty if it's detected.
+func (c *ComposeSetup) GetHost() string {
+	return os.ExpandEnv(c.Host)
+}
+
+// GetDockerHost returns the docker host of the compose environment with the env vars expanded, empty if it's not set.
+func (c *ComposeSetup) GetDockerHost() string {
+	return os.ExpandEnv(c.DockerHost)
+}
+
 func (s *Setup) GetKubeconfig() string {
 	// expand the file path with system environment
 	file := os.ExpandEnv(s.Kubeconfig)
@@ -109,8 +482,11 @@ func (s *Setup) GetKubeconfig() string {
 }
 
 type Manifest struct {
-	Path  string `yaml:"path"`
-	Waits []Wait `yaml:"wait"`
+	Path      string     `yaml:"path"`
+	Waits     []Wait     `yaml:"wait"`
+	FileWaits []FileWait `yaml:"file-wait"`
+	// Apply applies the manifest files by the server-side apply instead of creating them if not nil.
+	Apply *ManifestApply `yaml:"apply"`
 }
 
 type Run struct {
@@ -123,24 +499,154 @@ type Wait struct {
 	Resource      string `yaml:"resource"`
 	LabelSelector string `yaml:"label-selector"`
 	For           string `yaml:"for"`
+	// AllNamespaces waits for the matching resources across all the namespaces, such as the components of a Helm chart
+	// spreading across namespaces, the namespace must be empty and the resource must be a type rather than a name.
+	AllNamespaces bool `yaml:"all-namespaces"`
+	// Command and Container are used by the `exec` condition, the command is executed in the matched pods.
+	Command   string `yaml:"command"`
+	Container string `yaml:"container"`
+	// PollInterval is the interval of checking the condition, such as 200ms, only for the conditions implemented by e2e.
+	PollInterval string `yaml:"poll-interval"`
+	// Export is the env var name to export the value of the key, only for the `key=` condition.
+	Export string `yaml:"export"`
 }
 
 type Trigger struct {
-	Action   string            `yaml:"action"`
+	Action   string            `yaml:"action" enum:"http,command"`
 	Interval string            `yaml:"interval"`
 	Times    int               `yaml:"times"`
 	URL      string            `yaml:"url"`
 	Method   string            `yaml:"method"`
 	Body     string            `yaml:"body"`
 	Headers  map[string]string `yaml:"headers"`
+	Command  string            `yaml:"command"`
+	// Capture exports the fields of the last successful HTTP response as env vars, the key is the env var name
+	// and the value is the JSONPath expression on the response, such as `{.body.traceId}`.
+	Capture map[string]string `yaml:"capture"`
+	// Success is the condition of the successful HTTP response, defaults to the status code 200.
+	Success TriggerSuccess `yaml:"success"`
+	// Concurrency is the number of the workers sending the HTTP requests concurrently for the load generation,
+	// the times is the total successful requests of all the workers then.
+	Concurrency int `yaml:"concurrency"`
+}
+
+// TriggerSuccess is the condition of the successful HTTP response, the response should meet all the declared conditions.
+type TriggerSuccess struct {
+	// Status is the allowed status codes, defaults to 200.
+	Status []int `yaml:"status"`
+	// Headers are the regular expressions the response headers should match, the headers should be present.
+	Headers map[string]string `yaml:"headers"`
+	// Body are the regular expressions the values of the JSONPath expressions on the response should match,
+	// the expressions are evaluated like the capture, such as `{.body.status}`.
+	Body map[string]string `yaml:"body"`
 }
 
 type VerifyCase struct {
 	Name     string   `yaml:"name"`
 	Query    string   `yaml:"query"`
 	Actual   string   `yaml:"actual"`
+	Metrics  string   `yaml:"metrics"`
 	Expected string   `yaml:"expected"`
 	Includes []string `yaml:"includes"`
+	// ExpectedAnyOf are the expected data files of which any one matches counts as a pass, instead of the expected.
+	ExpectedAnyOf []string `yaml:"expected-any-of"`
+	// ExpectedQuery generates the expected data by the command instead of reading the file, such as querying the reference system.
+	ExpectedQuery string `yaml:"expected-query"`
+	// Stabilize polls the actual data until it stops changing before verifying.
+	Stabilize *VerifyStabilize `yaml:"stabilize"`
+	// Delta verifies the increase of the numbers in the actual data between two queries separated by the interval.
+	Delta *VerifyDelta `yaml:"delta"`
+	// Logs verifies the collected logs of the pods or the compose services instead of the expected data.
+	Logs *VerifyLogs `yaml:"logs"`
+	// GraphQL queries the GraphQL endpoint as the source, the `data` of the response is verified.
+	GraphQL *VerifyGraphQL `yaml:"graphql"`
+	// Swctl queries the metrics of SkyWalking by the swctl command built from the fields, instead of the hand-written query.
+	Swctl *VerifySwctl `yaml:"swctl"`
+	// Instances runs the query against every instance of the scaled service, such as the OAP cluster.
+	Instances *VerifyInstances `yaml:"instances"`
+	// FailOnStderr fails the query if it prints anything to stderr, otherwise the stderr is logged and not matched.
+	FailOnStderr bool `yaml:"fail-on-stderr"`
+	// Comparator overrides the `verify.comparator` of the case, such as `text` for the human-readable tables.
+	Comparator string `yaml:"comparator" enum:"yaml,json,text,csv"`
+	// Soft reports the failure of the case without failing the verification, such as the assertions still being stabilized.
+	Soft bool `yaml:"soft"`
+	// DependsOn are the names of the cases verified before the case, the case is skipped if any of them doesn't pass,
+	// such as the case querying by the ID captured by another case.
+	DependsOn []string `yaml:"depends-on"`
+	// Capture exports the fields of the actual data as the env vars after the case passes, by the JSONPath expressions
+	// such as `{.traces[0].traceId}`, so that the cases depending on it could refer to them by `${NAME}`.
+	Capture map[string]string `yaml:"capture"`
+	// Delay waits for the duration before the first query of the case, after the `verify.delay`.
+	Delay string `yaml:"delay"`
+}
+
+// VerifyInstances runs the query against each instance of the service, the env vars `<service>_<number>_*`
+// of the instance are exported as `<service>_*` when running the query.
+type VerifyInstances struct {
+	Service string `yaml:"service"`
+	// Mode is `any` to pass when the output of any instance matches, or `merge` to verify the merged outputs, defaults to `any`.
+	Mode string `yaml:"mode" enum:"any,merge"`
+}
+
+// VerifyGraphQL is the GraphQL query posted to the endpoint, such as the queries of the SkyWalking UI.
+type VerifyGraphQL struct {
+	URL       string            `yaml:"url"`
+	Query     string            `yaml:"query"`
+	Variables map[string]any    `yaml:"variables"`
+	Headers   map[string]string `yaml:"headers"`
+}
+
+// VerifySwctl is the metrics query of SkyWalking executed by swctl, such as `swctl metrics linear --name=service_sla`.
+type VerifySwctl struct {
+	// BaseURL is the GraphQL endpoint of OAP, defaults to `http://${oap_host}:${oap_12800}/graphql`.
+	BaseURL string `yaml:"base-url"`
+	// Name is the metrics name queried by `metrics linear`, or Expression is the MQE queried by `metrics exec`.
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+	// Service, Instance and Endpoint are the entity of the metrics.
+	Service  string `yaml:"service"`
+	Instance string `yaml:"instance"`
+	Endpoint string `yaml:"endpoint"`
+	// Start and End are the time range, either the durations before now such as `30m`, or the times in the format of the step.
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+	Step  string `yaml:"step" enum:"SECOND,MINUTE,HOUR,DAY"`
+	// Series verifies the values of the metrics series over the time range by the condition, instead of the expected data.
+	Series *VerifySeries `yaml:"series"`
+}
+
+// VerifySeries matches the values of the metrics series, such as all the values over the last 3 minutes are `> 0`.
+type VerifySeries struct {
+	// Match is `all` to check all the values, `any` to check any one of them, or `last` to check the last one, defaults to `all`.
+	Match string `yaml:"match" enum:"all,any,last"`
+	// Condition is the comparison of the values, such as `> 0`, `>= 99.5` or `== 100`.
+	Condition string `yaml:"condition"`
+}
+
+// VerifyLogs matches the lines of the collected log files against the regular expressions.
+type VerifyLogs struct {
+	// Files are the glob patterns of the log files relative to the log directory,
+	// such as `default/oap-*.log` of the KinD pods or `oap/std.log` of the compose services.
+	Files    []string `yaml:"files"`
+	Contains []string `yaml:"contains"`
+	Absent   []string `yaml:"absent"`
+	// Since limits the lines to verify, `trigger` verifies the lines written after the trigger started only.
+	Since string `yaml:"since" enum:"trigger"`
+}
+
+// VerifyDelta captures the baseline of the actual data, and verifies the difference after the interval.
+type VerifyDelta struct {
+	Interval string `yaml:"interval"`
+	// FromZero treats the numbers without the paired baseline, such as the metrics of the new endpoints, as increased from 0,
+	// otherwise the verification fails as their absolute values are not the increase.
+	FromZero bool `yaml:"from-zero"`
+}
+
+// VerifyStabilize requires the actual data to be identical across the consecutive polls.
+type VerifyStabilize struct {
+	Times    int    `yaml:"times"`
+	Interval string `yaml:"interval"`
+	Timeout  string `yaml:"timeout"`
 }
 
 type VerifyRetryStrategy struct {
@@ -157,11 +663,50 @@ func (v *VerifyCase) GetActual() string {
 	return util.ResolveAbs(v.Actual)
 }
 
+// GetDelay returns the delay before the first query of the case, 0 if it's not set.
+func (v *VerifyCase) GetDelay() time.Duration {
+	return parseDelay(v.Delay)
+}
+
+// parseDelay parses the delay, the invalid or negative ones are 0.
+func parseDelay(delay string) time.Duration {
+	d, err := time.ParseDuration(delay)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// validateDelay checks the delay is empty or a non-negative duration.
+func validateDelay(delay, field string) error {
+	if delay == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(delay); err != nil {
+		return fmt.Errorf("failed to parse %s %s: %v", field, delay, err)
+	} else if d < 0 {
+		return fmt.Errorf("%s should not be negative, but was %s", field, delay)
+	}
+	return nil
+}
+
 // GetExpected resolves the absolute file path of the expected data file.
 func (v *VerifyCase) GetExpected() string {
 	return util.ResolveAbs(v.Expected)
 }
 
+// GetExpectedFiles resolves the absolute file paths of the expected data files, either the expected or the expected-any-of.
+func (v *VerifyCase) GetExpectedFiles() []string {
+	if v.Expected != "" {
+		return []string{v.GetExpected()}
+	}
+	files := make([]string, 0, len(v.ExpectedAnyOf))
+	for _, file := range v.ExpectedAnyOf {
+		files = append(files, util.ResolveAbs(file))
+	}
+	return files
+}
+
 // parseInterval parses a Duration field with number and string content for compatibility,
 // only use this when we previously allow configuring number like 120 and now string like 2m.
 // TODO remove this in 2.0
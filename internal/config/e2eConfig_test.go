@@ -21,6 +21,9 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 
 	"github.com/apache/skywalking-infra-e2e/internal/util"
 	"k8s.io/apimachinery/pkg/util/rand"
@@ -77,3 +80,161 @@ func TestSetup_GetFile(t *testing.T) {
 		})
 	}
 }
+
+func TestSetup_FinalizeEnvironments(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   Setup
+		wantErr bool
+	}{
+		{
+			name: "should inherit the timeout of setup",
+			setup: Setup{Timeout: "5m", Environments: []Environment{
+				{Name: "primary", Setup: Setup{Env: "kind"}},
+				{Name: "secondary", Setup: Setup{Env: "compose", Timeout: "1m"}},
+			}},
+		},
+		{
+			name:    "should fail without environment name",
+			setup:   Setup{Environments: []Environment{{Setup: Setup{Env: "kind"}}}},
+			wantErr: true,
+		},
+		{
+			name: "should fail with duplicated environment name",
+			setup: Setup{Environments: []Environment{
+				{Name: "primary", Setup: Setup{Env: "kind"}},
+				{Name: "primary", Setup: Setup{Env: "compose"}},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "should fail when env and environments are both set",
+			setup:   Setup{Env: "kind", Environments: []Environment{{Name: "primary", Setup: Setup{Env: "kind"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "should fail when file and inline kind config are both set",
+			setup:   Setup{Env: "kind", File: "kind.yaml", Kind: KindSetup{Content: "kind: Cluster"}},
+			wantErr: true,
+		},
+		{
+			name:    "should fail with before in environments",
+			setup:   Setup{Environments: []Environment{{Name: "primary", Setup: Setup{Env: "kind", Before: "echo"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.setup.Finalize()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Setup.Finalize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			environments := tt.setup.GetEnvironments()
+			if environments[0].GetTimeout() != 5*time.Minute || environments[1].GetTimeout() != time.Minute {
+				t.Errorf("Setup.Finalize() timeouts = %v, %v", environments[0].GetTimeout(), environments[1].GetTimeout())
+			}
+		})
+	}
+}
+
+func TestSetup_FinalizeEnvVars(t *testing.T) {
+	setup := Setup{EnvVars: map[string]string{"NAMESPACE": "skywalking", "TOKEN": "secret"}, Environments: []Environment{
+		{Name: "primary", Setup: Setup{Env: "kind"}},
+		{Name: "secondary", Setup: Setup{Env: "compose", EnvVars: map[string]string{"NAMESPACE": "secondary"}}},
+	}}
+	if err := setup.Finalize(); err != nil {
+		t.Fatalf("Setup.Finalize() error = %v", err)
+	}
+	want := []map[string]string{
+		{"NAMESPACE": "skywalking", "TOKEN": "secret"},
+		{"NAMESPACE": "secondary", "TOKEN": "secret"},
+	}
+	for i, environment := range setup.GetEnvironments() {
+		if diff := cmp.Diff(want[i], environment.EnvVars); diff != "" {
+			t.Errorf("Setup.Finalize() env vars of %s mismatch (-want +got):\n%s", environment.Name, diff)
+		}
+	}
+}
+
+func TestVerify_CaseOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		cases   []VerifyCase
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:  "no dependencies",
+			cases: []VerifyCase{{Name: "a"}, {Name: "b"}, {}},
+			want:  []int{0, 1, 2},
+		},
+		{
+			name:  "dependency declared later",
+			cases: []VerifyCase{{Name: "trace", DependsOn: []string{"traces"}}, {Name: "service"}, {Name: "traces"}},
+			want:  []int{1, 2, 0},
+		},
+		{
+			name: "transitive dependencies",
+			cases: []VerifyCase{
+				{Name: "spans", DependsOn: []string{"trace"}},
+				{Name: "trace", DependsOn: []string{"traces"}},
+				{Name: "traces"},
+			},
+			want: []int{2, 1, 0},
+		},
+		{
+			name:    "unknown dependency",
+			cases:   []VerifyCase{{Name: "trace", DependsOn: []string{"traces"}}},
+			wantErr: true,
+		},
+		{
+			name:    "ambiguous dependency",
+			cases:   []VerifyCase{{Name: "traces"}, {Name: "traces"}, {Name: "trace", DependsOn: []string{"traces"}}},
+			wantErr: true,
+		},
+		{
+			name:    "cyclic dependencies",
+			cases:   []VerifyCase{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Verify{Cases: tt.cases}
+			got, err := v.CaseOrder()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CaseOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); !tt.wantErr && diff != "" {
+				t.Errorf("CaseOrder() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateDelay(t *testing.T) {
+	tests := []struct {
+		delay   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{delay: "", want: 0},
+		{delay: "30s", want: 30 * time.Second},
+		{delay: "30", wantErr: true},
+		{delay: "-1s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.delay, func(t *testing.T) {
+			if err := validateDelay(tt.delay, "verify.delay"); (err != nil) != tt.wantErr {
+				t.Fatalf("validateDelay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := (&Verify{Delay: tt.delay}).GetDelay(); !tt.wantErr && got != tt.want {
+				t.Errorf("GetDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
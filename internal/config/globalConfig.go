@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"gopkg.in/yaml.v2"
 
@@ -49,6 +50,23 @@ func init() {
 	GlobalConfig.E2EConfig.Verify.FailFast = true
 }
 
+// unmarshalConfig decodes the config file leniently, so that the files with the keys of the other versions still work,
+// the unknown keys, such as the misspelled ones, are warned as the JSON Schema reports them.
+func unmarshalConfig(file string, data []byte, out any) error {
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return err
+	}
+	if err := unknownKeys(data, out); err != nil {
+		logger.Log.Warnf("the unknown keys in %s are ignored, %v", file, err)
+	}
+	return nil
+}
+
+// unknownKeys decodes the data strictly into a new value of the same type as out, the error reports the unknown keys.
+func unknownKeys(data []byte, out any) error {
+	return yaml.UnmarshalStrict(data, reflect.New(reflect.TypeOf(out).Elem()).Interface())
+}
+
 func ReadGlobalConfigFile() {
 	if !util.PathExist(util.CfgFile) {
 		GlobalConfig.Error = fmt.Errorf("e2e config file %s not exist", util.CfgFile)
@@ -61,11 +79,16 @@ func ReadGlobalConfigFile() {
 		return
 	}
 
-	if err := yaml.Unmarshal(data, &GlobalConfig.E2EConfig); err != nil {
+	if err := unmarshalConfig(util.CfgFile, data, &GlobalConfig.E2EConfig); err != nil {
 		GlobalConfig.Error = fmt.Errorf("unmarshal e2e config file %s error: %s", util.CfgFile, err)
 		return
 	}
 
+	if err := GlobalConfig.E2EConfig.Validate(); err != nil {
+		GlobalConfig.Error = fmt.Errorf("invalid e2e config file %s: %v", util.CfgFile, err)
+		return
+	}
+
 	// convert verify
 	if err := convertVerify(&GlobalConfig.E2EConfig.Verify); err != nil {
 		GlobalConfig.Error = err
@@ -74,6 +97,7 @@ func ReadGlobalConfigFile() {
 
 	if err := GlobalConfig.E2EConfig.Setup.Finalize(); err != nil {
 		GlobalConfig.Error = err
+		return
 	}
 
 	GlobalConfig.Error = nil
@@ -83,6 +107,9 @@ func ReadGlobalConfigFile() {
 }
 
 func convertVerify(verify *Verify) error {
+	if err := validateDelay(verify.Delay, "verify.delay"); err != nil {
+		return err
+	}
 	// convert cases
 	result := make([]VerifyCase, 0)
 	cfgAbsPath, _ := filepath.Abs(util.CfgFile)
@@ -94,18 +121,48 @@ func convertVerify(verify *Verify) error {
 		result = append(result, cases...)
 	}
 	verify.Cases = result
+	// check the dependencies of the cases when loading, rather than after the setup
+	if _, err := verify.CaseOrder(); err != nil {
+		return err
+	}
 	return nil
 }
 
 func convertSingleCase(verifyCase *VerifyCase, baseFile string) ([]VerifyCase, error) {
-	if len(verifyCase.Includes) > 0 && (verifyCase.Expected != "" || verifyCase.Query != "") {
-		return nil, fmt.Errorf("include and query/expected only support selecting one of them in a case")
+	if len(verifyCase.Includes) > 0 && (verifyCase.Expected != "" || len(verifyCase.ExpectedAnyOf) > 0 || verifyCase.ExpectedQuery != "" ||
+		verifyCase.Query != "" || verifyCase.Metrics != "" || verifyCase.GraphQL != nil || verifyCase.Swctl != nil) {
+		return nil, fmt.Errorf("include and query/metrics/graphql/swctl/expected only support selecting one of them in a case")
+	}
+	if verifyCase.Swctl != nil && (verifyCase.Swctl.Name == "") == (verifyCase.Swctl.Expression == "") {
+		return nil, fmt.Errorf("swctl only supports selecting one of name and expression in a case")
+	}
+	if verifyCase.Swctl != nil && verifyCase.Swctl.Series != nil &&
+		(verifyCase.Expected != "" || len(verifyCase.ExpectedAnyOf) > 0 || verifyCase.ExpectedQuery != "" || verifyCase.Swctl.Series.Condition == "") {
+		return nil, fmt.Errorf("swctl series needs the condition, and doesn't support the expected data in a case")
+	}
+	if verifyCase.Instances != nil && (verifyCase.Query == "" || verifyCase.Instances.Service == "") {
+		return nil, fmt.Errorf("instances only support the query case with the service")
+	}
+	if len(verifyCase.Capture) > 0 && (verifyCase.Logs != nil || len(verifyCase.Includes) > 0) {
+		return nil, fmt.Errorf("capture only supports the cases with the actual data, not logs or includes")
+	}
+	if err := validateDelay(verifyCase.Delay, "the delay of case "+verifyCase.Name); err != nil {
+		return nil, err
+	}
+	if verifyCase.Expected != "" && len(verifyCase.ExpectedAnyOf) > 0 {
+		return nil, fmt.Errorf("expected and expected-any-of only support selecting one of them in a case")
+	}
+	if verifyCase.ExpectedQuery != "" && (verifyCase.Expected != "" || len(verifyCase.ExpectedAnyOf) > 0) {
+		return nil, fmt.Errorf("expected-query and expected/expected-any-of only support selecting one of them in a case")
 	}
 	if len(verifyCase.Includes) == 0 {
 		// using base path to resolve case paths
 		if verifyCase.Expected != "" {
 			verifyCase.Expected = util.ResolveAbsWithBase(verifyCase.Expected, baseFile)
 		}
+		for i, expected := range verifyCase.ExpectedAnyOf {
+			verifyCase.ExpectedAnyOf[i] = util.ResolveAbsWithBase(expected, baseFile)
+		}
 		if verifyCase.Actual != "" {
 			verifyCase.Actual = util.ResolveAbsWithBase(verifyCase.Actual, baseFile)
 		}
@@ -125,7 +182,7 @@ func convertSingleCase(verifyCase *VerifyCase, baseFile string) ([]VerifyCase, e
 		}
 
 		r := &ReusingCases{}
-		if err := yaml.Unmarshal(data, r); err != nil {
+		if err := unmarshalConfig(includePath, data, r); err != nil {
 			return nil, fmt.Errorf("unmarshal reuse case config file %s error: %s", includePath, err)
 		}
 
@@ -135,6 +192,11 @@ func convertSingleCase(verifyCase *VerifyCase, baseFile string) ([]VerifyCase, e
 			if err != nil {
 				return nil, err
 			}
+			// the included cases are all soft if the including case is soft, and depend on the dependencies of the including case
+			for i := range cases {
+				cases[i].Soft = cases[i].Soft || verifyCase.Soft
+				cases[i].DependsOn = append(cases[i].DependsOn, verifyCase.DependsOn...)
+			}
 			result = append(result, cases...)
 		}
 	}
@@ -0,0 +1,186 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Schema generates the JSON Schema of the configuration file from the E2EConfig struct,
+// the field names come from the `yaml` tags and the allowed values come from the `enum` tags.
+func Schema() map[string]any {
+	schema := typeSchema(reflect.TypeOf(E2EConfig{}), make(map[reflect.Type]bool))
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = "SkyWalking Infra E2E Configuration"
+	return schema
+}
+
+// Validate checks the fields with `enum` tags only contain the allowed values, the empty values are ignored.
+func (c *E2EConfig) Validate() error {
+	return validateEnums(reflect.ValueOf(c).Elem(), "")
+}
+
+// typeSchema generates the schema of the type, the types in the stack are not expanded again to avoid infinite recursion.
+func typeSchema(t reflect.Type, stack map[reflect.Type]bool) map[string]any {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), stack)
+	case reflect.Struct:
+		if stack[t] {
+			return map[string]any{"type": "object"}
+		}
+		stack[t] = true
+		defer delete(stack, t)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           structProperties(t, stack),
+			"additionalProperties": false,
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), stack)}
+	case reflect.Map:
+		// the `any` values of the maps are arbitrary, such as the variables of the GraphQL queries
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object"}
+		}
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), stack)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Interface:
+		// the `any` fields are the durations which are compatible with numbers, such as 10s or 10
+		return map[string]any{"type": []string{"string", "integer"}}
+	}
+	return map[string]any{}
+}
+
+// structProperties generates the schema of the struct fields, the fields of the inline structs are merged.
+func structProperties(t reflect.Type, stack map[reflect.Type]bool) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline := yamlField(&field)
+		if inline {
+			for k, v := range structProperties(field.Type, stack) {
+				properties[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		fieldSchema := typeSchema(field.Type, stack)
+		// the fields tagged with `schema:"any"` accept the values of any type, such as the values of the manifest patches
+		if field.Tag.Get("schema") == "any" {
+			fieldSchema = map[string]any{}
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			fieldSchema["enum"] = strings.Split(enum, ",")
+		}
+		properties[name] = fieldSchema
+	}
+	return properties
+}
+
+func validateEnums(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return validateEnums(v.Elem(), path)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, inline := yamlField(&field)
+			if inline {
+				if err := validateEnums(v.Field(i), path); err != nil {
+					return err
+				}
+				continue
+			}
+			if name == "" {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if err := validateEnumField(v.Field(i), &field, fieldPath); err != nil {
+				return err
+			}
+			if err := validateEnums(v.Field(i), fieldPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateEnums(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateEnumField(v reflect.Value, field *reflect.StructField, path string) error {
+	enum := field.Tag.Get("enum")
+	if enum == "" || v.Kind() != reflect.String || v.String() == "" {
+		return nil
+	}
+	allowed := strings.Split(enum, ",")
+	for _, a := range allowed {
+		if v.String() == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported value %q of %s, should be one of %v", v.String(), path, allowed)
+}
+
+// yamlField returns the name of the field in the configuration file and whether the field is inlined,
+// returns empty name if the field is ignored.
+func yamlField(field *reflect.StructField) (name string, inline bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tags := strings.Split(field.Tag.Get("yaml"), ",")
+	for _, flag := range tags[1:] {
+		if flag == "inline" {
+			return "", field.Type.Kind() == reflect.Struct
+		}
+	}
+	if tags[0] == "-" {
+		return "", false
+	}
+	if tags[0] == "" {
+		return strings.ToLower(field.Name), false
+	}
+	return tags[0], false
+}
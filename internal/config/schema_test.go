@@ -0,0 +1,170 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  E2EConfig
+		wantErr bool
+	}{
+		{
+			name:   "should pass with empty values",
+			config: E2EConfig{},
+		},
+		{
+			name:   "should pass with allowed values",
+			config: E2EConfig{Setup: Setup{Env: "kind", Kind: KindSetup{Deploy: KindDeploy{Wait: "none"}}}, Cleanup: Cleanup{On: "always"}},
+		},
+		{
+			name:    "should fail with unknown env",
+			config:  E2EConfig{Setup: Setup{Env: "k8s"}},
+			wantErr: true,
+		},
+		{
+			name:    "should fail with unknown cleanup policy",
+			config:  E2EConfig{Cleanup: Cleanup{On: "sometimes"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantUnknown bool
+		wantErr     bool
+	}{
+		{name: "should decode the known keys", data: "setup:\n  env: compose\n"},
+		{name: "should decode the unknown keys leniently", data: "setup:\n  env: compose\n  tiemout: 10m\n", wantUnknown: true},
+		{name: "should fail with the invalid yaml", data: "setup: [", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e2eConfig E2EConfig
+			if err := unmarshalConfig("e2e.yaml", []byte(tt.data), &e2eConfig); (err != nil) != tt.wantErr {
+				t.Fatalf("unmarshalConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if e2eConfig.Setup.Env != constant.Compose {
+				t.Errorf("unmarshalConfig() setup.env = %q, want %q", e2eConfig.Setup.Env, constant.Compose)
+			}
+			if err := unknownKeys([]byte(tt.data), &e2eConfig); (err != nil) != tt.wantUnknown {
+				t.Errorf("unknownKeys() error = %v, wantUnknown %v", err, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	property := func(schema map[string]any, path ...string) map[string]any {
+		for _, p := range path {
+			schema = schema["properties"].(map[string]any)[p].(map[string]any)
+		}
+		return schema
+	}
+
+	schema := Schema()
+	if schema["additionalProperties"] != false {
+		t.Errorf("Schema() should not allow additional properties")
+	}
+	if got := property(schema, "trigger", "action")["enum"]; !cmp.Equal(got, []string{"http", "command"}) {
+		t.Errorf("Schema() trigger.action enum = %v", got)
+	}
+	if got := property(schema, "setup", "kind", "deploy", "wait")["enum"]; !cmp.Equal(got, []string{"all", "none"}) {
+		t.Errorf("Schema() setup.kind.deploy.wait enum = %v", got)
+	}
+	if got := property(schema, "setup", "init-system-environment")["type"]; got != "string" {
+		t.Errorf("Schema() setup.init-system-environment type = %v", got)
+	}
+	if got := property(schema, "verify", "cases")["type"]; got != "array" {
+		t.Errorf("Schema() verify.cases type = %v", got)
+	}
+	variables := property(schema, "verify", "cases")["items"].(map[string]any)["properties"].(map[string]any)["graphql"]
+	if got := property(variables.(map[string]any), "variables"); !cmp.Equal(got, map[string]any{"type": "object"}) {
+		t.Errorf("Schema() verify.cases.graphql.variables = %v", got)
+	}
+	patches := property(schema, "setup", "kind", "patches")["items"].(map[string]any)
+	if got := property(patches, "value"); !cmp.Equal(got, map[string]any{}) {
+		t.Errorf("Schema() setup.kind.patches.value = %v", got)
+	}
+}
+
+func TestEnumTagsMatchConstants(t *testing.T) {
+	tests := []struct {
+		structType any
+		field      string
+		want       []string
+	}{
+		{structType: Setup{}, field: "Env", want: []string{constant.Kind, constant.Compose}},
+		{structType: Cleanup{}, field: "On", want: []string{
+			constant.CleanUpOnSuccess, constant.CleanUpOnFailure, constant.CleanUpAlways, constant.CleanUpNever,
+		}},
+		{structType: Trigger{}, field: "Action", want: []string{constant.ActionHTTP, constant.ActionCMD}},
+		{structType: KindDeploy{}, field: "Wait", want: []string{constant.DeployWaitAll, constant.DeployWaitNone}},
+		{structType: KindSetup{}, field: "ImagePullPolicy", want: []string{constant.ImagePullAlways, constant.ImagePullIfNotPresent}},
+		{structType: KindSetup{}, field: "ExportLogs", want: []string{constant.ExportLogsOnFailure, constant.ExportLogsAlways}},
+		{structType: ComposeSetup{}, field: "IPFamily", want: []string{constant.IPv4, constant.IPv6}},
+		{structType: ComposeSetup{}, field: "ExportLogs", want: []string{constant.ExportLogsOnFailure}},
+		{structType: KindExposePort{}, field: "Mode", want: []string{constant.ExposeModePortForward, constant.ExposeModeNodePort}},
+		{structType: KindExposeReady{}, field: "Type", want: []string{constant.ExposeReadyTCP, constant.ExposeReadyHTTP}},
+		{structType: VerifyLogs{}, field: "Since", want: []string{constant.LogsSinceTrigger}},
+		{structType: Verify{}, field: "Comparator", want: []string{
+			constant.ComparatorYAML, constant.ComparatorJSON, constant.ComparatorText, constant.ComparatorCSV,
+		}},
+		{structType: VerifyCase{}, field: "Comparator", want: []string{
+			constant.ComparatorYAML, constant.ComparatorJSON, constant.ComparatorText, constant.ComparatorCSV,
+		}},
+		{structType: VerifySwctl{}, field: "Step", want: []string{
+			constant.SwctlStepSecond, constant.SwctlStepMinute, constant.SwctlStepHour, constant.SwctlStepDay,
+		}},
+		{structType: VerifyInstances{}, field: "Mode", want: []string{constant.InstancesModeAny, constant.InstancesModeMerge}},
+		{structType: VerifySeries{}, field: "Match", want: []string{constant.SeriesMatchAll, constant.SeriesMatchAny, constant.SeriesMatchLast}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field, ok := reflect.TypeOf(tt.structType).FieldByName(tt.field)
+			if !ok {
+				t.Fatalf("field %s not found", tt.field)
+			}
+			if got := strings.Split(field.Tag.Get("enum"), ","); !cmp.Equal(got, tt.want) {
+				t.Errorf("enum tag of %s mismatch (-want +got):\n%s", tt.field, cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
@@ -21,4 +21,14 @@ package constant
 const (
 	Compose        = "compose"
 	ComposeCommand = "docker-compose"
+	IPv4           = "ipv4"
+	IPv6           = "ipv6"
+
+	// ComposeNetworkProject resolves the gateway against the default network created by compose for the project.
+	ComposeNetworkProject = "project"
+
+	// DockerDesktop is the operating system reported by the daemon of Docker Desktop for Mac and Windows, whose gateways
+	// are not reachable from the containers, the published ports are reached by DockerDesktopHost instead.
+	DockerDesktop     = "Docker Desktop"
+	DockerDesktopHost = "host.docker.internal"
 )
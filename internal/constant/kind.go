@@ -30,10 +30,29 @@ const (
 	KindClusterDefaultName   = "kind"
 	E2EDefaultFile           = "e2e.yaml"
 	K8sClusterConfigFileName = "e2e-k8s.config"
-	DefaultWaitTimeout       = 600 * time.Second
-	SingleDefaultWaitTimeout = 30 * 60 * time.Second
 	StepTypeManifest         = "manifest"
 	StepTypeCommand          = "command"
+	DeployWaitAll            = "all"
+	DeployWaitNone           = "none"
+	WaitForRollout           = "rollout"
+	WaitForBound             = "bound"
+	WaitForExec              = "exec"
+	WaitForJSONPath          = "jsonpath="
+	WaitForLoadBalancer      = "load-balancer"
+	WaitForKey               = "key="
+	WaitForEvent             = "event="
+	WaitForAbsent            = "absent"
+	WaitForLog               = "log="
+	ImagePullAlways          = "always"
+	ImagePullIfNotPresent    = "if-not-present"
+	ExportLogsOnFailure      = "on-failure"
+	ExportLogsAlways         = "always"
+	ExposeReadyTCP           = "tcp"
+	ExposeReadyHTTP          = "http"
+	ExposeModePortForward    = "port-forward"
+	ExposeModeNodePort       = "node-port"
+	SetupFailureReportFile   = "setup-failure.yaml"
+	DefaultFieldManager      = "skywalking-infra-e2e"
 )
 
 func init() {
@@ -51,4 +70,9 @@ var (
 	True                     = true
 	False                    = false
 	K8sClusterConfigFilePath = path.Join(os.TempDir(), K8sClusterConfigFileName)
+
+	// DefaultWaitTimeout is the timeout of the setup if not set, SingleDefaultWaitTimeout is the timeout of each wait condition,
+	// they could be overridden by the command line flags or the environment variables.
+	DefaultWaitTimeout       = 600 * time.Second
+	SingleDefaultWaitTimeout = 30 * 60 * time.Second
 )
@@ -20,5 +20,5 @@ package constant
 
 const (
 	ActionHTTP = "http"
-	ActionCMD  = "cmd"
+	ActionCMD  = "command"
 )
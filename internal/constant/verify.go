@@ -0,0 +1,55 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package constant
+
+const (
+	// LogsSinceTrigger verifies the log lines written after the trigger step started only.
+	LogsSinceTrigger = "trigger"
+	// LogOffsetsFile is the file in the working directory recording the sizes of the log files when the trigger starts.
+	LogOffsetsFile = "log-offsets.json"
+	// TriggerStartFile is the file in the working directory recording the time when the trigger starts.
+	TriggerStartFile = "trigger-start"
+	// LogsSinceAll exports the whole logs of the containers on failure.
+	LogsSinceAll = "all"
+
+	// the comparators of the actual data and the expected data
+	ComparatorYAML = "yaml"
+	ComparatorJSON = "json"
+	ComparatorText = "text"
+	ComparatorCSV  = "csv"
+
+	// InstancesModeAny passes when the output of any instance matches the expected data.
+	InstancesModeAny = "any"
+	// InstancesModeMerge merges the outputs of all the instances, and verifies the merged data.
+	InstancesModeMerge = "merge"
+
+	// SwctlBaseURL is the default GraphQL endpoint of the swctl cases, the `oap` service exposed by the setup.
+	SwctlBaseURL = "http://${oap_host}:${oap_12800}/graphql"
+
+	// the steps of the time range of the swctl cases
+	SwctlStepSecond = "SECOND"
+	SwctlStepMinute = "MINUTE"
+	SwctlStepHour   = "HOUR"
+	SwctlStepDay    = "DAY"
+
+	// the matchers of the values of the metrics series over the time range
+	SeriesMatchAll  = "all"
+	SeriesMatchAny  = "any"
+	SeriesMatchLast = "last"
+)
@@ -30,6 +30,10 @@ var (
 	WorkDir   string
 	LogDir    string
 	BatchMode bool
+	// EnvFileOut is the dotenv file which the exported env vars are written into as they're set.
+	EnvFileOut string
+	// LogsSince is the start point of the logs exported on failure, such as `trigger`, `all`, 10m or a RFC3339 timestamp.
+	LogsSince string
 )
 
 // ResolveAbs resolves the relative path (relative to CfgFile) to an absolute file path.
@@ -27,10 +27,14 @@ import (
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 )
 
-// CheckDockerDaemon checks if docker daemon is running.
-func CheckDockerDaemon() error {
+// CheckDockerDaemon checks if docker daemon is running, the host overrides the one from the environment, such as DOCKER_HOST, if it's not empty.
+func CheckDockerDaemon(host string) error {
 	logger.Log.Debug("checking docker daemon")
-	cli, err := client.NewClientWithOpts(client.FromEnv)
+	opts := []client.Opt{client.FromEnv}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
 	if err == nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -21,16 +21,22 @@ package util
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	apiv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+	"k8s.io/apimachinery/pkg/types"
+	k8swait "k8s.io/apimachinery/pkg/util/wait"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
@@ -39,26 +45,72 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 
 	"github.com/apache/skywalking-infra-e2e/internal/constant"
 	"github.com/apache/skywalking-infra-e2e/internal/logger"
 )
 
+// webhookRetryBackoff is the backoff of creating the objects when the admission webhooks are not ready yet,
+// it retries for about 2 minutes in total.
+var webhookRetryBackoff = k8swait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    8,
+	Cap:      30 * time.Second,
+}
+
 // K8sClusterInfo created when connect to cluster
 type K8sClusterInfo struct {
 	Client     *kubernetes.Clientset
 	Interface  dynamic.Interface
 	restConfig *rest.Config
 	namespace  string
+	// kubeContext is the context in the kubeconfig to use, the current context is used if empty.
+	kubeContext string
+	// discovery is shared by the copies of the cluster, so that the API discovery is requested once
+	// for all the wait blocks and the manifest operations of the setup.
+	discovery *discoveryCache
+	// transforms mutate the objects of the manifests before they're created.
+	transforms []ObjectTransform
+}
+
+// discoveryCache caches the discovered API resources and the REST mapper built from them.
+type discoveryCache struct {
+	client discovery.CachedDiscoveryInterface
+	mapper *restmapper.DeferredDiscoveryRESTMapper
+}
+
+func newDiscoveryCache(client discovery.DiscoveryInterface) *discoveryCache {
+	cached := memory.NewMemCacheClient(client)
+	return &discoveryCache{client: cached, mapper: restmapper.NewDeferredDiscoveryRESTMapper(cached)}
+}
+
+// restMapping maps the kind to the resource, the cache is refreshed once if the kind is not found,
+// such as the custom resources whose CRDs are created after the discovery.
+func (d *discoveryCache) restMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := d.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		d.mapper.Reset()
+		mapping, err = d.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	return mapping, err
 }
 
 type KindClusterNameConfig struct {
 	Name string `json:"name"`
 }
 
-// ConnectToK8sCluster gets clientSet and dynamic client from k8s config file.
-func ConnectToK8sCluster(kubeConfigPath string) (info *K8sClusterInfo, err error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+// ConnectToK8sCluster gets clientSet and dynamic client from k8s config file,
+// the kubeContext selects the context in the config file, the current context is used if empty.
+func ConnectToK8sCluster(kubeConfigPath, kubeContext string) (info *K8sClusterInfo, err error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeConfigPath},
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	)
+	config, err := clientConfig.ClientConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -72,54 +124,67 @@ func ConnectToK8sCluster(kubeConfigPath string) (info *K8sClusterInfo, err error
 		return nil, err
 	}
 
-	kubeConfigYaml, err := os.ReadFile(kubeConfigPath)
-	if err != nil {
-		return nil, err
-	}
-	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeConfigYaml)
+	discoveryConfig := rest.CopyConfig(config)
+	discoveryConfig.Burst = 100
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(discoveryConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	logger.Log.Info("connect to k8s cluster succeeded")
 
-	return &K8sClusterInfo{c, dc, restConfig, ""}, nil
+	return &K8sClusterInfo{
+		Client:      c,
+		Interface:   dc,
+		restConfig:  rest.CopyConfig(config),
+		kubeContext: kubeContext,
+		discovery:   newDiscoveryCache(discoveryClient),
+	}, nil
+}
+
+// KubeConfigWithContext writes a copy of the kubeconfig file with the current context switched to the kubeContext into the path,
+// so that the command lines such as `kubectl` target the same cluster. The copy contains the credentials, it's written with
+// the permission of the owner only, and should be removed in the cleanup.
+func KubeConfigWithContext(kubeConfigPath, kubeContext, path string) error {
+	kubeConfig, err := clientcmd.LoadFromFile(kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	if _, exists := kubeConfig.Contexts[kubeContext]; !exists {
+		return fmt.Errorf("context %s does not exist in kubeconfig %s", kubeContext, kubeConfigPath)
+	}
+	kubeConfig.CurrentContext = kubeContext
+
+	return clientcmd.WriteToFile(*kubeConfig, path)
 }
 
 func (c *K8sClusterInfo) CopyClusterToNamespace(namespace string) *K8sClusterInfo {
 	return &K8sClusterInfo{
-		Client:     c.Client,
-		Interface:  c.Interface,
-		restConfig: c.restConfig,
-		namespace:  namespace,
+		Client:      c.Client,
+		Interface:   c.Interface,
+		restConfig:  c.restConfig,
+		namespace:   namespace,
+		kubeContext: c.kubeContext,
+		discovery:   c.discovery,
+		transforms:  c.transforms,
 	}
 }
 
+// AddObjectTransform adds the transform applied to each object of the manifests before creating it.
+func (c *K8sClusterInfo) AddObjectTransform(transform ObjectTransform) {
+	c.transforms = append(c.transforms, transform)
+}
+
 func (c *K8sClusterInfo) ToRESTConfig() (*rest.Config, error) {
 	return c.restConfig, nil
 }
 
 func (c *K8sClusterInfo) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
-	config, err := c.ToRESTConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	config.Burst = 100
-
-	discoveryClient, _ := discovery.NewDiscoveryClientForConfig(config)
-	return memory.NewMemCacheClient(discoveryClient), nil
+	return c.discovery.client, nil
 }
 
 func (c *K8sClusterInfo) ToRESTMapper() (meta.RESTMapper, error) {
-	discoveryClient, err := c.ToDiscoveryClient()
-	if err != nil {
-		return nil, err
-	}
-
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(discoveryClient)
-	expander := restmapper.NewShortcutExpander(mapper, discoveryClient)
-	return expander, nil
+	return restmapper.NewShortcutExpander(c.discovery.mapper, c.discovery.client), nil
 }
 
 func (c *K8sClusterInfo) ToRawKubeConfigLoader() clientcmd.ClientConfig {
@@ -128,6 +193,7 @@ func (c *K8sClusterInfo) ToRawKubeConfigLoader() clientcmd.ClientConfig {
 
 	overrides := &clientcmd.ConfigOverrides{ClusterDefaults: clientcmd.ClusterDefaults}
 	overrides.Context.Namespace = c.namespace
+	overrides.CurrentContext = c.kubeContext
 
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
 }
@@ -170,66 +236,193 @@ func GetManifests(manifests string) (files []string, err error) {
 	return s, nil
 }
 
-// OperateManifest operates manifest in k8s cluster which kind created.
-func OperateManifest(c *kubernetes.Clientset, dc dynamic.Interface, manifest string, operation apiv1.Operation) error {
+// DecodeManifest decodes all the objects declared in the manifest file.
+func DecodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
 	b, err := os.ReadFile(manifest)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return DecodeObjects(b)
+}
 
+// DecodeObjects decodes the YAML or JSON documents into the objects, such as the rendered manifests.
+func DecodeObjects(b []byte) ([]*unstructured.Unstructured, error) {
+	objects := make([]*unstructured.Unstructured, 0)
 	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(b), 100)
 	for {
 		var rawObj runtime.RawExtension
-		if err = decoder.Decode(&rawObj); err != nil {
+		if err := decoder.Decode(&rawObj); err != nil {
 			break
 		}
 
-		obj, gvk, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(rawObj.Raw, nil, nil)
+		obj, _, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(rawObj.Raw, nil, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		unstructuredObj := &unstructured.Unstructured{Object: unstructuredMap}
-		apiGroupResource, err := restmapper.GetAPIGroupResources(c.Discovery())
+		objects = append(objects, &unstructured.Unstructured{Object: unstructuredMap})
+	}
+	return objects, nil
+}
+
+// OperateManifest operates manifest in k8s cluster which kind created.
+func OperateManifest(c *K8sClusterInfo, manifest string, operation apiv1.Operation) error {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	return OperateObjects(c, objects, operation)
+}
+
+// OperateObjects operates the decoded manifest objects in k8s cluster.
+func OperateObjects(c *K8sClusterInfo, objects []*unstructured.Unstructured, operation apiv1.Operation) error {
+	return operateObjects(c, objects, operation, nil)
+}
+
+// ValidateObjects validates the decoded manifest objects by the server-side dry-run of creating them,
+// the admission and validation errors are returned without persisting the objects.
+func ValidateObjects(c *K8sClusterInfo, objects []*unstructured.Unstructured) error {
+	return operateObjects(c, objects, apiv1.Create, []string{metav1.DryRunAll})
+}
+
+func operateObjects(c *K8sClusterInfo, objects []*unstructured.Unstructured, operation apiv1.Operation, dryRun []string) error {
+	for _, unstructuredObj := range objects {
+		if operation == apiv1.Create {
+			if err := c.transform(unstructuredObj); err != nil {
+				return err
+			}
+		}
+
+		gvk := unstructuredObj.GroupVersionKind()
+		dri, err := c.resourceInterface(unstructuredObj)
 		if err != nil {
 			return err
 		}
 
-		mapper := restmapper.NewDiscoveryRESTMapper(apiGroupResource)
-		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		switch operation {
+		case apiv1.Create:
+			err = retry.OnError(webhookRetryBackoff, isWebhookNotReady, func() error {
+				_, createErr := dri.Create(context.Background(), unstructuredObj, metav1.CreateOptions{DryRun: dryRun})
+				if isWebhookNotReady(createErr) {
+					logger.Log.Warnf("the admission webhook is not ready when creating %s %s, retrying: %v",
+						gvk.Kind, unstructuredObj.GetName(), createErr)
+				}
+				return createErr
+			})
+		case apiv1.Delete:
+			err = dri.Delete(context.Background(), unstructuredObj.GetName(), metav1.DeleteOptions{DryRun: dryRun})
+		}
+
 		if err != nil {
 			return err
 		}
+	}
 
-		var dri dynamic.ResourceInterface
-		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-			if unstructuredObj.GetNamespace() == "" {
-				unstructuredObj.SetNamespace(metav1.NamespaceDefault)
-			}
-			dri = dc.Resource(mapping.Resource).Namespace(unstructuredObj.GetNamespace())
-		} else {
-			dri = dc.Resource(mapping.Resource)
+	return nil
+}
+
+// ApplyOptions are the options of the server-side apply, the fields owned by other field managers, such as the controllers,
+// conflict with the applied fields unless Force is true, which takes over the ownership of the conflicting fields.
+type ApplyOptions struct {
+	FieldManager string
+	Force        bool
+}
+
+// ApplyManifest applies the objects declared in the manifest file by the server-side apply.
+func ApplyManifest(c *K8sClusterInfo, manifest string, options ApplyOptions) error {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	return ApplyObjects(c, objects, options)
+}
+
+// ApplyObjects applies the decoded manifest objects by the server-side apply, the objects are created if they don't exist,
+// otherwise the fields managed by the field manager are updated.
+func ApplyObjects(c *K8sClusterInfo, objects []*unstructured.Unstructured, options ApplyOptions) error {
+	patchOptions := applyPatchOptions(options)
+	for _, unstructuredObj := range objects {
+		if err := c.transform(unstructuredObj); err != nil {
+			return err
 		}
 
-		switch operation {
-		case apiv1.Create:
-			_, err = dri.Create(context.Background(), unstructuredObj, metav1.CreateOptions{})
-		case apiv1.Delete:
-			err = dri.Delete(context.Background(), unstructuredObj.GetName(), metav1.DeleteOptions{})
+		gvk := unstructuredObj.GroupVersionKind()
+		dri, err := c.resourceInterface(unstructuredObj)
+		if err != nil {
+			return err
+		}
+		data, err := unstructuredObj.MarshalJSON()
+		if err != nil {
+			return err
 		}
 
+		err = retry.OnError(webhookRetryBackoff, isWebhookNotReady, func() error {
+			_, applyErr := dri.Patch(context.Background(), unstructuredObj.GetName(), types.ApplyPatchType, data, patchOptions)
+			if isWebhookNotReady(applyErr) {
+				logger.Log.Warnf("the admission webhook is not ready when applying %s %s, retrying: %v",
+					gvk.Kind, unstructuredObj.GetName(), applyErr)
+			}
+			return applyErr
+		})
+		if apierrors.IsConflict(err) {
+			return fmt.Errorf("failed to apply %s %s by field manager %s, set force to take over the conflicting fields: %w",
+				gvk.Kind, unstructuredObj.GetName(), patchOptions.FieldManager, err)
+		}
 		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
+// applyPatchOptions converts the apply options to the patch options, the field manager defaults to the one of e2e,
+// so that the fields applied by the different steps and runs are owned by the same stable manager.
+func applyPatchOptions(options ApplyOptions) metav1.PatchOptions {
+	fieldManager := options.FieldManager
+	if fieldManager == "" {
+		fieldManager = constant.DefaultFieldManager
+	}
+	force := options.Force
+	return metav1.PatchOptions{FieldManager: fieldManager, Force: &force}
+}
+
+// transform mutates the object by the transforms of the cluster before it's created or applied.
+func (c *K8sClusterInfo) transform(obj *unstructured.Unstructured) error {
+	for _, transform := range c.transforms {
+		if err := transform(obj); err != nil {
+			return fmt.Errorf("failed to transform %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		}
+	}
 	return nil
 }
 
+// resourceInterface returns the dynamic client of the object's resource, the namespaced objects
+// without namespace are put in the default namespace.
+func (c *K8sClusterInfo) resourceInterface(obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	mapping, err := c.discovery.restMapping(obj.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(metav1.NamespaceDefault)
+		}
+		return c.Interface.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+	}
+	return c.Interface.Resource(mapping.Resource), nil
+}
+
+// isWebhookNotReady checks whether the error is caused by failing to call the admission webhook, such as the webhook
+// service is not serving or its certificate is not injected yet, which is transient and succeeds on retry.
+// The requests denied by the webhooks are permanent and not treated as not ready.
+func isWebhookNotReady(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "failed calling webhook")
+}
+
 func GetKindClusterName(kindConfigFilePath string) (name string, err error) {
 	data, err := os.ReadFile(kindConfigFilePath)
 	if err != nil {
@@ -249,3 +442,44 @@ func GetKindClusterName(kindConfigFilePath string) (name string, err error) {
 
 	return nameConfig.Name, nil
 }
+
+// GetKindNodeImages returns the images of all the nodes in the kind config file, the empty image is the default one of the kind.
+func GetKindNodeImages(kindConfigFilePath string) ([]string, error) {
+	data, err := os.ReadFile(kindConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := v1alpha4.Cluster{}
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(data), 100)
+	if err := decoder.Decode(&cluster); err != nil {
+		return nil, err
+	}
+
+	images := make([]string, 0, len(cluster.Nodes))
+	for i := range cluster.Nodes {
+		images = append(images, cluster.Nodes[i].Image)
+	}
+	return images, nil
+}
+
+// GetKindPortMappings returns the `extraPortMappings` of all the nodes in the kind config file,
+// which map the ports of the node containers to the host.
+func GetKindPortMappings(kindConfigFilePath string) ([]v1alpha4.PortMapping, error) {
+	data, err := os.ReadFile(kindConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := v1alpha4.Cluster{}
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(data), 100)
+	if err := decoder.Decode(&cluster); err != nil {
+		return nil, err
+	}
+
+	mappings := make([]v1alpha4.PortMapping, 0)
+	for i := range cluster.Nodes {
+		mappings = append(mappings, cluster.Nodes[i].ExtraPortMappings...)
+	}
+	return mappings, nil
+}
@@ -0,0 +1,196 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestIsWebhookNotReady(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil error", err: nil, want: false},
+		{
+			name: "webhook connection refused",
+			err: errors.New(`Internal error occurred: failed calling webhook "webhook.cert-manager.io": ` +
+				`Post "https://cert-manager-webhook.cert-manager.svc:443/mutate?timeout=10s": dial tcp 10.96.0.10:443: connect: connection refused`),
+			want: true,
+		},
+		{
+			name: "webhook certificate not injected",
+			err: errors.New(`Internal error occurred: failed calling webhook "validation.istio.io": ` +
+				`Post "https://istiod.istio-system.svc:443/validate?timeout=10s": x509: certificate signed by unknown authority`),
+			want: true,
+		},
+		{
+			name: "request denied by webhook",
+			err:  errors.New(`admission webhook "validation.istio.io" denied the request: configuration is invalid`),
+			want: false,
+		},
+		{
+			name: "validation error",
+			err:  errors.New(`Deployment.apps "foo" is invalid: spec.replicas: Invalid value: -1`),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isWebhookNotReady(tt.err); got != tt.want {
+				t.Errorf("isWebhookNotReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatchOptions(t *testing.T) {
+	tests := []struct {
+		name             string
+		options          ApplyOptions
+		wantFieldManager string
+		wantForce        bool
+	}{
+		{name: "default field manager", options: ApplyOptions{}, wantFieldManager: "skywalking-infra-e2e", wantForce: false},
+		{name: "custom field manager", options: ApplyOptions{FieldManager: "operator"}, wantFieldManager: "operator", wantForce: false},
+		{name: "force", options: ApplyOptions{Force: true}, wantFieldManager: "skywalking-infra-e2e", wantForce: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyPatchOptions(tt.options)
+			if got.FieldManager != tt.wantFieldManager {
+				t.Errorf("applyPatchOptions() field manager = %v, want %v", got.FieldManager, tt.wantFieldManager)
+			}
+			if got.Force == nil || *got.Force != tt.wantForce {
+				t.Errorf("applyPatchOptions() force = %v, want %v", got.Force, tt.wantForce)
+			}
+		})
+	}
+}
+
+func TestDiscoveryCacheRESTMapping(t *testing.T) {
+	var requests, crdCreated atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		groups := &metav1.APIGroupList{}
+		if crdCreated.Load() > 0 {
+			version := metav1.GroupVersionForDiscovery{GroupVersion: "example.com/v1", Version: "v1"}
+			groups.Groups = []metav1.APIGroup{{Name: "example.com", Versions: []metav1.GroupVersionForDiscovery{version}, PreferredVersion: version}}
+		}
+		responses := map[string]any{
+			"/api":  &metav1.APIVersions{Versions: []string{"v1"}},
+			"/apis": groups,
+			"/api/v1": &metav1.APIResourceList{GroupVersion: "v1",
+				APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}}},
+			"/apis/example.com/v1": &metav1.APIResourceList{GroupVersion: "example.com/v1",
+				APIResources: []metav1.APIResource{{Name: "foos", Kind: "Foo", Namespaced: true}}},
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client, err := discovery.NewDiscoveryClientForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := newDiscoveryCache(client)
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	foo := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Foo"}
+
+	if _, err := cache.restMapping(configMap); err != nil {
+		t.Fatalf("restMapping() error = %v", err)
+	}
+	discovered := requests.Load()
+	if _, err := cache.restMapping(configMap); err != nil {
+		t.Fatalf("restMapping() error = %v", err)
+	}
+	if got := requests.Load(); got != discovered {
+		t.Errorf("restMapping() requested the discovery %d times again, should use the cache", got-discovered)
+	}
+
+	if _, err := cache.restMapping(foo); err == nil {
+		t.Errorf("restMapping() should fail before the CRD is created")
+	}
+	crdCreated.Store(1)
+	mapping, err := cache.restMapping(foo)
+	if err != nil {
+		t.Fatalf("restMapping() should refresh the cache after the CRD is created, error = %v", err)
+	}
+	if mapping.Resource.Resource != "foos" {
+		t.Errorf("restMapping() resource = %v, want foos", mapping.Resource)
+	}
+}
+
+func TestKubeConfigWithContext(t *testing.T) {
+	dir := t.TempDir()
+	kubeConfigPath := filepath.Join(dir, "config")
+	kubeConfig := clientcmdapi.NewConfig()
+	kubeConfig.Clusters["dev"] = &clientcmdapi.Cluster{Server: "https://dev:6443"}
+	kubeConfig.Clusters["prod"] = &clientcmdapi.Cluster{Server: "https://prod:6443"}
+	kubeConfig.Contexts["dev"] = &clientcmdapi.Context{Cluster: "dev"}
+	kubeConfig.Contexts["prod"] = &clientcmdapi.Context{Cluster: "prod"}
+	kubeConfig.CurrentContext = "prod"
+	if err := clientcmd.WriteToFile(*kubeConfig, kubeConfigPath); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "context.config")
+	if err := KubeConfigWithContext(kubeConfigPath, "absent", path); err == nil {
+		t.Errorf("KubeConfigWithContext() should fail with the absent context")
+	}
+	if err := KubeConfigWithContext(kubeConfigPath, "dev", path); err != nil {
+		t.Fatalf("KubeConfigWithContext() error = %v", err)
+	}
+	got, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CurrentContext != "dev" {
+		t.Errorf("KubeConfigWithContext() current context = %s, want dev", got.CurrentContext)
+	}
+	// the copy contains the credentials
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("KubeConfigWithContext() file mode = %v, want %v", info.Mode().Perm(), os.FileMode(0o600))
+	}
+}
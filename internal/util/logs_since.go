@@ -0,0 +1,75 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apache/skywalking-infra-e2e/internal/constant"
+)
+
+// SaveTriggerStartTime records the time when the trigger starts, so that the logs exported on failure could start from it.
+func SaveTriggerStartTime(file string, t time.Time) error {
+	return os.WriteFile(file, []byte(t.Format(time.RFC3339Nano)), 0o600)
+}
+
+// ResetTriggerStartTime removes the trigger start time of the previous run, so that it's not taken as the one of this run.
+func ResetTriggerStartTime(file string) error {
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// LogsSinceTime resolves the start time of the logs exported on failure, the zero time means the whole logs,
+// which is also the case of `trigger` if the trigger hasn't started, such as the setup fails.
+func LogsSinceTime(since, triggerStartFile string, now time.Time) (time.Time, error) {
+	switch since {
+	case "", constant.LogsSinceAll:
+		return time.Time{}, nil
+	case constant.LogsSinceTrigger:
+		data, err := os.ReadFile(triggerStartFile)
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse the trigger start time in %s: %v", triggerStartFile, err)
+		}
+		return t, nil
+	}
+
+	if duration, err := time.ParseDuration(since); err == nil {
+		if duration <= 0 {
+			return time.Time{}, fmt.Errorf("--since should be > 0, but was %s", since)
+		}
+		return now.Add(-duration), nil
+	}
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported --since %s, should be %s, %s, a duration such as 10m or a RFC3339 timestamp",
+		since, constant.LogsSinceTrigger, constant.LogsSinceAll)
+}
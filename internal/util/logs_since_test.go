@@ -0,0 +1,83 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package util
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogsSinceTime(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	triggerStart := now.Add(-3 * time.Minute)
+	dir := t.TempDir()
+	triggerStartFile := filepath.Join(dir, "trigger-start")
+	if err := SaveTriggerStartTime(triggerStartFile, triggerStart); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		since            string
+		triggerStartFile string
+		want             time.Time
+		wantErr          bool
+	}{
+		{name: "empty", since: "", want: time.Time{}},
+		{name: "all", since: "all", want: time.Time{}},
+		{name: "trigger", since: "trigger", triggerStartFile: triggerStartFile, want: triggerStart},
+		{name: "trigger not started", since: "trigger", triggerStartFile: filepath.Join(dir, "absent"), want: time.Time{}},
+		{name: "duration", since: "10m", want: now.Add(-10 * time.Minute)},
+		{name: "timestamp", since: "2021-10-01T11:30:00Z", want: now.Add(-30 * time.Minute)},
+		{name: "negative duration", since: "-1m", wantErr: true},
+		{name: "invalid", since: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LogsSinceTime(tt.since, tt.triggerStartFile, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LogsSinceTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("LogsSinceTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetTriggerStartTime(t *testing.T) {
+	triggerStartFile := filepath.Join(t.TempDir(), "trigger-start")
+	if err := SaveTriggerStartTime(triggerStartFile, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// resetting twice covers the file of the previous run and no previous run
+	for i := 0; i < 2; i++ {
+		if err := ResetTriggerStartTime(triggerStartFile); err != nil {
+			t.Fatalf("ResetTriggerStartTime() error = %v", err)
+		}
+	}
+	got, err := LogsSinceTime("trigger", triggerStartFile, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsZero() {
+		t.Errorf("LogsSinceTime() = %v after the reset, want the zero time", got)
+	}
+}
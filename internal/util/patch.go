@@ -0,0 +1,153 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ObjectTransform mutates the object of the manifests before it's created, such as setting the run-specific image tag.
+type ObjectTransform func(obj *unstructured.Unstructured) error
+
+// SetField sets the value of the field path in the object, such as `spec.template.spec.containers[0].image`,
+// the keys containing dots are quoted like `metadata.annotations['sidecar.istio.io/inject']`.
+// The missing maps on the path are created, while the list elements must exist.
+// The value is converted to the JSON compatible types of the unstructured objects, such as the values decoded from YAML.
+func SetField(obj map[string]any, path string, value any) error {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("empty field path")
+	}
+	if _, ok := segments[0].(string); !ok {
+		return fmt.Errorf("field path %s should start with a key", path)
+	}
+	return setField(obj, segments, jsonValue(value), path)
+}
+
+// jsonValue converts the integers to int64 and the maps to map[string]any, which are deep copyable in the unstructured objects.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = jsonValue(item)
+		}
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = jsonValue(item)
+		}
+		return m
+	case []any:
+		list := make([]any, 0, len(v))
+		for _, item := range v {
+			list = append(list, jsonValue(item))
+		}
+		return list
+	}
+	return value
+}
+
+func setField(data any, segments []any, value any, path string) error {
+	segment, rest := segments[0], segments[1:]
+	switch key := segment.(type) {
+	case string:
+		m, ok := data.(map[string]any)
+		if !ok {
+			return fmt.Errorf("field %s of path %s is not in an object", key, path)
+		}
+		if len(rest) == 0 {
+			m[key] = value
+			return nil
+		}
+		if m[key] == nil {
+			if _, isIndex := rest[0].(int); isIndex {
+				return fmt.Errorf("list %s of path %s doesn't exist", key, path)
+			}
+			m[key] = make(map[string]any)
+		}
+		return setField(m[key], rest, value, path)
+	case int:
+		list, ok := data.([]any)
+		if !ok {
+			return fmt.Errorf("index [%d] of path %s is not in a list", key, path)
+		}
+		if key < 0 || key >= len(list) {
+			return fmt.Errorf("index [%d] of path %s is out of range, the length is %d", key, path, len(list))
+		}
+		if len(rest) == 0 {
+			list[key] = value
+			return nil
+		}
+		return setField(list[key], rest, value, path)
+	}
+	return nil
+}
+
+// parseFieldPath parses the field path into the keys and the list indexes, the leading `$.` or `.` is optional.
+func parseFieldPath(path string) ([]any, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	segments := make([]any, 0)
+	for len(p) > 0 {
+		switch {
+		case p[0] == '.':
+			p = p[1:]
+		case strings.HasPrefix(p, "['") || strings.HasPrefix(p, `["`):
+			end := strings.Index(p[2:], string(p[1])+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed quoted key in field path %s", path)
+			}
+			segments = append(segments, p[2:2+end])
+			p = p[2+end+2:]
+		case p[0] == '[':
+			end := strings.Index(p, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed index in field path %s", path)
+			}
+			index, err := strconv.Atoi(p[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %s in field path %s", p[:end+1], path)
+			}
+			segments = append(segments, index)
+			p = p[end+1:]
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			segments = append(segments, p[:end])
+			p = p[end:]
+		}
+	}
+	return segments, nil
+}
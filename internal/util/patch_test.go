@@ -0,0 +1,104 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSetField(t *testing.T) {
+	newDeployment := func() map[string]any {
+		return map[string]any{
+			"metadata": map[string]any{"name": "oap"},
+			"spec": map[string]any{
+				"template": map[string]any{
+					"spec": map[string]any{
+						"containers": []any{map[string]any{"name": "oap", "image": "oap:latest"}},
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		path    string
+		value   any
+		want    func(obj map[string]any)
+		wantErr bool
+	}{
+		{
+			name:  "list element",
+			path:  "spec.template.spec.containers[0].image",
+			value: "oap:abc123",
+			want: func(obj map[string]any) {
+				obj["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)[0].(map[string]any)["image"] = "oap:abc123"
+			},
+		},
+		{
+			name:  "quoted key with the missing map",
+			path:  "$.metadata.annotations['sidecar.istio.io/inject']",
+			value: "false",
+			want: func(obj map[string]any) {
+				obj["metadata"].(map[string]any)["annotations"] = map[string]any{"sidecar.istio.io/inject": "false"}
+			},
+		},
+		{
+			name:  "integer value",
+			path:  "spec.replicas",
+			value: 2,
+			want: func(obj map[string]any) {
+				obj["spec"].(map[string]any)["replicas"] = int64(2)
+			},
+		},
+		{
+			name:  "map value",
+			path:  "metadata.labels",
+			value: map[any]any{"app": "oap"},
+			want: func(obj map[string]any) {
+				obj["metadata"].(map[string]any)["labels"] = map[string]any{"app": "oap"}
+			},
+		},
+		{name: "index out of range", path: "spec.template.spec.containers[1].image", value: "oap", wantErr: true},
+		{name: "missing list", path: "spec.template.spec.initContainers[0].image", value: "oap", wantErr: true},
+		{name: "key of a list", path: "spec.template.spec.containers.image", value: "oap", wantErr: true},
+		{name: "unclosed quote", path: "metadata.annotations['foo", value: "oap", wantErr: true},
+		{name: "invalid index", path: "spec.template.spec.containers[a].image", value: "oap", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newDeployment()
+			err := SetField(obj, tt.path, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetField() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := newDeployment()
+			tt.want(want)
+			if !cmp.Equal(obj, want) {
+				t.Errorf("SetField() diff (-want +got):\n%s", cmp.Diff(want, obj))
+			}
+			// the unstructured objects are deep copied when they're created
+			(&unstructured.Unstructured{Object: obj}).DeepCopy()
+		})
+	}
+}
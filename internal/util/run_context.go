@@ -0,0 +1,61 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+//
+
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	runContextLock sync.RWMutex
+	// runContext is cancelled when the deadline of the run is hit, the blocking operations of the setup, trigger and verify,
+	// such as the sleeps and the waits, are interrupted by it.
+	runContext = context.Background()
+)
+
+// SetRunContext sets the context of the run, which is restored to the previous one by the returned function.
+func SetRunContext(ctx context.Context) (reset func()) {
+	runContextLock.Lock()
+	defer runContextLock.Unlock()
+	previous := runContext
+	runContext = ctx
+	return func() {
+		SetRunContext(previous)
+	}
+}
+
+// RunContext returns the context of the run, which is never cancelled if the run has no deadline.
+func RunContext() context.Context {
+	runContextLock.RLock()
+	defer runContextLock.RUnlock()
+	return runContext
+}
+
+// Sleep waits for the duration, returns the error of the run context if it's cancelled before that.
+func Sleep(duration time.Duration) error {
+	ctx := RunContext()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(duration):
+		return nil
+	}
+}
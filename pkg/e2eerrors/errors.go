@@ -0,0 +1,107 @@
+//
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package e2eerrors defines the error types returned by the e2e phases,
+// so that the callers could use errors.As to distinguish the error kinds.
+package e2eerrors
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SetupError is returned when the environment could not be set up.
+type SetupError struct {
+	// Env is the environment type, such as kind or compose.
+	Env string
+	// Environment is the name of the environment in the multi-environment run, empty for the single setup.
+	Environment string
+	Err         error
+}
+
+func (e *SetupError) Error() string {
+	if e.Environment != "" {
+		return fmt.Sprintf("environment %s: %v", e.Environment, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *SetupError) Unwrap() error { return e.Err }
+
+// StepError is returned when a step of the setup fails, it wraps the error of the step.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step [%s]: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// WaitTimeoutError is returned when the resource doesn't meet the condition before the timeout.
+type WaitTimeoutError struct {
+	Resource  string
+	Condition string
+	Timeout   time.Duration
+}
+
+func (e *WaitTimeoutError) Error() string {
+	return fmt.Sprintf("wait for %s %s timeout after %d seconds", e.Resource, e.Condition, int(e.Timeout.Seconds()))
+}
+
+// VerifyMismatchError is returned when the actual data of the case doesn't match the expected data.
+type VerifyMismatchError struct {
+	// Case is the source of the actual data, such as the query or the actual file.
+	Case string
+	Diff string
+	// Stderr is the captured stderr of the query, which is not matched but helps to find out the cause.
+	Stderr string
+}
+
+func (e *VerifyMismatchError) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("failed to verify the output: %s, error:\n%v\nstderr of the query:\n%s", e.Case, e.Diff, e.Stderr)
+	}
+	return fmt.Sprintf("failed to verify the output: %s, error:\n%v", e.Case, e.Diff)
+}
+
+// VerifyError is returned when some of the verify cases failed, it wraps the errors of the failed cases.
+type VerifyError struct {
+	Errs []error
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("failed to verify %d case(s)", len(e.Errs))
+}
+
+func (e *VerifyError) Unwrap() []error { return e.Errs }
+
+// IsTimeout checks whether the error is caused by the wait timeout.
+func IsTimeout(err error) bool {
+	var timeoutErr *WaitTimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// IsMismatch checks whether the error is caused by the verify mismatch.
+func IsMismatch(err error) bool {
+	var mismatchErr *VerifyMismatchError
+	return errors.As(err, &mismatchErr)
+}
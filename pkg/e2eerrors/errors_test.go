@@ -0,0 +1,76 @@
+//
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package e2eerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorKinds(t *testing.T) {
+	timeout := &WaitTimeoutError{Resource: "manifest", Condition: "ready", Timeout: time.Minute}
+	mismatch := &VerifyMismatchError{Case: "echo foo", Diff: "mismatch"}
+
+	tests := []struct {
+		name         string
+		err          error
+		wantTimeout  bool
+		wantMismatch bool
+	}{
+		{
+			name:        "should find the timeout in setup error",
+			err:         fmt.Errorf("[Setup] %w", &SetupError{Env: "kind", Err: timeout}),
+			wantTimeout: true,
+		},
+		{
+			name:        "should find the timeout in step error",
+			err:         &SetupError{Env: "kind", Err: &StepError{Step: "deploy oap", Err: timeout}},
+			wantTimeout: true,
+		},
+		{
+			name:         "should find the mismatch in verify error",
+			err:          &VerifyError{Errs: []error{errors.New("failed to execute the query"), mismatch}},
+			wantMismatch: true,
+		},
+		{
+			name: "should not match the plain error",
+			err:  errors.New("setup timeout"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTimeout(tt.err); got != tt.wantTimeout {
+				t.Errorf("IsTimeout() = %v, want %v", got, tt.wantTimeout)
+			}
+			if got := IsMismatch(tt.err); got != tt.wantMismatch {
+				t.Errorf("IsMismatch() = %v, want %v", got, tt.wantMismatch)
+			}
+		})
+	}
+
+	if got := timeout.Error(); got != "wait for manifest ready timeout after 60 seconds" {
+		t.Errorf("WaitTimeoutError.Error() = %v", got)
+	}
+	mismatch.Stderr = "warning"
+	if got := mismatch.Error(); got != "failed to verify the output: echo foo, error:\nmismatch\nstderr of the query:\nwarning" {
+		t.Errorf("VerifyMismatchError.Error() = %v", got)
+	}
+}
@@ -39,6 +39,11 @@ type YamlCaseResult struct {
 	PassedCount  int `yaml:"passedCount"`
 	FailedCount  int `yaml:"failedCount"`
 	SkippedCount int `yaml:"skippedCount"`
+	// Retries are the retry counts of the cases which consumed any retry, keyed by the case name.
+	Retries map[string]int `yaml:"retries,omitempty"`
+	// SoftFailed are the failed soft cases, which are not counted in the failed cases.
+	SoftFailed      []string `yaml:"softFailed,omitempty"`
+	SoftFailedCount int      `yaml:"softFailedCount,omitempty"`
 }
 
 func HasFormat() bool {
@@ -46,6 +51,34 @@ func HasFormat() bool {
 	return ok
 }
 
+// PassedAfterRetries returns the passed cases which consumed any retry, such as the latent flaky cases
+// which barely pass within the retry strategy.
+func PassedAfterRetries(caseRes []*CaseResult) []*CaseResult {
+	retried := make([]*CaseResult, 0)
+	for _, cr := range caseRes {
+		if !cr.Skip && cr.Err == nil && cr.Retries > 0 {
+			retried = append(retried, cr)
+		}
+	}
+	return retried
+}
+
+// SoftFailed returns the failed cases whose failures are only reported, which don't fail the verification.
+func SoftFailed(caseRes []*CaseResult) []*CaseResult {
+	failed := make([]*CaseResult, 0)
+	for _, cr := range caseRes {
+		if !cr.Skip && cr.Err != nil && cr.Soft {
+			failed = append(failed, cr)
+		}
+	}
+	return failed
+}
+
+// SoftFailure formats the error of the failed soft case, which is reported as a warning instead of a failure.
+func SoftFailure(err error) string {
+	return fmt.Sprintf("[soft] %v", err)
+}
+
 func PrintResult(caseRes []*CaseResult) {
 	if Format == "yaml" {
 		printResultInYAML(caseRes)
@@ -58,9 +91,17 @@ func printResultInYAML(caseRes []*CaseResult) {
 		if !cr.Skip {
 			if cr.Err == nil {
 				yamlCaseResult.Passed = append(yamlCaseResult.Passed, cr.Name)
+			} else if cr.Soft {
+				yamlCaseResult.SoftFailed = append(yamlCaseResult.SoftFailed, cr.Name)
 			} else {
 				yamlCaseResult.Failed = append(yamlCaseResult.Failed, cr.Name)
 			}
+			if cr.Retries > 0 {
+				if yamlCaseResult.Retries == nil {
+					yamlCaseResult.Retries = make(map[string]int)
+				}
+				yamlCaseResult.Retries[cr.Name] = cr.Retries
+			}
 		} else {
 			yamlCaseResult.Skipped = append(yamlCaseResult.Skipped, cr.Name)
 		}
@@ -69,6 +110,7 @@ func printResultInYAML(caseRes []*CaseResult) {
 	yamlCaseResult.PassedCount = len(yamlCaseResult.Passed)
 	yamlCaseResult.FailedCount = len(yamlCaseResult.Failed)
 	yamlCaseResult.SkippedCount = len(yamlCaseResult.Skipped)
+	yamlCaseResult.SoftFailedCount = len(yamlCaseResult.SoftFailed)
 
 	yamlCaseResultData, _ := yaml.Marshal(yamlCaseResult)
 	fmt.Println(string(yamlCaseResultData))
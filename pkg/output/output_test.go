@@ -0,0 +1,51 @@
+// Licensed to Apache Software Foundation (ASF) under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Apache Software Foundation (ASF) licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package output
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPassedAfterRetries(t *testing.T) {
+	caseRes := []*CaseResult{
+		{Name: "passed"},
+		{Name: "barely passed", Retries: 29},
+		{Name: "failed", Retries: 30, Err: errors.New("mismatch")},
+		{Name: "skipped", Skip: true},
+	}
+
+	retried := PassedAfterRetries(caseRes)
+	if len(retried) != 1 || retried[0].Name != "barely passed" || retried[0].Retries != 29 {
+		t.Errorf("PassedAfterRetries() = %v, want the barely passed case", retried)
+	}
+}
+
+func TestSoftFailed(t *testing.T) {
+	caseRes := []*CaseResult{
+		{Name: "passed", Soft: true},
+		{Name: "failed", Err: errors.New("mismatch")},
+		{Name: "soft failed", Soft: true, Err: errors.New("mismatch")},
+		{Name: "soft skipped", Soft: true, Skip: true},
+	}
+
+	softFailed := SoftFailed(caseRes)
+	if len(softFailed) != 1 || softFailed[0].Name != "soft failed" {
+		t.Errorf("SoftFailed() = %v, want the soft failed case", softFailed)
+	}
+}
@@ -29,6 +29,10 @@ type CaseResult struct {
 	Msg  string
 	Err  error
 	Skip bool
+	// Retries is the count of the retries the case consumed before passing or failing.
+	Retries int
+	// Soft is true if the failure of the case is only reported, which doesn't fail the verification.
+	Soft bool
 }
 
 type Printer interface {
@@ -132,6 +136,11 @@ func (p *printer) PrintResult(caseRes []*CaseResult) (passNum, failNum, skipNum
 				if p.batchOutput {
 					p.spinner.Success(cr.Msg)
 				}
+			} else if cr.Soft {
+				if p.batchOutput {
+					p.spinner.Warning(cr.Msg)
+					p.spinner.Warning(SoftFailure(cr.Err))
+				}
 			} else {
 				failNum++
 				if p.batchOutput {
@@ -156,6 +165,18 @@ func (p *printer) PrintResult(caseRes []*CaseResult) (passNum, failNum, skipNum
 	}
 	pterm.Info.WithMessageStyle(&pterm.Style{pterm.FgLightRed}).Println(fmt.Sprintf("%d failed", failNum))
 	pterm.Info.WithMessageStyle(&pterm.Style{pterm.FgYellow}).Println(fmt.Sprintf("%d skipped", skipNum))
+	if softFailed := SoftFailed(caseRes); len(softFailed) > 0 {
+		pterm.Info.WithMessageStyle(&pterm.Style{pterm.FgYellow}).Println(fmt.Sprintf("%d soft failed", len(softFailed)))
+		for _, cr := range softFailed {
+			pterm.Info.WithMessageStyle(&pterm.Style{pterm.FgYellow}).Println(fmt.Sprintf("  %s", cr.Name))
+		}
+	}
+	if retried := PassedAfterRetries(caseRes); len(retried) > 0 {
+		pterm.Info.WithMessageStyle(&pterm.Style{pterm.FgYellow}).Println(fmt.Sprintf("%d passed after retries", len(retried)))
+		for _, cr := range retried {
+			pterm.Info.WithMessageStyle(&pterm.Style{pterm.FgYellow}).Println(fmt.Sprintf("  %s: retried %d time(s)", cr.Name, cr.Retries))
+		}
+	}
 	fmt.Println()
 
 	return passNum, failNum, skipNum